@@ -2,10 +2,46 @@ package config
 
 const (
 	RouteNotSupported      = "Route is not supported yet."
+	MethodNotAllowed       = "Method not allowed."
 	BookcoverNotFound      = "Bookcover was not found."
 	InvalidISBN            = "Invalid ISBN (please use ISBN-13)"
 	ErrorReadingBody       = "An error occurred while reading body of the request."
 	InternalServerError    = "Internal server error. Please, try again later."
 	MandidatoryParamsMissing = "There are mandatory parameters missing."
 	ConflictingParams        = "Cannot combine isbn with book_title/author_name parameters."
+	InvalidImageSize         = "Invalid image_size (use small, medium or large)."
+	InvalidImageDimensions   = "Invalid width/height (use a whole number between 1 and 2000)."
+	InvalidFit               = "Invalid fit (use contain, or pad together with width and height)."
+	ConflictingImageParams   = "Cannot combine image_size with width/height parameters."
+	ProviderUnavailable      = "Cover providers are temporarily unavailable. Please, try again later."
+	ProviderBusy             = "Too many lookups in progress. Please, try again later."
+	ProviderBlocked          = "The cover provider is refusing lookups right now. Please, try again later."
+	ProviderLayoutChanged    = "The cover provider returned a page that could not be understood."
+	ImageHostNotAllowed      = "The cover is hosted on a domain that cannot be proxied."
+	ImageTooLarge            = "The cover image is too large to be proxied."
+	ImageFetchFailed         = "The cover image could not be downloaded."
+	ImageConversionFailed    = "The cover image could not be converted to the requested size or format."
+	InvalidFormat            = "Invalid format (use jpeg or png)."
+	InvalidRedirect          = "Invalid redirect (use true or false)."
+	InvalidFallback          = "Invalid fallback (use placeholder)."
+	FallbackNeedsRedirect    = "fallback=placeholder requires redirect=true."
+	InvalidInclude           = "Invalid include (use image_info)."
+	InvalidBatch             = "Invalid batch (send a JSON array of objects with isbn, or book_title and author_name)."
+	BatchTooLarge            = "Too many items in the batch."
+	BatchLookupCanceled      = "The batch was canceled before this item was looked up."
+	UnsupportedBulkType      = "Unsupported Content-Type (use application/x-ndjson or text/csv)."
+	InvalidCSVHeader         = "Invalid CSV header (name the isbn, or book_title and author_name columns)."
+	InvalidBulkLine          = "Invalid line (use a JSON object with isbn, or book_title and author_name)."
+	BulkLineTooLong          = "Line is too long."
+	InvalidJob               = "Invalid job (send a JSON object with a non-empty items array)."
+	JobTooLarge              = "Too many items in the job."
+	InvalidCallbackURL       = "Invalid callback_url (use an http or https URL)."
+	CallbacksDisabled        = "Callbacks are not enabled on this server."
+	JobQueueFull             = "Too many jobs are queued. Please, try again later."
+	JobNotFound              = "Job was not found."
+	InvalidGraphQLRequest    = "Invalid GraphQL request (send a query, with optional operationName and variables)."
+	GraphQLTooManyLookups    = "Too many books in one query."
+	RateLimitExceeded        = "Rate limit exceeded"
+	EmptyGRPCBatch           = "The batch has no items."
+	GRPCUnauthenticated      = "Missing or invalid API key."
 )
@@ -0,0 +1,160 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"strconv"
+	"time"
+
+	"bookcover-api/internal/cache"
+	"bookcover-api/internal/config"
+	"bookcover-api/internal/metrics"
+	"bookcover-api/internal/middleware"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Interceptor wraps unary and streaming calls alike, the way a
+// middleware.Middleware wraps HTTP handlers.
+type Interceptor struct {
+	Unary  grpc.UnaryServerInterceptor
+	Stream grpc.StreamServerInterceptor
+}
+
+// Chain returns the server options that run the interceptors in the order
+// they are listed.
+func Chain(interceptors ...Interceptor) []grpc.ServerOption {
+	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
+	for _, i := range interceptors {
+		unary = append(unary, i.Unary)
+		stream = append(stream, i.Stream)
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+}
+
+// MetricsInterceptor records the count and duration of calls by method and
+// status code.
+func MetricsInterceptor() Interceptor {
+	return Interceptor{
+		Unary: func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			start := time.Now()
+			resp, err := handler(ctx, req)
+			metrics.RecordGRPCRequest(info.FullMethod, status.Code(err).String(), time.Since(start))
+			return resp, err
+		},
+		Stream: func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			start := time.Now()
+			err := handler(srv, ss)
+			metrics.RecordGRPCRequest(info.FullMethod, status.Code(err).String(), time.Since(start))
+			return err
+		},
+	}
+}
+
+// AuthInterceptor rejects calls that do not send "authorization: Bearer
+// <apiKey>" metadata. Every call is rejected when apiKey is empty.
+func AuthInterceptor(apiKey string) Interceptor {
+	authorize := func(ctx context.Context) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if apiKey == "" || len(values) != 1 || subtle.ConstantTimeCompare([]byte(values[0]), []byte("Bearer "+apiKey)) != 1 {
+			return status.Error(codes.Unauthenticated, config.GRPCUnauthenticated)
+		}
+		return nil
+	}
+
+	return Interceptor{
+		Unary: func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := authorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		},
+		Stream: func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := authorize(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		},
+	}
+}
+
+// RateLimitInterceptor charges each call the quota returned by cost against
+// the counters of the calling address, shared with the HTTP rate limiter.
+// The remaining quota is sent as x-ratelimit-* header metadata.
+func RateLimitInterceptor(cacheClient cache.CacheClient, cfg middleware.RateLimitConfig, cost func(req any) int) Interceptor {
+	charge := func(ctx context.Context, req any) error {
+		if cfg.Unlimited {
+			return nil
+		}
+
+		quota, err := middleware.ChargeQuota(cacheClient, cfg, clientAddr(ctx), cost(req))
+		if err != nil {
+			return nil
+		}
+
+		grpc.SetHeader(ctx, metadata.Pairs(
+			"x-ratelimit-limit-daily", strconv.Itoa(quota.DailyLimit),
+			"x-ratelimit-remaining-daily", strconv.Itoa(quota.DailyRemaining),
+			"x-ratelimit-limit-monthly", strconv.Itoa(quota.MonthlyLimit),
+			"x-ratelimit-remaining-monthly", strconv.Itoa(quota.MonthlyRemaining),
+		))
+		if quota.Exceeded {
+			return status.Error(codes.ResourceExhausted, config.RateLimitExceeded)
+		}
+		return nil
+	}
+
+	return Interceptor{
+		Unary: func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := charge(ctx, req); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		},
+		Stream: func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			return handler(srv, &chargedStream{ServerStream: ss, charge: charge})
+		},
+	}
+}
+
+// chargedStream charges the first message a client sends on a stream, since
+// the cost of a call depends on it.
+type chargedStream struct {
+	grpc.ServerStream
+	charge  func(ctx context.Context, req any) error
+	charged bool
+}
+
+func (s *chargedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.charged {
+		return nil
+	}
+	s.charged = true
+	return s.charge(s.Context(), m)
+}
+
+// clientAddr is the host of the calling peer, used to key its rate limit.
+func clientAddr(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
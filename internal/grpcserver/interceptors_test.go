@@ -0,0 +1,84 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"bookcover-api/internal/middleware"
+	"bookcover-api/pkg/bookcoverpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthInterceptor(t *testing.T) {
+	client := dial(t, fakeScraper{}, testConfig())
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		code codes.Code
+	}{
+		{"no key", context.Background(), codes.Unauthenticated},
+		{"wrong key", metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer nope"), codes.Unauthenticated},
+		{"valid key", authorized(), codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetCoverByISBN(tt.ctx, &bookcoverpb.GetCoverByISBNRequest{Isbn: "9780345376596"})
+			if status.Code(err) != tt.code {
+				t.Errorf("Expected %v, got %v", tt.code, err)
+			}
+
+			stream, err := client.BatchGetCovers(tt.ctx, &bookcoverpb.BatchGetCoversRequest{Items: []*bookcoverpb.BatchItem{{Isbn: "9780345376596"}}})
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			if status.Code(err) != tt.code {
+				t.Errorf("Expected %v on the stream, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestAuthInterceptor_NoKeyConfigured(t *testing.T) {
+	client := dial(t, fakeScraper{}, Config{RateLimit: middleware.ProTier})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer ")
+	_, err := client.GetCoverByISBN(ctx, &bookcoverpb.GetCoverByISBNRequest{Isbn: "9780345376596"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated, got %v", err)
+	}
+}
+
+func TestRateLimitInterceptor(t *testing.T) {
+	cfg := testConfig()
+	cfg.RateLimit = middleware.RateLimitConfig{DailyLimit: 3, MonthlyLimit: 100}
+	client := dial(t, fakeScraper{}, cfg)
+
+	var header metadata.MD
+	_, err := client.GetCoverByISBN(authorized(), &bookcoverpb.GetCoverByISBNRequest{Isbn: "9780345376596"}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := header.Get("x-ratelimit-remaining-daily"); len(got) != 1 || got[0] != "2" {
+		t.Errorf("Expected 2 calls remaining, got %v", got)
+	}
+
+	// A batch is charged per item, so three more go over the limit.
+	stream, err := client.BatchGetCovers(authorized(), &bookcoverpb.BatchGetCoversRequest{
+		Items: []*bookcoverpb.BatchItem{{Isbn: "9780345376596"}, {Isbn: "9780345376597"}, {Isbn: "9780345376598"}},
+	})
+	if err == nil {
+		for err == nil {
+			_, err = stream.Recv()
+		}
+	}
+	if errors.Is(err, io.EOF) || status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted, got %v", err)
+	}
+}
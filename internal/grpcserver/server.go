@@ -0,0 +1,278 @@
+package grpcserver
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+
+	"bookcover-api/internal/cache"
+	"bookcover-api/internal/config"
+	"bookcover-api/internal/handler"
+	"bookcover-api/internal/middleware"
+	"bookcover-api/internal/service"
+	"bookcover-api/pkg/bookcoverpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultPort is where the gRPC server listens unless GRPC_PORT is set.
+const DefaultPort = 9000
+
+type Config struct {
+	Port int
+	// APIKey is the bearer token callers must send. Without one every call is
+	// rejected, and the server binary does not start the gRPC server.
+	APIKey string
+	// Batch bounds BatchGetCovers the same way it bounds POST /bookcover/batch.
+	Batch handler.BatchConfig
+	// RateLimit is charged one unit per call and one per item of a batch. Its
+	// Cost function is not used.
+	RateLimit middleware.RateLimitConfig
+}
+
+// ConfigFromEnv reads GRPC_PORT and GRPC_API_KEY, and the batch limits from
+// BATCH_MAX_ITEMS and BATCH_WORKERS. Calls are rate limited like the HTTP API.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Port:      DefaultPort,
+		APIKey:    os.Getenv("GRPC_API_KEY"),
+		Batch:     handler.BatchConfigFromEnv(),
+		RateLimit: middleware.ProTier,
+	}
+	if port, err := strconv.Atoi(os.Getenv("GRPC_PORT")); err == nil && port > 0 {
+		cfg.Port = port
+	}
+	return cfg
+}
+
+// New returns a gRPC server exposing the cover service, behind interceptors
+// for metrics, auth and rate limiting.
+func New(svc service.BookcoverService, cacheClient cache.CacheClient, cfg Config) *grpc.Server {
+	covers := NewCoverServer(svc, cfg.Batch)
+	srv := grpc.NewServer(Chain(
+		MetricsInterceptor(),
+		AuthInterceptor(cfg.APIKey),
+		RateLimitInterceptor(cacheClient, cfg.RateLimit, covers.Cost),
+	)...)
+	bookcoverpb.RegisterCoverServiceServer(srv, covers)
+	return srv
+}
+
+// CoverServer implements bookcoverpb.CoverServiceServer on top of the
+// BookcoverService.
+type CoverServer struct {
+	bookcoverpb.UnimplementedCoverServiceServer
+
+	service service.BookcoverService
+	cfg     handler.BatchConfig
+}
+
+func NewCoverServer(svc service.BookcoverService, cfg handler.BatchConfig) *CoverServer {
+	if cfg.MaxItems <= 0 {
+		cfg.MaxItems = handler.DefaultBatchMaxItems
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = handler.DefaultBatchWorkers
+	}
+	return &CoverServer{service: svc, cfg: cfg}
+}
+
+func (s *CoverServer) GetCoverByISBN(ctx context.Context, req *bookcoverpb.GetCoverByISBNRequest) (*bookcoverpb.Cover, error) {
+	return s.get(ctx, req.GetIsbn(), "", "", req.GetImage(), req.GetIncludeImageInfo())
+}
+
+func (s *CoverServer) GetCoverByTitleAuthor(ctx context.Context, req *bookcoverpb.GetCoverByTitleAuthorRequest) (*bookcoverpb.Cover, error) {
+	return s.get(ctx, "", req.GetBookTitle(), req.GetAuthorName(), req.GetImage(), req.GetIncludeImageInfo())
+}
+
+func (s *CoverServer) get(ctx context.Context, isbn, bookTitle, authorName string, image *bookcoverpb.ImageOptions, include bool) (*bookcoverpb.Cover, error) {
+	opts, err := imageOptions(image)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	query := service.Query{ISBN: isbn, BookTitle: bookTitle, AuthorName: authorName, Options: opts, IncludeImageInfo: include}
+	if err := query.ValidateBook(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	cover, err := s.service.Lookup(ctx, query)
+	if err != nil {
+		return nil, lookupError(err).Err()
+	}
+	return toCover(cover), nil
+}
+
+func (s *CoverServer) BatchGetCovers(req *bookcoverpb.BatchGetCoversRequest, stream grpc.ServerStreamingServer[bookcoverpb.BatchGetCoversResponse]) error {
+	items := req.GetItems()
+	if len(items) == 0 {
+		return status.Error(codes.InvalidArgument, config.EmptyGRPCBatch)
+	}
+	if len(items) > s.cfg.MaxItems {
+		return status.Error(codes.InvalidArgument, config.BatchTooLarge)
+	}
+	opts, err := imageOptions(req.GetImage())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	include := req.GetIncludeImageInfo()
+
+	// Workers stop as soon as the client goes away or a send fails.
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	indexes := make(chan int)
+	go func() {
+		defer close(indexes)
+		for i := range items {
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	results := make(chan *bookcoverpb.BatchGetCoversResponse)
+	var wg sync.WaitGroup
+	for range min(s.cfg.Workers, len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				select {
+				case results <- s.lookupItem(ctx, i, items[i], opts, include):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for result := range results {
+		if err := stream.Send(result); err != nil {
+			cancel()
+			return err
+		}
+	}
+	if err := stream.Context().Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return nil
+}
+
+func (s *CoverServer) lookupItem(ctx context.Context, index int, item *bookcoverpb.BatchItem, opts service.ImageOptions, include bool) *bookcoverpb.BatchGetCoversResponse {
+	result := &bookcoverpb.BatchGetCoversResponse{Index: int32(index), Item: item}
+
+	query := service.Query{
+		ISBN:             item.GetIsbn(),
+		BookTitle:        item.GetBookTitle(),
+		AuthorName:       item.GetAuthorName(),
+		Options:          opts,
+		IncludeImageInfo: include,
+	}
+	if err := query.ValidateBook(); err != nil {
+		result.Result = &bookcoverpb.BatchGetCoversResponse_Error{Error: &bookcoverpb.Error{Code: int32(codes.InvalidArgument), Message: err.Error()}}
+		return result
+	}
+
+	cover, err := s.service.Lookup(ctx, query)
+	if err != nil {
+		st := lookupError(err)
+		result.Result = &bookcoverpb.BatchGetCoversResponse_Error{Error: &bookcoverpb.Error{Code: int32(st.Code()), Message: st.Message()}}
+		return result
+	}
+	result.Result = &bookcoverpb.BatchGetCoversResponse_Cover{Cover: toCover(cover)}
+	return result
+}
+
+// Cost charges one unit of rate-limit quota per item of a batch, and one for
+// any other call. Batches that will be rejected cost a single unit.
+func (s *CoverServer) Cost(req any) int {
+	batch, ok := req.(*bookcoverpb.BatchGetCoversRequest)
+	if !ok || len(batch.GetItems()) > s.cfg.MaxItems {
+		return 1
+	}
+	return max(1, len(batch.GetItems()))
+}
+
+// imageOptions converts and validates the image options of a request.
+func imageOptions(image *bookcoverpb.ImageOptions) (service.ImageOptions, error) {
+	opts := service.ImageOptions{
+		Width:  int(image.GetWidth()),
+		Height: int(image.GetHeight()),
+	}
+
+	switch image.GetSize() {
+	case bookcoverpb.ImageSize_IMAGE_SIZE_UNSPECIFIED:
+	case bookcoverpb.ImageSize_IMAGE_SIZE_SMALL:
+		opts.Size = service.ImageSizeSmall
+	case bookcoverpb.ImageSize_IMAGE_SIZE_MEDIUM:
+		opts.Size = service.ImageSizeMedium
+	case bookcoverpb.ImageSize_IMAGE_SIZE_LARGE:
+		opts.Size = service.ImageSizeLarge
+	default:
+		return opts, service.ErrInvalidImageSize
+	}
+
+	switch image.GetFit() {
+	case bookcoverpb.Fit_FIT_UNSPECIFIED:
+	case bookcoverpb.Fit_FIT_CONTAIN:
+		opts.Fit = service.FitContain
+	case bookcoverpb.Fit_FIT_PAD:
+		opts.Fit = service.FitPad
+	default:
+		return opts, service.ErrInvalidFit
+	}
+	return opts, opts.Validate()
+}
+
+// lookupCodes maps each kind of failed lookup to the gRPC code matching the
+// HTTP status the handler package answers with.
+var lookupCodes = map[service.Failure]codes.Code{
+	service.FailureNotFound:              codes.NotFound,
+	service.FailureProviderUnavailable:   codes.Unavailable,
+	service.FailureProviderBusy:          codes.Unavailable,
+	service.FailureProviderBlocked:       codes.Unavailable,
+	service.FailureProviderLayoutChanged: codes.Unavailable,
+}
+
+// lookupError maps a service error to a gRPC status, the way lookupStatus in
+// the handler package maps it to an HTTP status.
+func lookupError(err error) *status.Status {
+	failure, message := service.Classify(err)
+	return status.New(lookupCodes[failure], message)
+}
+
+func toCover(cover *service.Cover) *bookcoverpb.Cover {
+	result := &bookcoverpb.Cover{
+		Url:    cover.URL,
+		Source: cover.Source,
+	}
+	switch cover.CacheStatus {
+	case service.CacheHit:
+		result.Cache = bookcoverpb.CacheStatus_CACHE_STATUS_HIT
+	case service.CacheMiss:
+		result.Cache = bookcoverpb.CacheStatus_CACHE_STATUS_MISS
+	case service.CacheStale:
+		result.Cache = bookcoverpb.CacheStatus_CACHE_STATUS_STALE
+	}
+	if info := cover.ImageInfo; info != nil {
+		result.ImageInfo = &bookcoverpb.ImageInfo{
+			Width:         int32(info.Width),
+			Height:        int32(info.Height),
+			Bytes:         info.Bytes,
+			MimeType:      info.MIMEType,
+			DominantColor: info.DominantColor,
+			AverageColor:  info.AverageColor,
+			BlurHash:      info.BlurHash,
+		}
+	}
+	return result
+}
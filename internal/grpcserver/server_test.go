@@ -0,0 +1,219 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sort"
+	"testing"
+
+	"bookcover-api/internal/config"
+	"bookcover-api/internal/handler"
+	"bookcover-api/internal/middleware"
+	"bookcover-api/internal/scraper"
+	"bookcover-api/internal/service"
+	"bookcover-api/mocks"
+	"bookcover-api/pkg/bookcoverpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	testAPIKey   = "secret"
+	notFoundISBN = "9780000000002"
+)
+
+// fakeScraper finds a cover for every book except notFoundISBN.
+type fakeScraper struct{}
+
+func (fakeScraper) Name() string { return "fake" }
+
+func (fakeScraper) FetchByTitleAuthor(ctx context.Context, bookTitle, authorName string) (string, error) {
+	return "https://example.com/" + bookTitle + ".jpg", nil
+}
+
+func (fakeScraper) FetchByISBN(ctx context.Context, isbn string) (string, error) {
+	if isbn == notFoundISBN {
+		return "", scraper.ErrNotFound
+	}
+	return "https://example.com/" + isbn + ".jpg", nil
+}
+
+// dial starts a server on an in-memory listener and returns a client for it.
+func dial(t *testing.T, s scraper.Scraper, cfg Config) bookcoverpb.CoverServiceClient {
+	t.Helper()
+	srv := New(service.NewBookcoverService(s, mocks.NewMockCache()), mocks.NewMockCache(), cfg)
+	listener := bufconn.Listen(1 << 20)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return bookcoverpb.NewCoverServiceClient(conn)
+}
+
+func testConfig() Config {
+	return Config{APIKey: testAPIKey, RateLimit: middleware.ProTier}
+}
+
+func authorized() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+testAPIKey)
+}
+
+func TestGetCoverByISBN(t *testing.T) {
+	client := dial(t, fakeScraper{}, testConfig())
+
+	cover, err := client.GetCoverByISBN(authorized(), &bookcoverpb.GetCoverByISBNRequest{Isbn: "978-0345376596"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cover.GetUrl() != "https://example.com/9780345376596.jpg" || cover.GetSource() != "fake" {
+		t.Errorf("Unexpected cover %v", cover)
+	}
+	if cover.GetCache() != bookcoverpb.CacheStatus_CACHE_STATUS_MISS {
+		t.Errorf("Expected a cache miss, got %v", cover.GetCache())
+	}
+
+	cover, _ = client.GetCoverByISBN(authorized(), &bookcoverpb.GetCoverByISBNRequest{Isbn: "9780345376596"})
+	if cover.GetCache() != bookcoverpb.CacheStatus_CACHE_STATUS_HIT {
+		t.Errorf("Expected a cache hit, got %v", cover.GetCache())
+	}
+}
+
+func TestGetCoverByISBN_Errors(t *testing.T) {
+	client := dial(t, fakeScraper{}, testConfig())
+
+	tests := []struct {
+		name    string
+		req     *bookcoverpb.GetCoverByISBNRequest
+		code    codes.Code
+		message string
+	}{
+		{"invalid ISBN", &bookcoverpb.GetCoverByISBNRequest{Isbn: "123"}, codes.InvalidArgument, config.InvalidISBN},
+		{"missing ISBN", &bookcoverpb.GetCoverByISBNRequest{}, codes.InvalidArgument, config.MandidatoryParamsMissing},
+		{"invalid options", &bookcoverpb.GetCoverByISBNRequest{
+			Isbn:  "9780345376596",
+			Image: &bookcoverpb.ImageOptions{Size: bookcoverpb.ImageSize_IMAGE_SIZE_SMALL, Width: 100},
+		}, codes.InvalidArgument, config.ConflictingImageParams},
+		{"not found", &bookcoverpb.GetCoverByISBNRequest{Isbn: notFoundISBN}, codes.NotFound, scraper.ErrNotFound.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetCoverByISBN(authorized(), tt.req)
+			st := status.Convert(err)
+			if st.Code() != tt.code || st.Message() != tt.message {
+				t.Errorf("Expected %v %q, got %v %q", tt.code, tt.message, st.Code(), st.Message())
+			}
+		})
+	}
+}
+
+func TestGetCoverByTitleAuthor(t *testing.T) {
+	client := dial(t, fakeScraper{}, testConfig())
+
+	cover, err := client.GetCoverByTitleAuthor(authorized(), &bookcoverpb.GetCoverByTitleAuthorRequest{BookTitle: "dune", AuthorName: "frank herbert"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cover.GetUrl() != "https://example.com/dune.jpg" {
+		t.Errorf("Unexpected cover %v", cover)
+	}
+
+	_, err = client.GetCoverByTitleAuthor(authorized(), &bookcoverpb.GetCoverByTitleAuthorRequest{BookTitle: "dune"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without an author, got %v", err)
+	}
+}
+
+func TestBatchGetCovers(t *testing.T) {
+	client := dial(t, fakeScraper{}, testConfig())
+
+	stream, err := client.BatchGetCovers(authorized(), &bookcoverpb.BatchGetCoversRequest{
+		Items: []*bookcoverpb.BatchItem{
+			{Isbn: "9780345376596"},
+			{Isbn: notFoundISBN},
+			{Isbn: "123"},
+			{BookTitle: "dune", AuthorName: "frank herbert"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var results []*bookcoverpb.BatchGetCoversResponse
+	for {
+		result, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		results = append(results, result)
+	}
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].GetIndex() < results[j].GetIndex() })
+
+	if results[0].GetCover().GetUrl() != "https://example.com/9780345376596.jpg" {
+		t.Errorf("Unexpected result %v", results[0])
+	}
+	if results[1].GetError().GetCode() != int32(codes.NotFound) {
+		t.Errorf("Expected NotFound, got %v", results[1])
+	}
+	if results[2].GetError().GetCode() != int32(codes.InvalidArgument) || results[2].GetError().GetMessage() != config.InvalidISBN {
+		t.Errorf("Expected InvalidArgument, got %v", results[2])
+	}
+	if results[3].GetCover().GetUrl() != "https://example.com/dune.jpg" || results[3].GetItem().GetBookTitle() != "dune" {
+		t.Errorf("Unexpected result %v", results[3])
+	}
+}
+
+func TestBatchGetCovers_TooLarge(t *testing.T) {
+	cfg := testConfig()
+	cfg.Batch = handler.BatchConfig{MaxItems: 1}
+	client := dial(t, fakeScraper{}, cfg)
+
+	stream, err := client.BatchGetCovers(authorized(), &bookcoverpb.BatchGetCoversRequest{
+		Items: []*bookcoverpb.BatchItem{{Isbn: "9780345376596"}, {Isbn: "9780345376597"}},
+	})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if st := status.Convert(err); st.Code() != codes.InvalidArgument || st.Message() != config.BatchTooLarge {
+		t.Errorf("Expected a too large batch error, got %v", err)
+	}
+}
+
+func TestLookupError(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{scraper.ErrNotFound, codes.NotFound},
+		{scraper.ErrCircuitOpen, codes.Unavailable},
+		{scraper.ErrBudgetExhausted, codes.Unavailable},
+		{scraper.ErrBlocked, codes.Unavailable},
+		{scraper.ErrUnknownLayout, codes.Unavailable},
+	}
+	for _, tt := range tests {
+		if code := lookupError(tt.err).Code(); code != tt.code {
+			t.Errorf("Expected %v for %v, got %v", tt.code, tt.err, code)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"bookcover-api/internal/config"
+	"bookcover-api/internal/imagecache"
+	"bookcover-api/internal/scraper"
+	"bookcover-api/pkg/response"
+)
+
+// ReloadRulesHandler re-reads the scraper rule file. Invalid rules are
+// rejected and the previous rules stay active.
+func ReloadRulesHandler(rules *scraper.RuleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		loaded, err := rules.Reload()
+		if err != nil {
+			w.Write(response.Error(w, http.StatusUnprocessableEntity, err.Error()))
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"version": loaded.Version,
+			"source":  rules.Source(),
+		})
+	}
+}
+
+// PurgeImageCacheHandler drops every cached image stored for the ISBN in the path.
+// Images first cached by a title and author lookup are not tagged with an ISBN.
+func PurgeImageCacheHandler(store *imagecache.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		isbn := strings.ReplaceAll(r.PathValue("isbn"), "-", "")
+		if len(isbn) != 13 {
+			w.Write(response.Error(w, http.StatusBadRequest, config.InvalidISBN))
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"isbn":   isbn,
+			"purged": store.PurgeISBN(isbn),
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bookcover-api/internal/imagecache"
+	"bookcover-api/internal/scraper"
+)
+
+func setupRuleStore(t *testing.T) (*scraper.RuleStore, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	os.WriteFile(path, []byte(`
+version: 1
+isbn:
+  cover: [".BookCover__image img"]
+search:
+  row: ["tr[itemscope]"]
+  cover: [".bookCover"]
+  author: [".authorName"]
+pages:
+  no_results_text: ["No results"]
+  login_selectors: ["form[action*=sign_in]"]
+  login_titles: ["sign in"]
+`), 0o644)
+
+	store, err := scraper.NewRuleStore(path)
+	if err != nil {
+		t.Fatalf("NewRuleStore() unexpected error: %v", err)
+	}
+	return store, path
+}
+
+func TestReloadRules_Success(t *testing.T) {
+	store, path := setupRuleStore(t)
+
+	req := httptest.NewRequest("POST", "/admin/scraper/rules/reload", nil)
+	w := httptest.NewRecorder()
+
+	ReloadRulesHandler(store)(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status code 200, got %d", resp.StatusCode)
+	}
+
+	var response map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&response)
+	if response["source"] != path {
+		t.Errorf("Expected source %s, got %v", path, response["source"])
+	}
+}
+
+func TestReloadRules_InvalidFile(t *testing.T) {
+	store, path := setupRuleStore(t)
+	os.WriteFile(path, []byte("version: 2"), 0o644)
+
+	req := httptest.NewRequest("POST", "/admin/scraper/rules/reload", nil)
+	w := httptest.NewRecorder()
+
+	ReloadRulesHandler(store)(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status code 422 for invalid rules, got %d", resp.StatusCode)
+	}
+}
+
+func TestPurgeImageCache(t *testing.T) {
+	store, err := imagecache.NewStore(imagecache.Config{Dir: t.TempDir(), MaxBytes: 1024})
+	if err != nil {
+		t.Fatalf("NewStore() unexpected error: %v", err)
+	}
+	writer, _ := store.Create(imagecache.Meta{URL: "https://a/1.jpg", ISBN: "9780345376596"})
+	writer.Write([]byte("cover"))
+	writer.Commit()
+
+	req := httptest.NewRequest("DELETE", "/admin/image-cache/978-0345376596", nil)
+	req.SetPathValue("isbn", "978-0345376596")
+	w := httptest.NewRecorder()
+
+	PurgeImageCacheHandler(store)(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status code 200, got %d", resp.StatusCode)
+	}
+
+	var response map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&response)
+	if response["purged"] != float64(1) {
+		t.Errorf("Expected 1 purged image, got %v", response["purged"])
+	}
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"bookcover-api/internal/config"
+	"bookcover-api/internal/imageinfo"
+	"bookcover-api/internal/service"
+	"bookcover-api/pkg/response"
+)
+
+const (
+	DefaultBatchMaxItems = 50
+	DefaultBatchWorkers  = 8
+	// maxBatchBodyBytes bounds the request body; 64 bytes per item is plenty.
+	maxBatchBodyBytes = 1 << 20
+)
+
+type BatchConfig struct {
+	// MaxItems is the largest batch accepted.
+	MaxItems int
+	// Workers bounds how many items are looked up concurrently.
+	Workers int
+	// Quota charges the lines of bulk uploads as they are read, and the
+	// distinct lookups of GraphQL queries. When nil they are not charged.
+	Quota *QuotaMeter
+}
+
+func DefaultBatchConfig() BatchConfig {
+	return BatchConfig{
+		MaxItems: DefaultBatchMaxItems,
+		Workers:  DefaultBatchWorkers,
+	}
+}
+
+// BatchConfigFromEnv reads BATCH_MAX_ITEMS and BATCH_WORKERS, keeping the
+// defaults for unset or invalid values.
+func BatchConfigFromEnv() BatchConfig {
+	cfg := DefaultBatchConfig()
+	if n, err := strconv.Atoi(os.Getenv("BATCH_MAX_ITEMS")); err == nil && n > 0 {
+		cfg.MaxItems = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("BATCH_WORKERS")); err == nil && n > 0 {
+		cfg.Workers = n
+	}
+	return cfg
+}
+
+// BatchItem is one book of a batch request.
+type BatchItem struct {
+	ISBN       string `json:"isbn,omitempty"`
+	BookTitle  string `json:"book_title,omitempty"`
+	AuthorName string `json:"author_name,omitempty"`
+}
+
+// BatchResult is the outcome of one item, in the same position as the item.
+type BatchResult struct {
+	BatchItem
+	Status    int             `json:"status"`
+	URL       string          `json:"url,omitempty"`
+	ImageInfo *imageinfo.Info `json:"image_info,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// BatchHandler looks up many covers in one request, for pages that render a
+// whole bookshelf.
+type BatchHandler struct {
+	service service.BookcoverService
+	cfg     BatchConfig
+}
+
+func NewBatchHandler(svc service.BookcoverService) *BatchHandler {
+	return NewBatchHandlerWithConfig(svc, DefaultBatchConfig())
+}
+
+func NewBatchHandlerWithConfig(svc service.BookcoverService, cfg BatchConfig) *BatchHandler {
+	if cfg.MaxItems <= 0 {
+		cfg.MaxItems = DefaultBatchMaxItems
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultBatchWorkers
+	}
+	return &BatchHandler{service: svc, cfg: cfg}
+}
+
+func (h *BatchHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	opts, err := imageOptions(r)
+	if err != nil {
+		w.Write(response.Error(w, http.StatusBadRequest, err.Error()))
+		return
+	}
+	include, err := imageInfoRequested(r)
+	if err != nil {
+		w.Write(response.Error(w, http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	var items []BatchItem
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)).Decode(&items); err != nil || len(items) == 0 {
+		w.Write(response.Error(w, http.StatusBadRequest, config.InvalidBatch))
+		return
+	}
+	if len(items) > h.cfg.MaxItems {
+		w.Write(response.Error(w, http.StatusRequestEntityTooLarge, config.BatchTooLarge))
+		return
+	}
+
+	// Items asking for the same book share one lookup, whose result is
+	// copied to each of them.
+	results := make([]BatchResult, len(items))
+	var queries []coverQuery
+	indexes := make(map[coverQuery][]int)
+	for i, item := range items {
+		query, message := batchQuery(item, opts, include)
+		if message != "" {
+			results[i] = BatchResult{BatchItem: item, Status: http.StatusBadRequest, Error: message}
+			continue
+		}
+		if _, seen := indexes[query]; !seen {
+			queries = append(queries, query)
+		}
+		indexes[query] = append(indexes[query], i)
+	}
+	fill := func(query coverQuery, result BatchResult) {
+		for _, i := range indexes[query] {
+			result.BatchItem = items[i]
+			results[i] = result
+		}
+	}
+
+	ctx := r.Context()
+	pending := make(chan coverQuery)
+	var wg sync.WaitGroup
+	for range min(h.cfg.Workers, len(queries)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for query := range pending {
+				fill(query, h.resolve(ctx, query))
+			}
+		}()
+	}
+
+feed:
+	for i, query := range queries {
+		select {
+		case pending <- query:
+		case <-ctx.Done():
+			for _, query := range queries[i:] {
+				fill(query, BatchResult{Status: http.StatusServiceUnavailable, Error: config.BatchLookupCanceled})
+			}
+			break feed
+		}
+	}
+	close(pending)
+	wg.Wait()
+
+	var buffer bytes.Buffer
+	enc := json.NewEncoder(&buffer)
+	enc.SetEscapeHTML(false)
+	enc.Encode(map[string][]BatchResult{"results": results})
+	w.WriteHeader(http.StatusOK)
+	w.Write(buffer.Bytes())
+}
+
+func (h *BatchHandler) lookup(ctx context.Context, item BatchItem, opts service.ImageOptions, include bool) BatchResult {
+	query, message := batchQuery(item, opts, include)
+	if message != "" {
+		return BatchResult{BatchItem: item, Status: http.StatusBadRequest, Error: message}
+	}
+
+	result := h.resolve(ctx, query)
+	result.BatchItem = item
+	return result
+}
+
+// batchQuery builds the lookup of an item. It returns the error message for
+// the item, or "" when the item is valid.
+func batchQuery(item BatchItem, opts service.ImageOptions, include bool) (coverQuery, string) {
+	query := coverQuery{isbn: item.ISBN, bookTitle: item.BookTitle, authorName: item.AuthorName, opts: opts, imageInfo: include}
+	return query, query.validate()
+}
+
+// resolve looks a validated query up. The result does not echo the item.
+func (h *BatchHandler) resolve(ctx context.Context, query coverQuery) BatchResult {
+	var result BatchResult
+	cover, err := query.resolve(ctx, h.service)
+	if err != nil {
+		result.Status, _, result.Error = lookupStatus(err)
+		return result
+	}
+	result.Status, result.URL, result.ImageInfo = http.StatusOK, cover.URL, cover.ImageInfo
+	return result
+}
+
+// Cost charges one unit of rate-limit quota per item of a batch. It reads the
+// body ahead of the handler and puts it back for it. Bodies the handler will
+// reject cost a single unit.
+func (h *BatchHandler) Cost(r *http.Request) int {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxBatchBodyBytes+1))
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return 1
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil || len(items) > h.cfg.MaxItems {
+		return 1
+	}
+	return max(1, len(items))
+}
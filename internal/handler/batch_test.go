@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"bookcover-api/internal/config"
+	"bookcover-api/internal/scraper"
+	"bookcover-api/internal/service"
+	"bookcover-api/mocks"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// countingScraper finds a cover for every ISBN except notFoundISBN and
+// records how many lookups run at the same time.
+type countingScraper struct {
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+	release     chan struct{}
+}
+
+const notFoundISBN = "9780000000002"
+
+func (s *countingScraper) Name() string { return "counting" }
+
+func (s *countingScraper) FetchByTitleAuthor(ctx context.Context, bookTitle, authorName string) (string, error) {
+	return "https://example.com/" + bookTitle + ".jpg", nil
+}
+
+func (s *countingScraper) FetchByISBN(ctx context.Context, isbn string) (string, error) {
+	n := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		max := s.maxInFlight.Load()
+		if n <= max || s.maxInFlight.CompareAndSwap(max, n) {
+			break
+		}
+	}
+	if s.release != nil {
+		<-s.release
+	}
+
+	if isbn == notFoundISBN {
+		return "", scraper.ErrNotFound
+	}
+	return "https://example.com/" + isbn + ".jpg", nil
+}
+
+func decodeBatch(t *testing.T, resp *http.Response) []BatchResult {
+	t.Helper()
+	var body struct {
+		Results []BatchResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+	return body.Results
+}
+
+func TestBatch_ResolvesEachItem(t *testing.T) {
+	mockCache := mocks.NewMockCache()
+	mockCache.Set(&memcache.Item{Key: "9780345376596", Value: []byte(expectedURL)})
+	handler := NewBatchHandler(service.NewBookcoverService(&countingScraper{}, mockCache))
+
+	body := `[
+		{"isbn": "978-0345376596"},
+		{"book_title": "Dune", "author_name": "Frank Herbert"},
+		{"isbn": "` + notFoundISBN + `"},
+		{"isbn": "123"},
+		{"book_title": "Dune"}
+	]`
+	req := httptest.NewRequest("POST", "/bookcover/batch", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.Batch(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status code 200, got %d", resp.StatusCode)
+	}
+
+	results := decodeBatch(t, resp)
+	want := []struct {
+		status int
+		url    string
+		err    string
+	}{
+		{http.StatusOK, expectedURL, ""},
+		{http.StatusOK, "https://example.com/Dune.jpg", ""},
+		{http.StatusNotFound, "", "image was not found"},
+		{http.StatusBadRequest, "", config.InvalidISBN},
+		{http.StatusBadRequest, "", config.MandidatoryParamsMissing},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("Expected %d results, got %d", len(want), len(results))
+	}
+	for i, w := range want {
+		got := results[i]
+		if got.Status != w.status || got.URL != w.url || got.Error != w.err {
+			t.Errorf("result %d = %+v, want status %d, url %q, error %q", i, got, w.status, w.url, w.err)
+		}
+	}
+	if results[0].ISBN != "978-0345376596" {
+		t.Errorf("Expected result to echo the item, got %+v", results[0].BatchItem)
+	}
+}
+
+func TestBatch_LooksDuplicatesUpOnce(t *testing.T) {
+	counter := &lookupCounter{}
+	handler := NewBatchHandler(service.NewBookcoverService(counter, nil))
+
+	body := `[
+		{"isbn": "978-0345376596"},
+		{"isbn": "9780345376596"},
+		{"isbn": "978-0345376597"},
+		{"isbn": "978-0345376596"}
+	]`
+	req := httptest.NewRequest("POST", "/bookcover/batch", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.Batch(w, req)
+
+	if got := counter.calls.Load(); got != 2 {
+		t.Errorf("Expected 2 lookups, got %d", got)
+	}
+	results := decodeBatch(t, w.Result())
+	wantISBNs := []string{"978-0345376596", "9780345376596", "978-0345376597", "978-0345376596"}
+	if len(results) != len(wantISBNs) {
+		t.Fatalf("Expected %d results, got %d", len(wantISBNs), len(results))
+	}
+	for i, isbn := range wantISBNs {
+		wantURL := "https://example.com/" + strings.ReplaceAll(isbn, "-", "") + ".jpg"
+		if got := results[i]; got.ISBN != isbn || got.Status != http.StatusOK || got.URL != wantURL {
+			t.Errorf("result %d = %+v, want ISBN %s with %s", i, got, isbn, wantURL)
+		}
+	}
+}
+
+func TestBatch_BoundsConcurrency(t *testing.T) {
+	s := &countingScraper{release: make(chan struct{})}
+	handler := NewBatchHandlerWithConfig(service.NewBookcoverService(s, mocks.NewMockCache()), BatchConfig{MaxItems: 20, Workers: 3})
+
+	items := make([]string, 12)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"isbn": "97803453765%02d"}`, i)
+	}
+	req := httptest.NewRequest("POST", "/bookcover/batch", strings.NewReader("["+strings.Join(items, ",")+"]"))
+	w := httptest.NewRecorder()
+
+	go func() {
+		for range items {
+			s.release <- struct{}{}
+		}
+	}()
+	handler.Batch(w, req)
+
+	if got := s.maxInFlight.Load(); got > 3 {
+		t.Errorf("Expected at most 3 concurrent lookups, got %d", got)
+	}
+	for i, result := range decodeBatch(t, w.Result()) {
+		if result.Status != http.StatusOK {
+			t.Errorf("result %d: expected status 200, got %+v", i, result)
+		}
+	}
+}
+
+func TestBatch_InvalidRequests(t *testing.T) {
+	handler := NewBatchHandlerWithConfig(service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()), BatchConfig{MaxItems: 2})
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		err    string
+	}{
+		{"not json", "isbn=978-0345376596", http.StatusBadRequest, config.InvalidBatch},
+		{"object instead of array", `{"isbn": "978-0345376596"}`, http.StatusBadRequest, config.InvalidBatch},
+		{"empty", `[]`, http.StatusBadRequest, config.InvalidBatch},
+		{"too many items", `[{"isbn": "1"}, {"isbn": "2"}, {"isbn": "3"}]`, http.StatusRequestEntityTooLarge, config.BatchTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/bookcover/batch", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.Batch(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != tt.status {
+				t.Errorf("Expected status code %d, got %d", tt.status, resp.StatusCode)
+			}
+			var result map[string]string
+			json.NewDecoder(resp.Body).Decode(&result)
+			if result["error"] != tt.err {
+				t.Errorf("Expected error %q, got %q", tt.err, result["error"])
+			}
+		})
+	}
+}
+
+func TestBatch_Cost(t *testing.T) {
+	handler := NewBatchHandlerWithConfig(service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()), BatchConfig{MaxItems: 3})
+
+	tests := []struct {
+		body string
+		cost int
+	}{
+		{`[{"isbn": "1"}, {"isbn": "2"}, {"isbn": "3"}]`, 3},
+		{`[]`, 1},
+		{`not json`, 1},
+		{`[{"isbn": "1"}, {"isbn": "2"}, {"isbn": "3"}, {"isbn": "4"}]`, 1},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/bookcover/batch", strings.NewReader(tt.body))
+		if got := handler.Cost(req); got != tt.cost {
+			t.Errorf("Cost(%s) = %d, want %d", tt.body, got, tt.cost)
+		}
+
+		// The body is still readable by the handler
+		var items []BatchItem
+		if err := json.NewDecoder(req.Body).Decode(&items); err != nil && !errors.As(err, new(*json.SyntaxError)) {
+			t.Errorf("Body was not restored: %v", err)
+		}
+	}
+}
@@ -1,9 +1,15 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"bookcover-api/internal/config"
 	"bookcover-api/internal/service"
@@ -15,8 +21,30 @@ const (
 	authorNameParam = "author_name"
 	isbnParam       = "isbn"
 	imageSizeParam  = "image_size"
+	widthParam      = "width"
+	heightParam     = "height"
+	fitParam        = "fit"
+	formatParam     = "format"
+	redirectParam   = "redirect"
+	fallbackParam   = "fallback"
+	includeParam    = "include"
 )
 
+// includeImageInfo is the include value that adds image_info to the response.
+const includeImageInfo = "image_info"
+
+var errInvalidInclude = errors.New(config.InvalidInclude)
+
+// The dates announced in the Deprecation and Sunset headers of the
+// /bookcover/{isbn} route.
+var (
+	byISBNDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	byISBNSunsetAt     = time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC)
+)
+
+// redirectMaxAge is how long clients may cache a redirect to a cover.
+const redirectMaxAge = 24 * time.Hour
+
 type BookcoverHandler struct {
 	service service.BookcoverService
 }
@@ -28,70 +56,292 @@ func NewBookcoverHandler(svc service.BookcoverService) *BookcoverHandler {
 }
 
 func (h *BookcoverHandler) Search(w http.ResponseWriter, r *http.Request) {
-	isbn := r.URL.Query().Get(isbnParam)
-	bookTitle := r.URL.Query().Get(bookTitleParam)
-	authorName := r.URL.Query().Get(authorNameParam)
-	imageSize := r.URL.Query().Get(imageSizeParam)
+	noStore(w)
 
-	if isbn != "" && (bookTitle != "" || authorName != "") {
-		w.Write(response.Error(w, http.StatusBadRequest, config.ConflictingParams))
-		return
+	redirect := false
+	if raw := r.URL.Query().Get(redirectParam); raw != "" {
+		var err error
+		if redirect, err = strconv.ParseBool(raw); err != nil {
+			w.Write(response.Error(w, http.StatusBadRequest, config.InvalidRedirect))
+			return
+		}
 	}
 
-	if isbn != "" {
-		h.searchByISBN(w, isbn, imageSize)
+	query, ok := parseCoverQuery(w, r)
+	if !ok {
 		return
 	}
-
-	if bookTitle == "" || authorName == "" {
-		w.Write(response.Error(w, http.StatusBadRequest, config.MandidatoryParamsMissing))
+	// A placeholder is an image, which only a redirect can answer with.
+	if query.fallback && !redirect {
+		w.Write(response.Error(w, http.StatusBadRequest, config.FallbackNeedsRedirect))
 		return
 	}
 
-	imageURL, err := h.service.GetByTitleAuthor(bookTitle, authorName, imageSize)
+	cover, err := query.resolve(r.Context(), h.service)
 	if err != nil {
-		w.Write(response.Error(w, http.StatusNotFound, err.Error()))
+		if query.fallback {
+			servePlaceholder(w, r, query.placeholder())
+			return
+		}
+		w.Write(cachedLookupError(w, err))
 		return
 	}
 
-	w.Write(response.Success(w, imageURL))
+	if redirect {
+		redirectToCover(w, cover.URL)
+		return
+	}
+	w.Write(cachedCoverResponse(w, r, cover))
 }
 
-func (h *BookcoverHandler) searchByISBN(w http.ResponseWriter, isbn, imageSize string) {
-	isbn = strings.ReplaceAll(isbn, "-", "")
+// Cover answers /cover/{isbn}.jpg with a redirect to the cover image, so that
+// it can be used directly as an <img> source. Files without the .jpg suffix
+// are not found.
+func (h *BookcoverHandler) Cover(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
+	isbn, ok := strings.CutSuffix(r.PathValue("file"), ".jpg")
+	if !ok {
+		cacheFor(w, notFoundMaxAge)
+		w.Write(response.Error(w, http.StatusNotFound, config.RouteNotSupported))
+		return
+	}
+	isbn = strings.ReplaceAll(isbn, "-", "")
 	if len(isbn) != 13 {
 		w.Write(response.Error(w, http.StatusBadRequest, config.InvalidISBN))
 		return
 	}
 
-	imageURL, err := h.service.GetByISBN(isbn, imageSize)
+	opts, err := imageOptions(r)
+	if err != nil {
+		w.Write(response.Error(w, http.StatusBadRequest, err.Error()))
+		return
+	}
+	fallback, err := placeholderFallback(r)
+	if err != nil {
+		w.Write(response.Error(w, http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	query := coverQuery{isbn: isbn, opts: opts, fallback: fallback}
+	cover, err := query.resolve(r.Context(), h.service)
 	if err != nil {
-		w.Write(response.Error(w, http.StatusNotFound, err.Error()))
+		if query.fallback {
+			servePlaceholder(w, r, query.placeholder())
+			return
+		}
+		w.Write(cachedLookupError(w, err))
 		return
 	}
 
-	w.Write(response.Success(w, imageURL))
+	redirectToCover(w, cover.URL)
 }
 
+// ByISBN answers /bookcover/{isbn}. The route is deprecated in favor of
+// /v1/covers/{isbn}, which the response headers point to.
 func (h *BookcoverHandler) ByISBN(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Path
 	isbn := strings.TrimPrefix(path, "/bookcover/")
+	deprecate(w, "/v1/covers/"+url.PathEscape(isbn))
+	noStore(w)
 	isbn = strings.ReplaceAll(isbn, "-", "")
-	imageSize := r.URL.Query().Get(imageSizeParam)
 
 	if len(isbn) != 13 {
 		w.Write(response.Error(w, http.StatusBadRequest, config.InvalidISBN))
 		return
 	}
 
-	imageURL, err := h.service.GetByISBN(isbn, imageSize)
+	opts, err := imageOptions(r)
+	if err != nil {
+		w.Write(response.Error(w, http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	include, err := imageInfoRequested(r)
+	if err != nil {
+		w.Write(response.Error(w, http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	cover, err := h.service.Lookup(r.Context(), service.Query{ISBN: isbn, Options: opts, IncludeImageInfo: include})
 	if err != nil {
-		w.Write(response.Error(w, http.StatusNotFound, err.Error()))
+		w.Write(cachedLookupError(w, err))
 		return
 	}
 
-	w.Write(response.Success(w, imageURL))
+	w.Write(cachedCoverResponse(w, r, cover))
+}
+
+// coverResponse writes the JSON body for a cover, with its image info when it
+// was asked for and could be computed.
+func coverResponse(w http.ResponseWriter, cover *service.Cover) []byte {
+	if cover.ImageInfo == nil {
+		return response.Success(w, cover.URL)
+	}
+	return response.SuccessWith(w, cover.URL, map[string]any{"image_info": cover.ImageInfo})
+}
+
+// deprecate marks the response as coming from a deprecated route, linking to
+// the route that replaces it.
+func deprecate(w http.ResponseWriter, successor string) {
+	header := w.Header()
+	header.Set("Deprecation", fmt.Sprintf("@%d", byISBNDeprecatedAt.Unix()))
+	header.Set("Sunset", byISBNSunsetAt.Format(http.TimeFormat))
+	header.Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+}
+
+func redirectToCover(w http.ResponseWriter, imageURL string) {
+	header := w.Header()
+	header.Del("Content-Type")
+	header.Set("Location", imageURL)
+	header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(redirectMaxAge.Seconds())))
+	w.WriteHeader(http.StatusFound)
+}
+
+// coverQuery is a validated cover lookup.
+type coverQuery struct {
+	isbn       string
+	bookTitle  string
+	authorName string
+	opts       service.ImageOptions
+	// fallback asks for a generated cover when the lookup fails.
+	fallback bool
+	// imageInfo asks for the dimensions, colors and BlurHash of the cover.
+	imageInfo bool
+}
+
+// parseCoverQuery validates the lookup parameters. On failure it writes the
+// JSON error response and returns false.
+func parseCoverQuery(w http.ResponseWriter, r *http.Request) (coverQuery, bool) {
+	query, message := readCoverQuery(r)
+	if message == "" {
+		var err error
+		if query.fallback, err = placeholderFallback(r); err != nil {
+			message = err.Error()
+		}
+	}
+	if message != "" {
+		w.Write(response.Error(w, http.StatusBadRequest, message))
+		return query, false
+	}
+	return query, true
+}
+
+// readCoverQuery reads and validates the book and image parameters. It
+// returns the error message for the client, or "" when the query is valid.
+func readCoverQuery(r *http.Request) (coverQuery, string) {
+	query := coverQuery{
+		isbn:       r.URL.Query().Get(isbnParam),
+		bookTitle:  r.URL.Query().Get(bookTitleParam),
+		authorName: r.URL.Query().Get(authorNameParam),
+	}
+
+	if query.isbn != "" && (query.bookTitle != "" || query.authorName != "") {
+		return query, config.ConflictingParams
+	}
+
+	var err error
+	if query.opts, err = imageOptions(r); err != nil {
+		return query, err.Error()
+	}
+	if query.imageInfo, err = imageInfoRequested(r); err != nil {
+		return query, err.Error()
+	}
+	return query, query.validate()
+}
+
+// validate checks that the query names a book, normalizing the ISBN. It
+// returns the error message for the client, or "" when the query is valid.
+func (q *coverQuery) validate() string {
+	query := q.query()
+	if err := query.ValidateBook(); err != nil {
+		return err.Error()
+	}
+	q.isbn = query.ISBN
+	return ""
+}
+
+func (q coverQuery) query() service.Query {
+	return service.Query{
+		ISBN:             q.isbn,
+		BookTitle:        q.bookTitle,
+		AuthorName:       q.authorName,
+		Options:          q.opts,
+		IncludeImageInfo: q.imageInfo,
+	}
+}
+
+func (q coverQuery) resolve(ctx context.Context, svc service.BookcoverService) (*service.Cover, error) {
+	return svc.Lookup(ctx, q.query())
+}
+
+// imageInfoRequested reads the include query parameter.
+func imageInfoRequested(r *http.Request) (bool, error) {
+	switch r.URL.Query().Get(includeParam) {
+	case "":
+		return false, nil
+	case includeImageInfo:
+		return true, nil
+	default:
+		return false, errInvalidInclude
+	}
+}
+
+// imageOptions reads and validates the image_size, width, height and fit
+// query parameters.
+func imageOptions(r *http.Request) (service.ImageOptions, error) {
+	query := r.URL.Query()
+	opts := service.ImageOptions{
+		Size: query.Get(imageSizeParam),
+		Fit:  query.Get(fitParam),
+	}
+
+	var err error
+	if opts.Width, err = dimension(query.Get(widthParam)); err != nil {
+		return opts, err
+	}
+	if opts.Height, err = dimension(query.Get(heightParam)); err != nil {
+		return opts, err
+	}
+	return opts, opts.Validate()
+}
+
+func dimension(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value <= 0 {
+		return 0, service.ErrInvalidImageDimensions
+	}
+	return value, nil
+}
+
+// lookupError maps a service error to the matching JSON error response.
+func lookupError(w http.ResponseWriter, err error) []byte {
+	status, _, message := lookupStatus(err)
+	return response.Error(w, status, message)
+}
+
+// lookupStatuses maps each kind of failed lookup to a status code and the
+// error code of the /v1 API.
+var lookupStatuses = map[service.Failure]struct {
+	status int
+	code   string
+}{
+	service.FailureNotFound:              {http.StatusNotFound, codeNotFound},
+	service.FailureProviderUnavailable:   {http.StatusServiceUnavailable, codeProviderUnavailable},
+	service.FailureProviderBusy:          {http.StatusServiceUnavailable, codeProviderBusy},
+	service.FailureProviderBlocked:       {http.StatusServiceUnavailable, codeProviderBlocked},
+	service.FailureProviderLayoutChanged: {http.StatusBadGateway, codeProviderLayoutChanged},
+}
+
+// lookupStatus maps a service error to a status code, error code and
+// client-facing message.
+func lookupStatus(err error) (int, string, string) {
+	failure, message := service.Classify(err)
+	status := lookupStatuses[failure]
+	return status.status, status.code, message
 }
 
 func CacheStatsHandler() http.HandlerFunc {
@@ -1,14 +1,22 @@
 package handler
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"bookcover-api/internal/cache"
 	"bookcover-api/internal/config"
+	"bookcover-api/internal/imageinfo"
+	"bookcover-api/internal/imageproxy"
 	"bookcover-api/internal/scraper"
 	"bookcover-api/internal/service"
 	"bookcover-api/mocks"
@@ -290,3 +298,436 @@ func TestBookcoverSearch_ISBNWithAuthorName_ConflictingParams(t *testing.T) {
 		t.Errorf("Expected error %s, got %s", config.ConflictingParams, response["error"])
 	}
 }
+
+type unavailableScraper struct{}
+
+func (unavailableScraper) Name() string { return "unavailable" }
+
+func (unavailableScraper) FetchByTitleAuthor(ctx context.Context, bookTitle, authorName string) (string, error) {
+	return "", scraper.ErrCircuitOpen
+}
+
+func (unavailableScraper) FetchByISBN(ctx context.Context, isbn string) (string, error) {
+	return "", scraper.ErrCircuitOpen
+}
+
+func TestBookcoverSearch_ProviderUnavailable(t *testing.T) {
+	bookcoverService := service.NewBookcoverService(unavailableScraper{}, mocks.NewMockCache())
+	handler := NewBookcoverHandler(bookcoverService)
+
+	req := httptest.NewRequest("GET", "/bookcover?isbn="+isbn, nil)
+	w := httptest.NewRecorder()
+
+	handler.Search(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code 503 when providers are unavailable, got %d", resp.StatusCode)
+	}
+
+	var response map[string]string
+	json.NewDecoder(resp.Body).Decode(&response)
+	if response["error"] != config.ProviderUnavailable {
+		t.Errorf("Expected error %s, got %s", config.ProviderUnavailable, response["error"])
+	}
+}
+
+func TestBookcoverByISBN_InvalidImageParams(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"image_size=xlarge", config.InvalidImageSize},
+		{"width=abc", config.InvalidImageDimensions},
+		{"width=0", config.InvalidImageDimensions},
+		{"height=5000", config.InvalidImageDimensions},
+		{"image_size=small&width=100", config.ConflictingImageParams},
+		{"width=100&fit=pad", config.InvalidFit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			handler, _ := setupTestHandler()
+
+			req := httptest.NewRequest("GET", "/bookcover/"+isbn+"?"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			handler.ByISBN(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status code 400, got %d", resp.StatusCode)
+			}
+
+			var response map[string]string
+			json.NewDecoder(resp.Body).Decode(&response)
+			if response["error"] != tt.expected {
+				t.Errorf("Expected error %s, got %s", tt.expected, response["error"])
+			}
+		})
+	}
+}
+
+func TestBookcoverByISBN_CacheHit_WithDimensions(t *testing.T) {
+	handler, mockCache := setupTestHandler()
+
+	cachedURL := "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458.jpg"
+	mockCache.Set(&memcache.Item{Key: strings.ReplaceAll(isbn, "-", ""), Value: []byte(cachedURL)})
+
+	req := httptest.NewRequest("GET", "/bookcover/"+isbn+"?width=300&height=450&fit=pad", nil)
+	w := httptest.NewRecorder()
+
+	handler.ByISBN(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status code 200, got %d", resp.StatusCode)
+	}
+
+	var response map[string]string
+	json.NewDecoder(resp.Body).Decode(&response)
+	expected := "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458._SR300,450_.jpg"
+	if response["url"] != expected {
+		t.Errorf("Expected URL %s, got %s", expected, response["url"])
+	}
+}
+
+func TestBookcoverSearch_Redirect(t *testing.T) {
+	handler, mockCache := setupTestHandler()
+	mockCache.Set(&memcache.Item{Key: strings.ReplaceAll(isbn, "-", ""), Value: []byte(expectedURL)})
+
+	req := httptest.NewRequest("GET", "/bookcover?isbn="+isbn+"&redirect=true", nil)
+	w := httptest.NewRecorder()
+
+	handler.Search(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected status code 302, got %d", resp.StatusCode)
+	}
+	if location := resp.Header.Get("Location"); location != expectedURL {
+		t.Errorf("Expected Location %s, got %s", expectedURL, location)
+	}
+	if cc := resp.Header.Get("Cache-Control"); cc != "public, max-age=86400" {
+		t.Errorf("Expected Cache-Control public, max-age=86400, got %s", cc)
+	}
+}
+
+func TestBookcoverSearch_InvalidRedirect(t *testing.T) {
+	handler, _ := setupTestHandler()
+
+	req := httptest.NewRequest("GET", "/bookcover?isbn="+isbn+"&redirect=maybe", nil)
+	w := httptest.NewRecorder()
+
+	handler.Search(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code 400, got %d", w.Code)
+	}
+}
+
+func TestBookcoverSearch_FallbackWithoutRedirect(t *testing.T) {
+	handler, _ := setupTestHandler()
+
+	req := httptest.NewRequest("GET", "/bookcover?isbn="+isbn+"&fallback=placeholder", nil)
+	w := httptest.NewRecorder()
+
+	handler.Search(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code 400, got %d", w.Code)
+	}
+}
+
+func TestCover_Redirect(t *testing.T) {
+	handler, mockCache := setupTestHandler()
+	mockCache.Set(&memcache.Item{Key: strings.ReplaceAll(isbn, "-", ""), Value: []byte(expectedURL)})
+
+	req := httptest.NewRequest("GET", "/cover/"+isbn+".jpg", nil)
+	req.SetPathValue("file", isbn+".jpg")
+	w := httptest.NewRecorder()
+
+	handler.Cover(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected status code 302, got %d", resp.StatusCode)
+	}
+	if location := resp.Header.Get("Location"); location != expectedURL {
+		t.Errorf("Expected Location %s, got %s", expectedURL, location)
+	}
+}
+
+func TestCover_NotFoundIsJSON(t *testing.T) {
+	bookcoverService := service.NewBookcoverService(unavailableScraper{}, mocks.NewMockCache())
+	handler := NewBookcoverHandler(bookcoverService)
+
+	req := httptest.NewRequest("GET", "/cover/"+isbn+".jpg", nil)
+	req.SetPathValue("file", isbn+".jpg")
+	w := httptest.NewRecorder()
+
+	handler.Cover(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code 503, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %s", ct)
+	}
+}
+
+func TestCover_RequiresJPGSuffix(t *testing.T) {
+	handler, mockCache := setupTestHandler()
+	mockCache.Set(&memcache.Item{Key: strings.ReplaceAll(isbn, "-", ""), Value: []byte(expectedURL)})
+
+	for _, file := range []string{isbn, isbn + ".png"} {
+		req := httptest.NewRequest("GET", "/cover/"+file, nil)
+		req.SetPathValue("file", file)
+		w := httptest.NewRecorder()
+
+		handler.Cover(w, req)
+
+		resp := w.Result()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: expected status code 404, got %d", file, resp.StatusCode)
+		}
+	}
+}
+
+func TestCover_NotFoundIsCached(t *testing.T) {
+	handler := NewBookcoverHandler(service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()))
+
+	req := httptest.NewRequest("GET", "/cover/"+notFoundISBN+".jpg", nil)
+	req.SetPathValue("file", notFoundISBN+".jpg")
+	w := httptest.NewRecorder()
+
+	handler.Cover(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status code 404, got %d", resp.StatusCode)
+	}
+	if cc := resp.Header.Get("Cache-Control"); cc != "public, max-age=3600" {
+		t.Errorf("Expected Cache-Control public, max-age=3600, got %q", cc)
+	}
+}
+
+func TestPlaceholder_InvalidFormat(t *testing.T) {
+	bookcoverService := service.NewBookcoverService(unavailableScraper{}, mocks.NewMockCache())
+	handler := NewBookcoverHandler(bookcoverService)
+
+	tests := []struct {
+		name   string
+		serve  http.HandlerFunc
+		target string
+	}{
+		{"cover", handler.Cover, "/cover/" + isbn + ".jpg?fallback=placeholder&format=bmp"},
+		{"search", handler.Search, "/bookcover?isbn=" + isbn + "&redirect=true&fallback=placeholder&format=bmp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			req.SetPathValue("file", isbn+".jpg")
+			w := httptest.NewRecorder()
+
+			tt.serve(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("Expected status code 400, got %d", resp.StatusCode)
+			}
+			var response map[string]string
+			json.NewDecoder(resp.Body).Decode(&response)
+			if response["error"] != config.InvalidFormat {
+				t.Errorf("Expected error %s, got %s", config.InvalidFormat, response["error"])
+			}
+		})
+	}
+}
+
+func TestCover_PlaceholderFallback(t *testing.T) {
+	bookcoverService := service.NewBookcoverService(unavailableScraper{}, mocks.NewMockCache())
+	handler := NewBookcoverHandler(bookcoverService)
+
+	req := httptest.NewRequest("GET", "/cover/"+isbn+".jpg?fallback=placeholder", nil)
+	req.SetPathValue("file", isbn+".jpg")
+	w := httptest.NewRecorder()
+
+	handler.Cover(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status code 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "image/svg+xml" {
+		t.Errorf("Expected Content-Type image/svg+xml, got %s", ct)
+	}
+}
+
+func TestBookcoverByISBN_IncludeImageInfo(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 30))
+	var cover bytes.Buffer
+	png.Encode(&cover, img)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(cover.Bytes())
+	}))
+	defer srv.Close()
+
+	mockCache := mocks.NewMockCache()
+	mockCache.Set(&memcache.Item{Key: strings.ReplaceAll(isbn, "-", ""), Value: []byte(srv.URL + "/cover.png")})
+
+	cfg := imageproxy.DefaultConfig()
+	cfg.AllowedHosts = []string{"127.0.0.1"}
+	cfg.Transport = srv.Client().Transport
+	bookcoverService := service.NewBookcoverServiceWithConfig(mockCache, service.Config{
+		Providers: []scraper.Scraper{unavailableScraper{}},
+		Analyzer:  imageinfo.NewAnalyzer(imageproxy.NewClient(cfg)),
+	})
+	handler := NewBookcoverHandler(bookcoverService)
+
+	req := httptest.NewRequest("GET", "/bookcover/"+isbn+"?include=image_info", nil)
+	w := httptest.NewRecorder()
+
+	handler.ByISBN(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status code 200, got %d", resp.StatusCode)
+	}
+
+	var body struct {
+		URL       string          `json:"url"`
+		ImageInfo *imageinfo.Info `json:"image_info"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+	if body.URL != srv.URL+"/cover.png" {
+		t.Errorf("Expected url %s, got %s", srv.URL+"/cover.png", body.URL)
+	}
+	if body.ImageInfo == nil {
+		t.Fatal("Expected image_info in the response")
+	}
+	if body.ImageInfo.Width != 20 || body.ImageInfo.Height != 30 || body.ImageInfo.MIMEType != "image/png" {
+		t.Errorf("Unexpected image_info %+v", body.ImageInfo)
+	}
+}
+
+func TestBookcoverByISBN_InvalidInclude(t *testing.T) {
+	handler, _ := setupTestHandler()
+
+	req := httptest.NewRequest("GET", "/bookcover/"+isbn+"?include=everything", nil)
+	w := httptest.NewRecorder()
+
+	handler.ByISBN(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status code 400, got %d", resp.StatusCode)
+	}
+
+	var result map[string]string
+	json.NewDecoder(resp.Body).Decode(&result)
+	if result["error"] != config.InvalidInclude {
+		t.Errorf("Expected error %q, got %q", config.InvalidInclude, result["error"])
+	}
+}
+
+func TestBookcoverSearch_ETagAndNotModified(t *testing.T) {
+	mockCache := mocks.NewMockCache()
+	cachedURL := "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458.jpg"
+	record := fmt.Sprintf(`{"url":%q,"source":"goodreads","cached_at":%d}`, cachedURL, time.Now().Add(-time.Hour).Unix())
+	mockCache.Set(&memcache.Item{Key: "9780345376597", Value: []byte(record)})
+	handler := NewBookcoverHandler(service.NewBookcoverServiceWithConfig(mockCache, service.Config{MaxAge: 3 * time.Hour}))
+
+	req := httptest.NewRequest("GET", "/bookcover?isbn="+isbn, nil)
+	w := httptest.NewRecorder()
+	handler.Search(w, req)
+
+	resp := w.Result()
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("Expected status code 200 with a strong ETag, got %d %q", resp.StatusCode, etag)
+	}
+	// The entry has two of its three hours left.
+	if cc := resp.Header.Get("Cache-Control"); cc != "public, max-age=7200" && cc != "public, max-age=7199" {
+		t.Errorf("Expected the remaining freshness as max-age, got %q", cc)
+	}
+	expires, err := http.ParseTime(resp.Header.Get("Expires"))
+	if err != nil || time.Until(expires) < 119*time.Minute || time.Until(expires) > 2*time.Hour {
+		t.Errorf("Expected Expires in two hours, got %q", resp.Header.Get("Expires"))
+	}
+
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		req = httptest.NewRequest("GET", "/bookcover?isbn="+isbn, nil)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		w = httptest.NewRecorder()
+		handler.Search(w, req)
+
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("Expected an empty 304 for If-None-Match %s, got %d %q", ifNoneMatch, w.Code, w.Body)
+		}
+		if w.Header().Get("ETag") != etag || w.Header().Get("Cache-Control") == "" {
+			t.Errorf("Expected the validator and caching headers on the 304, got %v", w.Header())
+		}
+	}
+
+	req = httptest.NewRequest("GET", "/bookcover?isbn="+isbn+"&image_size=small", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.Search(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("Expected a different ETag for another size, got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestBookcoverByISBN_LegacyEntryCachePolicy(t *testing.T) {
+	handler, mockCache := setupTestHandler()
+	mockCache.Set(&memcache.Item{Key: "9780345376597", Value: []byte(expectedURL)})
+
+	req := httptest.NewRequest("GET", "/bookcover/"+isbn, nil)
+	w := httptest.NewRecorder()
+	handler.ByISBN(w, req)
+
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == "" {
+		t.Fatalf("Expected status code 200 with an ETag, got %d", w.Code)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=86400" {
+		t.Errorf("Expected a day for entries without an age, got %q", cc)
+	}
+}
+
+func TestBookcoverSearch_ErrorCachePolicy(t *testing.T) {
+	tests := []struct {
+		name         string
+		scraper      scraper.Scraper
+		query        string
+		status       int
+		cacheControl string
+	}{
+		{"not found", &lookupCounter{}, "isbn=" + notFoundISBN, http.StatusNotFound, "public, max-age=3600"},
+		{"provider unavailable", unavailableScraper{}, "isbn=" + isbn, http.StatusServiceUnavailable, "no-store"},
+		{"bad request", &lookupCounter{}, "isbn=123", http.StatusBadRequest, "no-store"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewBookcoverHandler(service.NewBookcoverService(tt.scraper, mocks.NewMockCache()))
+
+			req := httptest.NewRequest("GET", "/bookcover?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			handler.Search(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status code %d, got %d", tt.status, w.Code)
+			}
+			if cc := w.Header().Get("Cache-Control"); cc != tt.cacheControl {
+				t.Errorf("Expected Cache-Control %q, got %q", tt.cacheControl, cc)
+			}
+			if w.Header().Get("ETag") != "" {
+				t.Errorf("Expected no ETag on errors")
+			}
+		})
+	}
+}
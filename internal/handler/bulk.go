@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"bookcover-api/internal/config"
+	"bookcover-api/internal/middleware"
+	"bookcover-api/pkg/response"
+)
+
+// maxBulkLineBytes bounds a single NDJSON line.
+const maxBulkLineBytes = 64 << 10
+
+// BulkResult is the outcome of one line of a bulk upload. Results are
+// written as soon as they resolve, so they are not in upload order.
+type BulkResult struct {
+	Line int `json:"line"`
+	BatchResult
+}
+
+// bulkLine is one parsed line of the upload. Lines that could not be parsed
+// carry the error to report instead of an item, with status 400 unless
+// status says otherwise.
+type bulkLine struct {
+	number int
+	item   BatchItem
+	status int
+	err    string
+}
+
+// bulkReader returns the next line of the upload, io.EOF at the end, or
+// another error when the upload cannot be read any further.
+type bulkReader func() (bulkLine, error)
+
+// Bulk resolves an NDJSON or CSV upload of any size and streams back one
+// NDJSON result per line as each book resolves. Lines are only read as fast
+// as results are written, and the lookups stop when the client goes away.
+// Each line is charged one unit of quota as it is read; the line that runs
+// out of quota gets a 429 result and the rest of the upload is not read.
+func (h *BatchHandler) Bulk(w http.ResponseWriter, r *http.Request) {
+	// Errors before the stream starts are JSON like everywhere else.
+	w.Header().Set("Content-Type", "application/json")
+
+	opts, err := imageOptions(r)
+	if err != nil {
+		w.Write(response.Error(w, http.StatusBadRequest, err.Error()))
+		return
+	}
+	include, err := imageInfoRequested(r)
+	if err != nil {
+		w.Write(response.Error(w, http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	var next bulkReader
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-ndjson", "application/jsonl":
+		next = ndjsonReader(r.Body)
+	case "text/csv":
+		if next, err = csvReader(r.Body); err != nil {
+			w.Write(response.Error(w, http.StatusBadRequest, config.InvalidCSVHeader))
+			return
+		}
+	default:
+		w.Write(response.Error(w, http.StatusUnsupportedMediaType, config.UnsupportedBulkType))
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// HTTP/1 stops reading the request once the response starts unless asked not to.
+	if err := rc.EnableFullDuplex(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		slog.Warn("failed to enable full duplex for bulk lookup", "error", err)
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	rc.Flush()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	lines := make(chan bulkLine)
+	results := make(chan BulkResult)
+
+	client := middleware.ClientIP(r)
+	go func() {
+		defer close(lines)
+		for {
+			line, err := next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				line.err = config.ErrorReadingBody
+				if errors.Is(err, bufio.ErrTooLong) {
+					line.err = config.BulkLineTooLong
+				}
+			}
+			exhausted := err == nil && !h.cfg.Quota.charge(client, 1)
+			if exhausted {
+				line.status, line.err = http.StatusTooManyRequests, config.RateLimitExceeded
+			}
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil || exhausted {
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range h.cfg.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var line bulkLine
+				var ok bool
+				select {
+				case line, ok = <-lines:
+				case <-ctx.Done():
+					return
+				}
+				if !ok {
+					return
+				}
+
+				result := BulkResult{Line: line.number}
+				if line.err != "" {
+					status := http.StatusBadRequest
+					if line.status != 0 {
+						status = line.status
+					}
+					result.BatchResult = BatchResult{BatchItem: line.item, Status: status, Error: line.err}
+				} else {
+					result.BatchResult = h.lookup(ctx, line.item, opts, include)
+				}
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var buffer bytes.Buffer
+	enc := json.NewEncoder(&buffer)
+	enc.SetEscapeHTML(false)
+	for result := range results {
+		if ctx.Err() != nil {
+			continue
+		}
+		buffer.Reset()
+		enc.Encode(result)
+		if _, err := w.Write(buffer.Bytes()); err != nil {
+			cancel()
+			continue
+		}
+		if err := rc.Flush(); err != nil {
+			cancel()
+		}
+	}
+}
+
+func ndjsonReader(body io.Reader) bulkReader {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), maxBulkLineBytes)
+	number := 0
+	return func() (bulkLine, error) {
+		for scanner.Scan() {
+			number++
+			data := bytes.TrimSpace(scanner.Bytes())
+			if len(data) == 0 {
+				continue
+			}
+
+			line := bulkLine{number: number}
+			if err := json.Unmarshal(data, &line.item); err != nil {
+				line.err = config.InvalidBulkLine
+			}
+			return line, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return bulkLine{number: number + 1}, err
+		}
+		return bulkLine{}, io.EOF
+	}
+}
+
+// csvReader reads the header row, which names the isbn, book_title and
+// author_name columns in any order, and returns a reader for the rows.
+func csvReader(body io.Reader) (bulkReader, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	_, hasISBN := columns[isbnParam]
+	_, hasTitle := columns[bookTitleParam]
+	_, hasAuthor := columns[authorNameParam]
+	if !hasISBN && !(hasTitle && hasAuthor) {
+		return nil, errors.New("csv header names no lookup columns")
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	return func() (bulkLine, error) {
+		record, err := reader.Read()
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return bulkLine{number: parseErr.StartLine, err: config.InvalidBulkLine}, nil
+		}
+		if err != nil {
+			return bulkLine{}, err
+		}
+		number, _ := reader.FieldPos(0)
+		return bulkLine{
+			number: number,
+			item: BatchItem{
+				ISBN:       field(record, isbnParam),
+				BookTitle:  field(record, bookTitleParam),
+				AuthorName: field(record, authorNameParam),
+			},
+		}, nil
+	}, nil
+}
@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"bookcover-api/internal/config"
+	"bookcover-api/internal/metrics"
+	"bookcover-api/internal/middleware"
+	"bookcover-api/internal/service"
+	"bookcover-api/mocks"
+)
+
+func decodeBulk(t *testing.T, body io.Reader) []BulkResult {
+	t.Helper()
+	var results []BulkResult
+	dec := json.NewDecoder(body)
+	for dec.More() {
+		var result BulkResult
+		if err := dec.Decode(&result); err != nil {
+			t.Fatalf("Failed to decode result: %v", err)
+		}
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Line < results[j].Line })
+	return results
+}
+
+func TestBulk_NDJSON(t *testing.T) {
+	handler := NewBatchHandler(service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()))
+
+	body := strings.Join([]string{
+		`{"isbn": "978-0345376596"}`,
+		``,
+		`{"book_title": "Dune", "author_name": "Frank Herbert"}`,
+		`not json`,
+		`{"isbn": "` + notFoundISBN + `"}`,
+	}, "\n")
+	req := httptest.NewRequest("POST", "/bookcover/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	w := httptest.NewRecorder()
+
+	handler.Bulk(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status code 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Expected Content-Type application/x-ndjson, got %s", ct)
+	}
+
+	results := decodeBulk(t, resp.Body)
+	want := []struct {
+		line   int
+		status int
+		url    string
+		err    string
+	}{
+		{1, http.StatusOK, "https://example.com/9780345376596.jpg", ""},
+		{3, http.StatusOK, "https://example.com/Dune.jpg", ""},
+		{4, http.StatusBadRequest, "", config.InvalidBulkLine},
+		{5, http.StatusNotFound, "", "image was not found"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("Expected %d results, got %d: %+v", len(want), len(results), results)
+	}
+	for i, w := range want {
+		got := results[i]
+		if got.Line != w.line || got.Status != w.status || got.URL != w.url || got.Error != w.err {
+			t.Errorf("result %d = %+v, want line %d, status %d, url %q, error %q", i, got, w.line, w.status, w.url, w.err)
+		}
+	}
+}
+
+func TestBulk_CSV(t *testing.T) {
+	handler := NewBatchHandler(service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()))
+
+	body := "author_name,book_title,isbn\n" +
+		",,978-0345376596\n" +
+		"Frank Herbert,Dune,\n" +
+		"\"unterminated,Dune,\n"
+	req := httptest.NewRequest("POST", "/bookcover/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+	w := httptest.NewRecorder()
+
+	handler.Bulk(w, req)
+
+	results := decodeBulk(t, w.Result().Body)
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d: %+v", len(results), results)
+	}
+	if results[0].Line != 2 || results[0].URL != "https://example.com/9780345376596.jpg" {
+		t.Errorf("Unexpected ISBN result %+v", results[0])
+	}
+	if results[1].Line != 3 || results[1].URL != "https://example.com/Dune.jpg" {
+		t.Errorf("Unexpected title and author result %+v", results[1])
+	}
+	if results[2].Line != 4 || results[2].Error != config.InvalidBulkLine {
+		t.Errorf("Unexpected malformed line result %+v", results[2])
+	}
+}
+
+func TestBulk_InvalidRequests(t *testing.T) {
+	handler := NewBatchHandler(service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()))
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		err         string
+	}{
+		{"unsupported type", "application/json", `[]`, http.StatusUnsupportedMediaType, config.UnsupportedBulkType},
+		{"csv without lookup columns", "text/csv", "title,author\nDune,Frank Herbert\n", http.StatusBadRequest, config.InvalidCSVHeader},
+		{"empty csv", "text/csv", "", http.StatusBadRequest, config.InvalidCSVHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/bookcover/bulk", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+
+			handler.Bulk(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != tt.status {
+				t.Errorf("Expected status code %d, got %d", tt.status, resp.StatusCode)
+			}
+			var result map[string]string
+			json.NewDecoder(resp.Body).Decode(&result)
+			if result["error"] != tt.err {
+				t.Errorf("Expected error %q, got %q", tt.err, result["error"])
+			}
+		})
+	}
+}
+
+func TestBulk_StreamsWhileUploading(t *testing.T) {
+	handler := NewBatchHandler(service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()))
+	srv := httptest.NewServer(middleware.Chain(handler.Bulk, metrics.MetricsMiddleware()))
+	defer srv.Close()
+
+	upload, uploadWriter := io.Pipe()
+	defer uploadWriter.Close()
+	req, _ := http.NewRequest("POST", srv.URL, upload)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	responses := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("request failed: %v", err)
+			close(responses)
+			return
+		}
+		responses <- resp
+	}()
+
+	io.WriteString(uploadWriter, `{"isbn": "978-0345376596"}`+"\n")
+
+	var resp *http.Response
+	select {
+	case resp = <-responses:
+	case <-time.After(5 * time.Second):
+		t.Fatal("response did not start before the upload finished")
+	}
+	if resp == nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	// The first result arrives while the upload is still open.
+	lines := bufio.NewScanner(resp.Body)
+	if !lines.Scan() {
+		t.Fatalf("expected a result line, got error %v", lines.Err())
+	}
+	var result BulkResult
+	if err := json.Unmarshal(lines.Bytes(), &result); err != nil || result.Status != http.StatusOK {
+		t.Errorf("unexpected first result %s", lines.Bytes())
+	}
+
+	io.WriteString(uploadWriter, `{"isbn": "`+notFoundISBN+`"}`+"\n")
+	uploadWriter.Close()
+	if !lines.Scan() {
+		t.Fatalf("expected a second result line, got error %v", lines.Err())
+	}
+	if lines.Scan() {
+		t.Errorf("unexpected extra line %s", lines.Bytes())
+	}
+}
+
+func TestBulk_StopsWhenClientGoesAway(t *testing.T) {
+	s := &countingScraper{release: make(chan struct{})}
+	handler := NewBatchHandlerWithConfig(service.NewBookcoverService(s, mocks.NewMockCache()), BatchConfig{Workers: 2})
+
+	upload, uploadWriter := io.Pipe()
+	go func() {
+		for {
+			if _, err := io.WriteString(uploadWriter, `{"isbn": "978-0345376596"}`+"\n"); err != nil {
+				return
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("POST", "/bookcover/bulk", upload).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	done := make(chan struct{})
+	go func() {
+		handler.Bulk(httptest.NewRecorder(), req)
+		close(done)
+	}()
+
+	s.release <- struct{}{}
+	cancel()
+	// Let the lookups that were already running finish.
+	go func() {
+		for {
+			select {
+			case s.release <- struct{}{}:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler kept running after the client went away")
+	}
+	uploadWriter.CloseWithError(io.ErrClosedPipe)
+}
+
+func TestBulk_StopsWhenQuotaRunsOut(t *testing.T) {
+	quota := NewQuotaMeter(mocks.NewMockCache(), middleware.RateLimitConfig{DailyLimit: 2, MonthlyLimit: 100})
+	handler := NewBatchHandlerWithConfig(service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()), BatchConfig{Quota: quota})
+
+	body := strings.Repeat(`{"isbn": "978-0345376596"}`+"\n", 4)
+	req := httptest.NewRequest("POST", "/bookcover/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	w := httptest.NewRecorder()
+
+	handler.Bulk(w, req)
+
+	results := decodeBulk(t, w.Result().Body)
+	if len(results) != 3 {
+		t.Fatalf("Expected results up to the line over the quota, got %+v", results)
+	}
+	for _, result := range results[:2] {
+		if result.Status != http.StatusOK {
+			t.Errorf("line %d: expected status 200, got %d", result.Line, result.Status)
+		}
+	}
+	if last := results[2]; last.Line != 3 || last.Status != http.StatusTooManyRequests || last.Error != config.RateLimitExceeded {
+		t.Errorf("Expected a 429 for line 3, got %+v", last)
+	}
+}
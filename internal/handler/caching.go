@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"bookcover-api/internal/service"
+	"bookcover-api/pkg/response"
+)
+
+// How long clients may cache /bookcover lookups. Found covers are fresh for
+// as long as their cache entry is; these apply when that is unknown or
+// unbounded, and to covers that were not found.
+const (
+	coverMaxAge    = 24 * time.Hour
+	notFoundMaxAge = time.Hour
+)
+
+// coverETag is a strong validator of the JSON body for a cover, derived from
+// the resolved URL and the image info included with it.
+func coverETag(cover *service.Cover) string {
+	hash := sha256.New()
+	io.WriteString(hash, cover.URL)
+	if cover.ImageInfo != nil {
+		json.NewEncoder(hash).Encode(cover.ImageInfo)
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// cachedCoverResponse writes the caching headers for a found cover and its
+// JSON body, or a 304 without body when the client already has it.
+func cachedCoverResponse(w http.ResponseWriter, r *http.Request, cover *service.Cover) []byte {
+	maxAge, ok := cover.FreshFor()
+	if !ok {
+		maxAge = coverMaxAge
+	}
+
+	etag := coverETag(cover)
+	w.Header().Set("ETag", etag)
+	cacheFor(w, maxAge)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	return coverResponse(w, cover)
+}
+
+// cachedLookupError writes the JSON error for a failed lookup. Covers that
+// were not found may be cached for a while; other errors may not.
+func cachedLookupError(w http.ResponseWriter, err error) []byte {
+	status, _, message := lookupStatus(err)
+	if status == http.StatusNotFound {
+		cacheFor(w, notFoundMaxAge)
+	} else {
+		noStore(w)
+	}
+	return response.Error(w, status, message)
+}
+
+func cacheFor(w http.ResponseWriter, maxAge time.Duration) {
+	seconds := int(maxAge.Seconds())
+	header := w.Header()
+	header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", seconds))
+	header.Set("Expires", time.Now().Add(time.Duration(seconds)*time.Second).UTC().Format(http.TimeFormat))
+}
+
+func noStore(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Del("Expires")
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, using the
+// weak comparison RFC 9110 prescribes for it.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,393 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"bookcover-api/internal/config"
+	"bookcover-api/internal/imageinfo"
+	"bookcover-api/internal/middleware"
+	"bookcover-api/internal/service"
+	"bookcover-api/pkg/response"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// maxGraphQLBodyBytes bounds the body of POST /graphql.
+const maxGraphQLBodyBytes = 64 << 10
+
+const (
+	// codeTooManyLookups is the error code of lookups past the per-query limit.
+	codeTooManyLookups = "too_many_lookups"
+	// codeRateLimitExceeded is the error code of lookups past the client's quota.
+	codeRateLimitExceeded = "rate_limit_exceeded"
+)
+
+//go:embed schema.graphql
+var graphQLSchema string
+
+var (
+	errTooManyLookups    = errors.New(config.GraphQLTooManyLookups)
+	errRateLimitExceeded = errors.New(config.RateLimitExceeded)
+)
+
+// GraphQLHandler answers GraphQL queries for books and their covers. Lookups
+// of one query share a loader, so a book asked for in several places is
+// looked up once, and the books of a books query are looked up concurrently.
+type GraphQLHandler struct {
+	schema *graphql.Schema
+	cfg    BatchConfig
+}
+
+func NewGraphQLHandler(svc service.BookcoverService) *GraphQLHandler {
+	return NewGraphQLHandlerWithConfig(svc, DefaultBatchConfig())
+}
+
+// NewGraphQLHandlerWithConfig creates a GraphQLHandler. cfg.MaxItems bounds the
+// distinct lookups of one query, cfg.Workers how many of them run at once and
+// cfg.Quota charges each of them.
+func NewGraphQLHandlerWithConfig(svc service.BookcoverService, cfg BatchConfig) *GraphQLHandler {
+	if cfg.MaxItems <= 0 {
+		cfg.MaxItems = DefaultBatchMaxItems
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultBatchWorkers
+	}
+	return &GraphQLHandler{
+		schema: graphql.MustParseSchema(graphQLSchema, &queryResolver{service: svc}),
+		cfg:    cfg,
+	}
+}
+
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// readGraphQLRequest reads a query sent as a JSON body on POST, or as query
+// string parameters otherwise.
+func readGraphQLRequest(r *http.Request, body []byte) (graphQLRequest, error) {
+	var req graphQLRequest
+	if r.Method != http.MethodPost {
+		params := r.URL.Query()
+		req.Query = params.Get("query")
+		req.OperationName = params.Get("operationName")
+		if raw := params.Get("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+				return req, err
+			}
+		}
+	} else if err := json.Unmarshal(body, &req); err != nil {
+		return req, err
+	}
+
+	if req.Query == "" {
+		return req, errors.New("missing query")
+	}
+	return req, nil
+}
+
+// Query answers GraphQL queries sent with GET or POST; the route's HttpMethod
+// middleware rejects other methods. Errors of the query itself are reported
+// in the GraphQL response, with status 200.
+func (h *GraphQLHandler) Query(w http.ResponseWriter, r *http.Request) {
+	var body []byte
+	if r.Method == http.MethodPost {
+		var err error
+		if body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, maxGraphQLBodyBytes)); err != nil {
+			w.Write(response.Error(w, http.StatusBadRequest, config.InvalidGraphQLRequest))
+			return
+		}
+	}
+	req, err := readGraphQLRequest(r, body)
+	if err != nil {
+		w.Write(response.Error(w, http.StatusBadRequest, config.InvalidGraphQLRequest))
+		return
+	}
+
+	loader := newCoverLoader(r.Context(), middleware.ClientIP(r), h.cfg)
+	ctx := context.WithValue(r.Context(), coverLoaderKey{}, loader)
+	result := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	var buffer bytes.Buffer
+	enc := json.NewEncoder(&buffer)
+	enc.SetEscapeHTML(false)
+	enc.Encode(result)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buffer.Bytes())
+}
+
+type coverLoaderKey struct{}
+
+// coverLoader runs the lookups of one query. Lookups of the same book share
+// one call to the service, and at most cfg.Workers of them run at once. They
+// stop when ctx, the context of the request, is done.
+//
+// Each book counts once against maxCalls and is charged to client once,
+// including the second call that asks for its image info.
+type coverLoader struct {
+	ctx      context.Context
+	client   string
+	quota    *QuotaMeter
+	slots    chan struct{}
+	maxCalls int
+
+	mu    sync.Mutex
+	calls map[coverQuery]*coverCall
+	// books holds the books counted so far, with the error their lookups
+	// fail with when the book was over the quota.
+	books map[coverQuery]error
+}
+
+type coverCall struct {
+	done  chan struct{}
+	cover *service.Cover
+	err   error
+}
+
+func newCoverLoader(ctx context.Context, client string, cfg BatchConfig) *coverLoader {
+	return &coverLoader{
+		ctx:      ctx,
+		client:   client,
+		quota:    cfg.Quota,
+		slots:    make(chan struct{}, cfg.Workers),
+		maxCalls: cfg.MaxItems,
+		calls:    make(map[coverQuery]*coverCall),
+		books:    make(map[coverQuery]error),
+	}
+}
+
+func loaderFrom(ctx context.Context) *coverLoader {
+	return ctx.Value(coverLoaderKey{}).(*coverLoader)
+}
+
+// start looks the query up in the background, unless a lookup of it was
+// already started.
+func (l *coverLoader) start(svc service.BookcoverService, query coverQuery) (*coverCall, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if call, ok := l.calls[query]; ok {
+		return call, nil
+	}
+
+	book := query
+	book.imageInfo = false
+	err, counted := l.books[book]
+	if !counted {
+		if len(l.books) >= l.maxCalls {
+			return nil, errTooManyLookups
+		}
+		if !l.quota.charge(l.client, 1) {
+			err = errRateLimitExceeded
+		}
+		l.books[book] = err
+	}
+
+	call := &coverCall{done: make(chan struct{})}
+	l.calls[query] = call
+	if err != nil {
+		call.err = err
+		close(call.done)
+		return call, nil
+	}
+	go func() {
+		defer close(call.done)
+		l.slots <- struct{}{}
+		defer func() { <-l.slots }()
+		call.cover, call.err = query.resolve(l.ctx, svc)
+	}()
+	return call, nil
+}
+
+// load looks the query up and waits for the result.
+func (l *coverLoader) load(ctx context.Context, svc service.BookcoverService, query coverQuery) (*service.Cover, error) {
+	call, err := l.start(svc, query)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-call.done:
+		return call.cover, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type queryResolver struct {
+	service service.BookcoverService
+}
+
+func (q *queryResolver) Book(args struct{ ISBN string }) *bookResolver {
+	return q.book(coverQuery{isbn: args.ISBN})
+}
+
+func (q *queryResolver) Books(ctx context.Context, args struct{ ISBNs []string }) []*bookResolver {
+	books := make([]*bookResolver, len(args.ISBNs))
+	for i, isbn := range args.ISBNs {
+		books[i] = q.book(coverQuery{isbn: isbn})
+		// Start every lookup now rather than when each cover is resolved.
+		if books[i].invalid == "" {
+			loaderFrom(ctx).start(q.service, books[i].query)
+		}
+	}
+	return books
+}
+
+func (q *queryResolver) Search(args struct{ Title, Author string }) *bookResolver {
+	return q.book(coverQuery{bookTitle: args.Title, authorName: args.Author})
+}
+
+func (q *queryResolver) book(input coverQuery) *bookResolver {
+	book := &bookResolver{service: q.service, input: input, query: input}
+	book.invalid = book.query.validate()
+	return book
+}
+
+// bookResolver is a book as it was asked for. input keeps the arguments as
+// given and query the validated lookup; invalid is the validation message.
+type bookResolver struct {
+	service service.BookcoverService
+	input   coverQuery
+	query   coverQuery
+	invalid string
+}
+
+func (b *bookResolver) ISBN() *string   { return optional(b.input.isbn) }
+func (b *bookResolver) Title() *string  { return optional(b.input.bookTitle) }
+func (b *bookResolver) Author() *string { return optional(b.input.authorName) }
+
+func (b *bookResolver) Cover(ctx context.Context) (*coverResolver, error) {
+	if b.invalid != "" {
+		return nil, &graphQLError{code: codeInvalidRequest, message: b.invalid}
+	}
+
+	cover, err := loaderFrom(ctx).load(ctx, b.service, b.query)
+	if err != nil {
+		return nil, lookupGraphQLError(err)
+	}
+	return &coverResolver{book: b, cover: cover}, nil
+}
+
+type coverResolver struct {
+	book  *bookResolver
+	cover *service.Cover
+}
+
+func (c *coverResolver) URL() string { return c.cover.URL }
+
+func (c *coverResolver) Small() string {
+	return c.sized(service.ImageOptions{Size: service.ImageSizeSmall})
+}
+
+func (c *coverResolver) Medium() string {
+	return c.sized(service.ImageOptions{Size: service.ImageSizeMedium})
+}
+
+func (c *coverResolver) Large() string {
+	return c.sized(service.ImageOptions{Size: service.ImageSizeLarge})
+}
+
+func (c *coverResolver) sized(opts service.ImageOptions) string {
+	url, _ := c.cover.Resized(opts)
+	return url
+}
+
+func (c *coverResolver) Resized(args struct {
+	Width  *int32
+	Height *int32
+	Fit    *string
+}) (string, error) {
+	var opts service.ImageOptions
+	if args.Width != nil {
+		opts.Width = int(*args.Width)
+	}
+	if args.Height != nil {
+		opts.Height = int(*args.Height)
+	}
+	if args.Fit != nil {
+		opts.Fit = strings.ToLower(*args.Fit)
+	}
+	url, err := c.cover.Resized(opts)
+	if err != nil {
+		return "", &graphQLError{code: codeInvalidRequest, message: err.Error()}
+	}
+	return url, nil
+}
+
+func (c *coverResolver) Source() *string { return optional(c.cover.Source) }
+
+func (c *coverResolver) Cache() string { return strings.ToUpper(string(c.cover.CacheStatus)) }
+
+// ImageInfo looks the book up again asking for image info. The cover is
+// cached by then, so only the image itself may need to be fetched, and the
+// loader does not count or charge the book a second time.
+func (c *coverResolver) ImageInfo(ctx context.Context) (*imageInfoResolver, error) {
+	query := c.book.query
+	query.imageInfo = true
+	cover, err := loaderFrom(ctx).load(ctx, c.book.service, query)
+	if err != nil {
+		return nil, lookupGraphQLError(err)
+	}
+	if cover.ImageInfo == nil {
+		return nil, nil
+	}
+	return &imageInfoResolver{cover.ImageInfo}, nil
+}
+
+type imageInfoResolver struct {
+	info *imageinfo.Info
+}
+
+func (i *imageInfoResolver) Width() int32          { return int32(i.info.Width) }
+func (i *imageInfoResolver) Height() int32         { return int32(i.info.Height) }
+func (i *imageInfoResolver) Bytes() int32          { return int32(i.info.Bytes) }
+func (i *imageInfoResolver) MimeType() string      { return i.info.MIMEType }
+func (i *imageInfoResolver) DominantColor() string { return i.info.DominantColor }
+func (i *imageInfoResolver) AverageColor() string  { return i.info.AverageColor }
+func (i *imageInfoResolver) BlurHash() string      { return i.info.BlurHash }
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// graphQLError reports a failed lookup with the error code the /v1 API uses,
+// under extensions.code.
+type graphQLError struct {
+	code    string
+	message string
+}
+
+func (e *graphQLError) Error() string { return e.message }
+
+func (e *graphQLError) Extensions() map[string]any {
+	return map[string]any{"code": e.code}
+}
+
+// lookupGraphQLError maps a lookup error to the error of the cover field. Books
+// that were not found have a null cover and no error.
+func lookupGraphQLError(err error) error {
+	if errors.Is(err, errTooManyLookups) {
+		return &graphQLError{code: codeTooManyLookups, message: err.Error()}
+	}
+	if errors.Is(err, errRateLimitExceeded) {
+		return &graphQLError{code: codeRateLimitExceeded, message: err.Error()}
+	}
+	_, code, message := lookupStatus(err)
+	if code == codeNotFound {
+		return nil
+	}
+	return &graphQLError{code: code, message: message}
+}
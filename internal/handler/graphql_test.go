@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"bookcover-api/internal/config"
+	"bookcover-api/internal/middleware"
+	"bookcover-api/internal/scraper"
+	"bookcover-api/internal/service"
+	"bookcover-api/mocks"
+)
+
+type graphQLResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Path       []any          `json:"path"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func postGraphQL(t *testing.T, handler *GraphQLHandler, query string, variables map[string]any) graphQLResponse {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+	w := httptest.NewRecorder()
+
+	handler.Query(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status code 200, got %d", resp.StatusCode)
+	}
+	var result graphQLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+	return result
+}
+
+func TestGraphQL_Book(t *testing.T) {
+	handler := NewGraphQLHandler(service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()))
+
+	result := postGraphQL(t, handler, `{
+		book(isbn: "978-0345376596") {
+			isbn
+			cover { url small resized(width: 300) source cache }
+		}
+	}`, nil)
+
+	if len(result.Errors) > 0 {
+		t.Fatalf("Unexpected errors: %+v", result.Errors)
+	}
+	var book struct {
+		ISBN  string `json:"isbn"`
+		Cover struct {
+			URL     string `json:"url"`
+			Small   string `json:"small"`
+			Resized string `json:"resized"`
+			Source  string `json:"source"`
+			Cache   string `json:"cache"`
+		} `json:"cover"`
+	}
+	json.Unmarshal(result.Data["book"], &book)
+	if book.ISBN != "978-0345376596" {
+		t.Errorf("Expected the ISBN as given, got %q", book.ISBN)
+	}
+	if book.Cover.URL != "https://example.com/9780345376596.jpg" || book.Cover.Source != "counting" || book.Cover.Cache != "MISS" {
+		t.Errorf("Unexpected cover %+v", book.Cover)
+	}
+	// Covers hosted outside the resizing CDNs are returned as-is in every size.
+	if book.Cover.Small != book.Cover.URL || book.Cover.Resized != book.Cover.URL {
+		t.Errorf("Expected sizes to match the URL, got %+v", book.Cover)
+	}
+}
+
+func TestGraphQL_BooksLookEachBookUpOnce(t *testing.T) {
+	counter := &lookupCounter{}
+	handler := NewGraphQLHandler(service.NewBookcoverService(counter, nil))
+
+	result := postGraphQL(t, handler, `query($isbns: [String!]!) {
+		books(isbns: $isbns) { isbn cover { url } }
+		again: book(isbn: "9780345376596") { cover { url } }
+	}`, map[string]any{"isbns": []string{"978-0345376596", "9780345376596", notFoundISBN, "123"}})
+
+	var books []struct {
+		ISBN  string `json:"isbn"`
+		Cover *struct {
+			URL string `json:"url"`
+		} `json:"cover"`
+	}
+	json.Unmarshal(result.Data["books"], &books)
+	if len(books) != 4 {
+		t.Fatalf("Expected 4 books, got %d", len(books))
+	}
+	if books[0].Cover == nil || books[1].Cover == nil {
+		t.Fatalf("Expected covers for the first two books, got %+v", books)
+	}
+	if books[2].Cover != nil || books[3].Cover != nil {
+		t.Errorf("Expected no cover for the missing and invalid books, got %+v", books)
+	}
+
+	// Only the invalid ISBN is an error; a missing book just has no cover.
+	if len(result.Errors) != 1 {
+		t.Fatalf("Expected 1 error, got %+v", result.Errors)
+	}
+	if result.Errors[0].Message != config.InvalidISBN || result.Errors[0].Extensions["code"] != codeInvalidRequest {
+		t.Errorf("Unexpected error %+v", result.Errors[0])
+	}
+
+	if n := counter.calls.Load(); n != 2 {
+		t.Errorf("Expected 2 lookups, got %d", n)
+	}
+}
+
+func TestGraphQL_ProviderErrorCode(t *testing.T) {
+	handler := NewGraphQLHandler(service.NewBookcoverService(unavailableScraper{}, mocks.NewMockCache()))
+
+	result := postGraphQL(t, handler, `{ search(title: "dune", author: "herbert") { title cover { url } } }`, nil)
+
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != codeProviderUnavailable {
+		t.Fatalf("Expected a provider_unavailable error, got %+v", result.Errors)
+	}
+	if !strings.Contains(string(result.Data["search"]), `"title":"dune"`) {
+		t.Errorf("Expected the book without a cover, got %s", result.Data["search"])
+	}
+}
+
+func TestGraphQL_TooManyLookups(t *testing.T) {
+	handler := NewGraphQLHandlerWithConfig(service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()), BatchConfig{MaxItems: 1})
+
+	result := postGraphQL(t, handler, `{ books(isbns: ["9780345376596", "9780345376597"]) { cover { url } } }`, nil)
+
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != codeTooManyLookups {
+		t.Errorf("Expected a too_many_lookups error, got %+v", result.Errors)
+	}
+}
+
+func TestGraphQL_GetAndInvalidRequest(t *testing.T) {
+	handler := NewGraphQLHandler(service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()))
+
+	req := httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(`{ book(isbn: "9780345376596") { cover { url } } }`), nil)
+	w := httptest.NewRecorder()
+	handler.Query(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "9780345376596.jpg") {
+		t.Errorf("Expected the cover over GET, got %d %s", w.Code, w.Body)
+	}
+
+	req = httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"variables": {}}`))
+	w = httptest.NewRecorder()
+	handler.Query(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code 400 without a query, got %d", w.Code)
+	}
+}
+
+func TestGraphQL_ChargesEachDistinctLookup(t *testing.T) {
+	quota := NewQuotaMeter(mocks.NewMockCache(), middleware.RateLimitConfig{DailyLimit: 2, MonthlyLimit: 100})
+	handler := NewGraphQLHandlerWithConfig(service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()), BatchConfig{Quota: quota})
+
+	// The repeated ISBN is one lookup, so only the last book is over the quota.
+	result := postGraphQL(t, handler, `{
+		books(isbns: ["9780345376596", "9780345376596", "9780345376597", "9780345376598"]) { cover { url } }
+	}`, nil)
+
+	var books []struct {
+		Cover *struct {
+			URL string `json:"url"`
+		} `json:"cover"`
+	}
+	json.Unmarshal(result.Data["books"], &books)
+	if len(books) != 4 || books[0].Cover == nil || books[1].Cover == nil || books[2].Cover == nil || books[3].Cover != nil {
+		t.Fatalf("Expected covers for the books within the quota only, got %s", result.Data["books"])
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != codeRateLimitExceeded {
+		t.Errorf("Expected a rate_limit_exceeded error for the last book, got %+v", result.Errors)
+	}
+}
+
+func TestGraphQL_ImageInfoAtItemLimit(t *testing.T) {
+	quota := NewQuotaMeter(mocks.NewMockCache(), middleware.RateLimitConfig{DailyLimit: 2, MonthlyLimit: 100})
+	svc := service.NewBookcoverServiceWithConfig(mocks.NewMockCache(), service.Config{
+		Providers: []scraper.Scraper{&countingScraper{}},
+		Analyzer:  contractAnalyzer{},
+	})
+	handler := NewGraphQLHandlerWithConfig(svc, BatchConfig{MaxItems: 2, Quota: quota})
+
+	// Asking for the image info does not count the books twice, against
+	// either the item limit or the quota.
+	result := postGraphQL(t, handler, `{
+		books(isbns: ["9780345376596", "9780345376597"]) { cover { url imageInfo { width } } }
+	}`, nil)
+
+	if len(result.Errors) > 0 {
+		t.Fatalf("Unexpected errors: %+v", result.Errors)
+	}
+	var books []struct {
+		Cover struct {
+			ImageInfo *struct {
+				Width int `json:"width"`
+			} `json:"imageInfo"`
+		} `json:"cover"`
+	}
+	json.Unmarshal(result.Data["books"], &books)
+	if len(books) != 2 {
+		t.Fatalf("Expected 2 books, got %s", result.Data["books"])
+	}
+	for i, book := range books {
+		if book.Cover.ImageInfo == nil || book.Cover.ImageInfo.Width != 300 {
+			t.Errorf("book %d: expected image info with width 300, got %+v", i, book.Cover.ImageInfo)
+		}
+	}
+}
+
+// lookupCounter finds a cover for every ISBN except notFoundISBN and counts
+// the lookups that reach it.
+type lookupCounter struct {
+	calls atomic.Int32
+}
+
+func (c *lookupCounter) Name() string { return "counter" }
+
+func (c *lookupCounter) FetchByTitleAuthor(ctx context.Context, bookTitle, authorName string) (string, error) {
+	c.calls.Add(1)
+	return "https://example.com/" + bookTitle + ".jpg", nil
+}
+
+func (c *lookupCounter) FetchByISBN(ctx context.Context, isbn string) (string, error) {
+	c.calls.Add(1)
+	if isbn == notFoundISBN {
+		return "", scraper.ErrNotFound
+	}
+	return "https://example.com/" + isbn + ".jpg", nil
+}
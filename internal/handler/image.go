@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"bookcover-api/internal/config"
+	"bookcover-api/internal/imagecache"
+	"bookcover-api/internal/imageproc"
+	"bookcover-api/internal/imageproxy"
+	"bookcover-api/internal/imageurl"
+	"bookcover-api/internal/metrics"
+	"bookcover-api/internal/service"
+	"bookcover-api/pkg/response"
+)
+
+// ImageHandler serves the cover bytes themselves for clients that cannot
+// hotlink the provider's CDN.
+type ImageHandler struct {
+	service service.BookcoverService
+	images  *imageproxy.Client
+	cache   *imagecache.Store
+}
+
+// NewImageHandler creates an ImageHandler. cache may be nil, in which case
+// every image is fetched from upstream.
+func NewImageHandler(svc service.BookcoverService, images *imageproxy.Client, cache *imagecache.Store) *ImageHandler {
+	return &ImageHandler{
+		service: svc,
+		images:  images,
+		cache:   cache,
+	}
+}
+
+func (h *ImageHandler) Image(w http.ResponseWriter, r *http.Request) {
+	// Errors are JSON like everywhere else; the header is replaced once the image is found.
+	w.Header().Set("Content-Type", "application/json")
+
+	format, err := imageproc.ParseFormat(r.URL.Query().Get(formatParam))
+	if err != nil {
+		w.Write(response.Error(w, http.StatusBadRequest, config.InvalidFormat))
+		return
+	}
+	if format == "" {
+		format = imageproc.Negotiate(r.Header.Get("Accept"))
+	}
+
+	query, ok := parseCoverQuery(w, r)
+	if !ok {
+		return
+	}
+
+	cover, err := query.resolve(r.Context(), h.service)
+	if err != nil {
+		if query.fallback {
+			servePlaceholder(w, r, query.placeholder())
+			return
+		}
+		w.Write(lookupError(w, err))
+		return
+	}
+
+	imageURL := cover.URL
+	opts := query.opts
+	meta := imagecache.Meta{
+		URL:     imageURL,
+		Variant: opts.Variant(),
+		ISBN:    query.isbn,
+	}
+	if format != "" {
+		meta.Variant += "." + string(format)
+	}
+
+	if h.cache != nil {
+		if file, cached, ok := h.cache.Get(meta.URL, meta.Variant); ok {
+			defer file.Close()
+			setValidators(w, cached.ETag, cached.LastModified)
+			h.writeHeaders(w, cached.ContentType, cached.Size, "HIT")
+			if r.Method == http.MethodHead {
+				return
+			}
+			n, err := io.Copy(w, file)
+			metrics.RecordImageBytesServed("cache", n)
+			if err != nil {
+				slog.Warn("failed to stream cached cover image", "url", imageURL, "error", err)
+			}
+			return
+		}
+	}
+
+	image, err := h.images.Open(r.Context(), imageURL)
+	if err != nil {
+		if query.fallback {
+			servePlaceholder(w, r, query.placeholder())
+			return
+		}
+		w.Write(imageError(w, err))
+		return
+	}
+	defer image.Body.Close()
+
+	// Covers on CDNs without size tokens, and format conversions, go through
+	// our own pipeline.
+	width, height := opts.Dimensions()
+	resize := (width > 0 || height > 0) && !imageurl.Resizable(imageURL)
+	process := resize || (format != "" && format != imageproc.FormatOf(image.ContentType))
+
+	// HEAD is answered as soon as the headers are known, without reading the
+	// image. Only the length of a processed image is unknown until it is
+	// encoded, so it is left out.
+	if r.Method == http.MethodHead {
+		if process {
+			output := imageproc.OutputFormat(imageproc.FormatOf(image.ContentType), format)
+			h.writeHeaders(w, output.ContentType(), -1, "MISS")
+			return
+		}
+		setValidators(w, image.ETag, image.LastModified)
+		h.writeHeaders(w, image.ContentType, image.ContentLength, "MISS")
+		return
+	}
+
+	if process {
+		data, contentType, err := imageproc.Process(image.Body, imageproc.Options{
+			Width:  width,
+			Height: height,
+			Pad:    opts.Fit == service.FitPad,
+			Format: format,
+		})
+		if err != nil {
+			if query.fallback {
+				servePlaceholder(w, r, query.placeholder())
+				return
+			}
+			w.Write(imageError(w, err))
+			return
+		}
+		image = &imageproxy.Image{
+			Body:          io.NopCloser(bytes.NewReader(data)),
+			ContentType:   contentType,
+			ContentLength: int64(len(data)),
+		}
+	}
+
+	setValidators(w, image.ETag, image.LastModified)
+	h.writeHeaders(w, image.ContentType, image.ContentLength, "MISS")
+
+	var dst io.Writer = w
+	var pending *imagecache.Writer
+	if h.cache != nil {
+		meta.ContentType = image.ContentType
+		meta.ETag, meta.LastModified = image.ETag, image.LastModified
+		if pending, err = h.cache.Create(meta); err != nil {
+			slog.Warn("failed to cache cover image", "url", imageURL, "error", err)
+		} else {
+			dst = io.MultiWriter(w, pending)
+		}
+	}
+
+	n, err := io.Copy(dst, image.Body)
+	metrics.RecordImageBytesServed("upstream", n)
+	if err != nil {
+		slog.Warn("failed to stream cover image", "url", imageURL, "error", err)
+	}
+
+	if pending == nil {
+		return
+	}
+	if err != nil || n != image.ContentLength {
+		pending.Abort()
+	} else if err := pending.Commit(); err != nil {
+		slog.Warn("failed to cache cover image", "url", imageURL, "error", err)
+	}
+}
+
+// writeHeaders writes the headers of an image response. A negative length
+// means it is not known and leaves Content-Length out.
+func (h *ImageHandler) writeHeaders(w http.ResponseWriter, contentType string, length int64, cacheStatus string) {
+	header := w.Header()
+	header.Set("Content-Type", contentType)
+	if length >= 0 {
+		header.Set("Content-Length", strconv.FormatInt(length, 10))
+	}
+	header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.images.MaxAge().Seconds())))
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Vary", "Accept")
+	header.Set("X-Cache", cacheStatus)
+	w.WriteHeader(http.StatusOK)
+}
+
+// setValidators passes on the upstream validators of an image, so that
+// clients revalidate the same way whether it came from the cache or not.
+func setValidators(w http.ResponseWriter, etag, lastModified string) {
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if lastModified != "" {
+		w.Header().Set("Last-Modified", lastModified)
+	}
+}
+
+// imageError maps an image proxy error to the matching JSON error response.
+func imageError(w http.ResponseWriter, err error) []byte {
+	var statusErr *imageproxy.StatusError
+	switch {
+	case errors.Is(err, imageproxy.ErrHostNotAllowed):
+		return response.Error(w, http.StatusBadGateway, config.ImageHostNotAllowed)
+	case errors.Is(err, imageproxy.ErrTooLarge), errors.Is(err, imageproc.ErrTooManyPixels):
+		return response.Error(w, http.StatusBadGateway, config.ImageTooLarge)
+	case errors.Is(err, imageproc.ErrUnsupportedFormat):
+		return response.Error(w, http.StatusBadGateway, config.ImageConversionFailed)
+	case errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound:
+		return response.Error(w, http.StatusNotFound, config.BookcoverNotFound)
+	default:
+		slog.Warn("failed to proxy cover image", "error", err)
+		return response.Error(w, http.StatusBadGateway, config.ImageFetchFailed)
+	}
+}
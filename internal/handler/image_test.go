@@ -0,0 +1,320 @@
+package handler
+
+import (
+	"bytes"
+	"image"
+	"image/color/palette"
+	"image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"bookcover-api/internal/imagecache"
+	"bookcover-api/internal/imageproxy"
+	"bookcover-api/internal/service"
+	"bookcover-api/mocks"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+func setupImageHandler(t *testing.T, upstream http.HandlerFunc) (*ImageHandler, *httptest.Server) {
+	t.Helper()
+	srv := httptest.NewTLSServer(upstream)
+	t.Cleanup(srv.Close)
+
+	mockCache := mocks.NewMockCache()
+	mockCache.Set(&memcache.Item{Key: "9780345376596", Value: []byte(srv.URL + "/cover.jpg")})
+
+	cfg := imageproxy.DefaultConfig()
+	cfg.AllowedHosts = []string{"127.0.0.1"}
+	cfg.Transport = srv.Client().Transport
+
+	svc := service.NewBookcoverService(unavailableScraper{}, mockCache)
+	return NewImageHandler(svc, imageproxy.NewClient(cfg), nil), srv
+}
+
+func TestImage_StreamsCover(t *testing.T) {
+	jpeg := []byte("\xff\xd8\xff\xe0\x00\x10JFIF")
+	handler, _ := setupImageHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write(jpeg)
+	})
+
+	req := httptest.NewRequest("GET", "/bookcover/image?isbn=978-0345376596", nil)
+	w := httptest.NewRecorder()
+
+	handler.Image(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status code 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("Expected Content-Type image/jpeg, got %s", ct)
+	}
+	if cl := resp.Header.Get("Content-Length"); cl != "10" {
+		t.Errorf("Expected Content-Length 10, got %s", cl)
+	}
+	if cc := resp.Header.Get("Cache-Control"); cc != "public, max-age=86400" {
+		t.Errorf("Expected Cache-Control public, max-age=86400, got %s", cc)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != string(jpeg) {
+		t.Errorf("Expected cover bytes to be streamed, got %q", body)
+	}
+}
+
+func TestImage_UpstreamNotAnImage(t *testing.T) {
+	handler, _ := setupImageHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html></html>"))
+	})
+
+	req := httptest.NewRequest("GET", "/bookcover/image?isbn=978-0345376596", nil)
+	w := httptest.NewRecorder()
+
+	handler.Image(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("Expected status code 502, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON error, got Content-Type %s", ct)
+	}
+}
+
+func TestImage_InvalidISBN(t *testing.T) {
+	handler, _ := setupImageHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("upstream should not be called for an invalid ISBN")
+	})
+
+	req := httptest.NewRequest("GET", "/bookcover/image?isbn=123", nil)
+	w := httptest.NewRecorder()
+
+	handler.Image(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code 400, got %d", w.Code)
+	}
+}
+
+func TestImage_ServesRepeatRequestsFromCache(t *testing.T) {
+	jpeg := []byte("\xff\xd8\xff\xe0\x00\x10JFIF")
+	var upstreamCalls int
+	handler, _ := setupImageHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls++
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("ETag", `"cover-v1"`)
+		w.Write(jpeg)
+	})
+	store, err := imagecache.NewStore(imagecache.Config{Dir: t.TempDir(), MaxBytes: 1 << 20})
+	if err != nil {
+		t.Fatalf("NewStore() unexpected error: %v", err)
+	}
+	handler.cache = store
+
+	for i, expected := range []string{"MISS", "HIT"} {
+		req := httptest.NewRequest("GET", "/bookcover/image?isbn=978-0345376596", nil)
+		w := httptest.NewRecorder()
+
+		handler.Image(w, req)
+
+		resp := w.Result()
+		if xc := resp.Header.Get("X-Cache"); xc != expected {
+			t.Errorf("request %d: expected X-Cache %s, got %s", i+1, expected, xc)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "image/jpeg" {
+			t.Errorf("request %d: expected Content-Type image/jpeg, got %s", i+1, ct)
+		}
+		if etag := resp.Header.Get("ETag"); etag != `"cover-v1"` {
+			t.Errorf("request %d: expected the upstream ETag, got %q", i+1, etag)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != string(jpeg) {
+			t.Errorf("request %d: expected cover bytes, got %q", i+1, body)
+		}
+	}
+
+	if upstreamCalls != 1 {
+		t.Errorf("Expected a single upstream fetch, got %d", upstreamCalls)
+	}
+	if purged := store.PurgeISBN("9780345376596"); purged != 1 {
+		t.Errorf("Expected the cached cover to be tagged with its ISBN, purged %d", purged)
+	}
+}
+
+func TestImage_HeadDoesNotReadImage(t *testing.T) {
+	const length = 1 << 16
+	handler, _ := setupImageHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Content-Length", strconv.Itoa(length))
+		w.Write(append([]byte("\xff\xd8\xff\xe0\x00\x10JFIF"), make([]byte, 1024)...))
+		w.(http.Flusher).Flush()
+		// The rest of the image never comes; reading it would hang until
+		// the client gives up.
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+			t.Error("Expected the image body not to be read")
+		}
+	})
+
+	tests := []struct {
+		name          string
+		query         string
+		contentType   string
+		contentLength string
+	}{
+		{"as is", "", "image/jpeg", strconv.Itoa(length)},
+		{"converted", "&format=png", "image/png", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("HEAD", "/bookcover/image?isbn=978-0345376596"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			handler.Image(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("Expected status code 200, got %d", resp.StatusCode)
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("Expected Content-Type %s, got %s", tt.contentType, ct)
+			}
+			if cl := resp.Header.Get("Content-Length"); cl != tt.contentLength {
+				t.Errorf("Expected Content-Length %q, got %q", tt.contentLength, cl)
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("Expected no body, got %d bytes", w.Body.Len())
+			}
+		})
+	}
+}
+
+func TestImage_ResizesAndConvertsOffCDN(t *testing.T) {
+	cover := image.NewRGBA(image.Rect(0, 0, 400, 600))
+	var source bytes.Buffer
+	png.Encode(&source, cover)
+
+	handler, _ := setupImageHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(source.Bytes())
+	})
+
+	req := httptest.NewRequest("GET", "/bookcover/image?isbn=978-0345376596&width=100&format=jpeg", nil)
+	w := httptest.NewRecorder()
+
+	handler.Image(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status code 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("Expected Content-Type image/jpeg, got %s", ct)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	if cl := resp.Header.Get("Content-Length"); cl != strconv.Itoa(len(body)) {
+		t.Errorf("Expected Content-Length %d, got %s", len(body), cl)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Expected a decodable image, got %v", err)
+	}
+	if format != "jpeg" || cfg.Width != 100 || cfg.Height != 150 {
+		t.Errorf("Expected 100x150 jpeg, got %dx%d %s", cfg.Width, cfg.Height, format)
+	}
+}
+
+func TestImage_ConvertsGIFToPNG(t *testing.T) {
+	var source bytes.Buffer
+	gif.Encode(&source, image.NewPaletted(image.Rect(0, 0, 40, 60), palette.Plan9), nil)
+
+	handler, _ := setupImageHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/gif")
+		w.Write(source.Bytes())
+	})
+
+	req := httptest.NewRequest("GET", "/bookcover/image?isbn=978-0345376596&format=png", nil)
+	w := httptest.NewRecorder()
+
+	handler.Image(w, req)
+
+	resp := w.Result()
+	if ct := resp.Header.Get("Content-Type"); ct != "image/png" {
+		t.Errorf("Expected Content-Type image/png, got %s", ct)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if _, format, err := image.DecodeConfig(bytes.NewReader(body)); err != nil || format != "png" {
+		t.Errorf("Expected a png, got %s (%v)", format, err)
+	}
+}
+
+func TestImage_InvalidFormat(t *testing.T) {
+	handler, _ := setupImageHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("upstream should not be called for an invalid format")
+	})
+
+	req := httptest.NewRequest("GET", "/bookcover/image?isbn=978-0345376596&format=tiff", nil)
+	w := httptest.NewRecorder()
+
+	handler.Image(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code 400, got %d", w.Code)
+	}
+}
+
+func TestImage_PlaceholderFallback(t *testing.T) {
+	handler, _ := setupImageHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("upstream should not be called when the lookup fails")
+	})
+
+	tests := []struct {
+		accept      string
+		contentType string
+	}{
+		{"image/avif,image/webp,image/svg+xml,image/*,*/*;q=0.8", "image/svg+xml"},
+		{"image/png", "image/png"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/bookcover/image?book_title=Unknown+Book&author_name=Nobody&fallback=placeholder", nil)
+		req.Header.Set("Accept", tt.accept)
+		w := httptest.NewRecorder()
+
+		handler.Image(w, req)
+
+		resp := w.Result()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Accept %s: expected status code 200, got %d", tt.accept, resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("Accept %s: expected Content-Type %s, got %s", tt.accept, tt.contentType, ct)
+		}
+		if resp.Header.Get("X-Cover-Placeholder") != "true" {
+			t.Errorf("Accept %s: expected X-Cover-Placeholder header", tt.accept)
+		}
+	}
+}
+
+func TestImage_InvalidFallback(t *testing.T) {
+	handler, _ := setupImageHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest("GET", "/bookcover/image?isbn=978-0345376596&fallback=blank", nil)
+	w := httptest.NewRecorder()
+
+	handler.Image(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"bookcover-api/internal/config"
+	"bookcover-api/internal/jobs"
+	"bookcover-api/internal/service"
+	"bookcover-api/pkg/response"
+)
+
+// maxJobBodyBytes bounds the body of POST /jobs.
+const maxJobBodyBytes = 8 << 20
+
+type jobRequest struct {
+	Items       []jobs.Item `json:"items"`
+	CallbackURL string      `json:"callback_url"`
+}
+
+// JobsHandler creates lookup jobs and reports their progress.
+type JobsHandler struct {
+	manager *jobs.Manager
+}
+
+func NewJobsHandler(manager *jobs.Manager) *JobsHandler {
+	return &JobsHandler{manager: manager}
+}
+
+func (h *JobsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	opts, err := imageOptions(r)
+	if err != nil {
+		w.Write(response.Error(w, http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	var req jobRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJobBodyBytes)).Decode(&req); err != nil {
+		w.Write(response.Error(w, http.StatusBadRequest, config.InvalidJob))
+		return
+	}
+
+	job, err := h.manager.Submit(req.Items, opts, req.CallbackURL)
+	if err != nil {
+		w.Write(jobError(w, err))
+		return
+	}
+
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	w.Write(jobResponse(w, http.StatusAccepted, job))
+}
+
+func (h *JobsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	job, err := h.manager.Get(r.PathValue("id"))
+	if err != nil {
+		w.Write(jobError(w, err))
+		return
+	}
+	w.Write(jobResponse(w, http.StatusOK, job))
+}
+
+// Cost charges one unit of rate-limit quota per item of a job, like a batch.
+// Jobs that will be rejected as too large cost a single unit.
+func (h *JobsHandler) Cost(r *http.Request) int {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxJobBodyBytes+1))
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return 1
+	}
+
+	var req struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil || len(req.Items) > h.manager.MaxItems() {
+		return 1
+	}
+	return max(1, len(req.Items))
+}
+
+// ResolveJobItem looks up one item of a job the same way a batch does.
+func (h *BatchHandler) ResolveJobItem(ctx context.Context, item jobs.Item, opts service.ImageOptions) jobs.Result {
+	result := h.lookup(ctx, BatchItem(item), opts, false)
+	return jobs.Result{
+		Item:   item,
+		Status: result.Status,
+		URL:    result.URL,
+		Error:  result.Error,
+	}
+}
+
+func jobResponse(w http.ResponseWriter, status int, job *jobs.Job) []byte {
+	var buffer bytes.Buffer
+	enc := json.NewEncoder(&buffer)
+	enc.SetEscapeHTML(false)
+	enc.Encode(job.View())
+	w.WriteHeader(status)
+	return buffer.Bytes()
+}
+
+// jobError maps a job manager error to the matching JSON error response.
+func jobError(w http.ResponseWriter, err error) []byte {
+	switch {
+	case errors.Is(err, jobs.ErrNoItems):
+		return response.Error(w, http.StatusBadRequest, config.InvalidJob)
+	case errors.Is(err, jobs.ErrTooManyItems):
+		return response.Error(w, http.StatusRequestEntityTooLarge, config.JobTooLarge)
+	case errors.Is(err, jobs.ErrInvalidCallback):
+		return response.Error(w, http.StatusBadRequest, config.InvalidCallbackURL)
+	case errors.Is(err, jobs.ErrCallbacksDisabled):
+		return response.Error(w, http.StatusBadRequest, config.CallbacksDisabled)
+	case errors.Is(err, jobs.ErrQueueFull), errors.Is(err, jobs.ErrShuttingDown):
+		return response.Error(w, http.StatusServiceUnavailable, config.JobQueueFull)
+	case errors.Is(err, jobs.ErrNotFound):
+		return response.Error(w, http.StatusNotFound, config.JobNotFound)
+	default:
+		slog.Error("job store error", "error", err)
+		return response.Error(w, http.StatusInternalServerError, config.InternalServerError)
+	}
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bookcover-api/internal/config"
+	"bookcover-api/internal/jobs"
+	"bookcover-api/internal/service"
+	"bookcover-api/mocks"
+)
+
+func setupJobsHandler(t *testing.T) *JobsHandler {
+	t.Helper()
+	batch := NewBatchHandler(service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()))
+	manager := jobs.NewManager(jobs.NewMemoryStore(), batch.ResolveJobItem, jobs.Config{MaxItems: 3})
+	if err := manager.Start(); err != nil {
+		t.Fatalf("Start() unexpected error: %v", err)
+	}
+	t.Cleanup(func() { manager.Shutdown(context.Background()) })
+	return NewJobsHandler(manager)
+}
+
+func TestJobs_CreateAndPoll(t *testing.T) {
+	handler := setupJobsHandler(t)
+
+	body := `{"items": [{"isbn": "978-0345376596"}, {"isbn": "` + notFoundISBN + `"}, {"isbn": "123"}]}`
+	req := httptest.NewRequest("POST", "/jobs", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.Create(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected status code 202, got %d", resp.StatusCode)
+	}
+	var created jobs.View
+	json.NewDecoder(resp.Body).Decode(&created)
+	if location := resp.Header.Get("Location"); location != "/jobs/"+created.ID {
+		t.Errorf("Expected Location /jobs/%s, got %s", created.ID, location)
+	}
+	if created.Total != 3 {
+		t.Errorf("Expected total 3, got %d", created.Total)
+	}
+
+	var view jobs.View
+	deadline := time.Now().Add(5 * time.Second)
+	for view.Status != jobs.StatusCompleted && time.Now().Before(deadline) {
+		req := httptest.NewRequest("GET", "/jobs/"+created.ID, nil)
+		req.SetPathValue("id", created.ID)
+		w := httptest.NewRecorder()
+		handler.Get(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+		json.NewDecoder(w.Body).Decode(&view)
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if view.Status != jobs.StatusCompleted || len(view.Results) != 3 {
+		t.Fatalf("Expected a completed job with 3 results, got %+v", view)
+	}
+	statuses := []int{http.StatusOK, http.StatusNotFound, http.StatusBadRequest}
+	for i, status := range statuses {
+		if view.Results[i].Status != status {
+			t.Errorf("result %d: expected status %d, got %+v", i, status, view.Results[i])
+		}
+	}
+}
+
+func TestJobs_InvalidRequests(t *testing.T) {
+	handler := setupJobsHandler(t)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		err    string
+	}{
+		{"not json", "isbn=978-0345376596", http.StatusBadRequest, config.InvalidJob},
+		{"no items", `{"items": []}`, http.StatusBadRequest, config.InvalidJob},
+		{"too many items", `{"items": [{"isbn": "1"}, {"isbn": "2"}, {"isbn": "3"}, {"isbn": "4"}]}`, http.StatusRequestEntityTooLarge, config.JobTooLarge},
+		{"callbacks disabled", `{"items": [{"isbn": "1"}], "callback_url": "https://example.com/hook"}`, http.StatusBadRequest, config.CallbacksDisabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/jobs", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.Create(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status code %d, got %d", tt.status, w.Code)
+			}
+			var result map[string]string
+			json.NewDecoder(w.Body).Decode(&result)
+			if result["error"] != tt.err {
+				t.Errorf("Expected error %q, got %q", tt.err, result["error"])
+			}
+		})
+	}
+}
+
+func TestJobs_NotFound(t *testing.T) {
+	handler := setupJobsHandler(t)
+
+	req := httptest.NewRequest("GET", "/jobs/0123456789abcdef", nil)
+	req.SetPathValue("id", "0123456789abcdef")
+	w := httptest.NewRecorder()
+
+	handler.Get(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code 404, got %d", w.Code)
+	}
+}
+
+func TestJobs_Cost(t *testing.T) {
+	handler := setupJobsHandler(t)
+
+	req := httptest.NewRequest("POST", "/jobs", strings.NewReader(`{"items": [{"isbn": "1"}, {"isbn": "2"}]}`))
+	if got := handler.Cost(req); got != 2 {
+		t.Errorf("Cost() = %d, want 2", got)
+	}
+
+	req = httptest.NewRequest("POST", "/jobs", strings.NewReader(`{"items": [{"isbn": "1"}, {"isbn": "2"}, {"isbn": "3"}, {"isbn": "4"}]}`))
+	if got := handler.Cost(req); got != 1 {
+		t.Errorf("Cost() of a job over the limit = %d, want 1", got)
+	}
+}
@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"net/http"
+
+	"bookcover-api/internal/config"
+	"bookcover-api/pkg/response"
+	"bookcover-api/static"
+)
+
+// OpenAPI serves the OpenAPI 3 document describing every route. The document
+// is maintained by hand in static/openapi.json and checked against the
+// handlers by the contract tests.
+func OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	data, err := static.Files.ReadFile("openapi.json")
+	if err != nil {
+		w.Write(response.Error(w, http.StatusInternalServerError, config.InternalServerError))
+		return
+	}
+	w.Write(data)
+}
@@ -0,0 +1,457 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"bookcover-api/internal/imagecache"
+	"bookcover-api/internal/imageinfo"
+	"bookcover-api/internal/imageproxy"
+	"bookcover-api/internal/jobs"
+	"bookcover-api/internal/middleware"
+	"bookcover-api/internal/scraper"
+	"bookcover-api/internal/service"
+	"bookcover-api/mocks"
+	"bookcover-api/static"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// apiSpec is the part of the OpenAPI document the contract tests check
+// responses against.
+type apiSpec struct {
+	Paths      map[string]map[string]specOperation `json:"paths"`
+	Components struct {
+		Schemas   map[string]*specSchema   `json:"schemas"`
+		Responses map[string]*specResponse `json:"responses"`
+	} `json:"components"`
+}
+
+type specOperation struct {
+	Responses map[string]*specResponse `json:"responses"`
+}
+
+type specResponse struct {
+	Ref     string `json:"$ref"`
+	Headers map[string]struct {
+		Required bool `json:"required"`
+	} `json:"headers"`
+	Content map[string]struct {
+		Schema *specSchema `json:"schema"`
+	} `json:"content"`
+}
+
+type specSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 string                 `json:"type"`
+	Nullable             bool                   `json:"nullable"`
+	Enum                 []any                  `json:"enum"`
+	Pattern              string                 `json:"pattern"`
+	MinItems             int                    `json:"minItems"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*specSchema `json:"properties"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *specSchema            `json:"items"`
+}
+
+func loadSpec(t *testing.T) *apiSpec {
+	t.Helper()
+	data, err := static.Files.ReadFile("openapi.json")
+	if err != nil {
+		t.Fatalf("Failed to read openapi.json: %v", err)
+	}
+	var spec apiSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("openapi.json is not valid: %v", err)
+	}
+	return &spec
+}
+
+// check validates a response of the route against the document.
+func (s *apiSpec) check(method, route string, resp *http.Response, body []byte) error {
+	op, ok := s.Paths[route][strings.ToLower(method)]
+	if !ok && resp.StatusCode == http.StatusMethodNotAllowed {
+		// Wrong methods are answered with the 405 of the route's operations.
+		for _, op = range s.Paths[route] {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return fmt.Errorf("%s %s is not documented", method, route)
+	}
+	documented, ok := op.Responses[strconv.Itoa(resp.StatusCode)]
+	if !ok {
+		return fmt.Errorf("status %d is not documented", resp.StatusCode)
+	}
+	if documented.Ref != "" {
+		documented = s.Components.Responses[strings.TrimPrefix(documented.Ref, "#/components/responses/")]
+	}
+
+	for name, header := range documented.Headers {
+		if header.Required && resp.Header.Get(name) == "" {
+			return fmt.Errorf("header %s is missing", name)
+		}
+	}
+	if len(documented.Content) == 0 {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("invalid Content-Type %q", resp.Header.Get("Content-Type"))
+	}
+	content, ok := documented.Content[mediaType]
+	if !ok {
+		content, ok = documented.Content[strings.Split(mediaType, "/")[0]+"/*"]
+	}
+	if !ok {
+		return fmt.Errorf("Content-Type %s is not documented", mediaType)
+	}
+
+	switch mediaType {
+	case "application/json":
+		return s.validateJSON(body, content.Schema)
+	case "application/x-ndjson":
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			if err := s.validateJSON(scanner.Bytes(), content.Schema); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *apiSpec) validateJSON(data []byte, schema *specSchema) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("body is not JSON: %v", err)
+	}
+	return s.validate(value, schema, "body")
+}
+
+// validate checks value against the subset of JSON Schema the document uses.
+func (s *apiSpec) validate(value any, schema *specSchema, path string) error {
+	if schema.Ref != "" {
+		return s.validate(value, s.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")], path)
+	}
+	if value == nil {
+		if schema.Nullable {
+			return nil
+		}
+		return fmt.Errorf("%s is null", path)
+	}
+	if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, value) {
+		return fmt.Errorf("%s is %v, not one of %v", path, value, schema.Enum)
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s is not an object", path)
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s.%s is missing", path, name)
+			}
+		}
+		for name, property := range object {
+			propertySchema, ok := schema.Properties[name]
+			if !ok {
+				if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+					return fmt.Errorf("%s.%s is not documented", path, name)
+				}
+				continue
+			}
+			if err := s.validate(property, propertySchema, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s is not an array", path)
+		}
+		if len(array) < schema.MinItems {
+			return fmt.Errorf("%s has fewer than %d items", path, schema.MinItems)
+		}
+		for i, item := range array {
+			if err := s.validate(item, schema.Items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s is not a string", path)
+		}
+		if schema.Pattern != "" && !regexp.MustCompile(schema.Pattern).MatchString(str) {
+			return fmt.Errorf("%s %q does not match %s", path, str, schema.Pattern)
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			return fmt.Errorf("%s is not an integer", path)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s is not a number", path)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s is not a boolean", path)
+		}
+	}
+	return nil
+}
+
+// Books the contract scraper answers with an error.
+const (
+	unavailableISBN = "9780000000019"
+	layoutISBN      = "9780000000026"
+)
+
+// contractScraper finds a cover for every book except the ones above, so
+// that the contract tests can reach every documented error.
+type contractScraper struct{}
+
+func (contractScraper) Name() string { return "contract" }
+
+func (contractScraper) FetchByTitleAuthor(ctx context.Context, bookTitle, authorName string) (string, error) {
+	return "https://example.com/" + bookTitle + ".jpg", nil
+}
+
+func (contractScraper) FetchByISBN(ctx context.Context, isbn string) (string, error) {
+	switch isbn {
+	case notFoundISBN:
+		return "", scraper.ErrNotFound
+	case unavailableISBN:
+		return "", scraper.ErrCircuitOpen
+	case layoutISBN:
+		return "", scraper.ErrUnknownLayout
+	}
+	return "https://example.com/" + isbn + ".jpg", nil
+}
+
+type contractAnalyzer struct{}
+
+func (contractAnalyzer) Analyze(ctx context.Context, url string) (*imageinfo.Info, error) {
+	return &imageinfo.Info{
+		Width:         300,
+		Height:        450,
+		Bytes:         1024,
+		MIMEType:      "image/jpeg",
+		DominantColor: "#101820",
+		AverageColor:  "#3a4452",
+		BlurHash:      "00TSUA",
+	}, nil
+}
+
+// imageISBN is the book whose cover the image route can proxy.
+const imageISBN = "9780345376596"
+
+// contractMux serves the routes the server does, backed by stubs.
+func contractMux(t *testing.T) *http.ServeMux {
+	t.Helper()
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write([]byte("\xff\xd8\xff\xe0\x00\x10JFIF"))
+	}))
+	t.Cleanup(upstream.Close)
+
+	mockCache := mocks.NewMockCache()
+	mockCache.Set(&memcache.Item{Key: imageISBN, Value: []byte(upstream.URL + "/cover.jpg")})
+	svc := service.NewBookcoverServiceWithConfig(mockCache, service.Config{
+		Providers: []scraper.Scraper{contractScraper{}},
+		Analyzer:  contractAnalyzer{},
+	})
+
+	imageConfig := imageproxy.DefaultConfig()
+	imageConfig.AllowedHosts = []string{"127.0.0.1"}
+	imageConfig.Transport = upstream.Client().Transport
+	imageCache, err := imagecache.NewStore(imagecache.Config{Dir: t.TempDir(), MaxBytes: 1 << 20})
+	if err != nil {
+		t.Fatalf("NewStore() unexpected error: %v", err)
+	}
+	rules, _ := setupRuleStore(t)
+
+	batch := NewBatchHandlerWithConfig(svc, BatchConfig{MaxItems: 2})
+	manager := jobs.NewManager(jobs.NewMemoryStore(), batch.ResolveJobItem, jobs.Config{MaxItems: 2})
+	if err := manager.Start(); err != nil {
+		t.Fatalf("Start() unexpected error: %v", err)
+	}
+	t.Cleanup(func() { manager.Shutdown(context.Background()) })
+	t.Setenv("ADMIN_API_KEY", "secret")
+
+	return NewServeMux(Routes{
+		Bookcover:  NewBookcoverHandler(svc),
+		Covers:     NewCoversHandler(svc),
+		Images:     NewImageHandler(svc, imageproxy.NewClient(imageConfig), nil),
+		Batch:      batch,
+		GraphQL:    NewGraphQLHandler(svc),
+		Jobs:       NewJobsHandler(manager),
+		Rules:      rules,
+		ImageCache: imageCache,
+		Cache:      mockCache,
+		Cors:       middleware.DefaultCorsConfig(),
+	})
+}
+
+func TestOpenAPI_ServesDocument(t *testing.T) {
+	req := httptest.NewRequest("GET", "/openapi.json", nil)
+	w := httptest.NewRecorder()
+
+	OpenAPI(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status code 200, got %d", resp.StatusCode)
+	}
+	var doc map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("Failed to decode the document: %v", err)
+	}
+	if doc["openapi"] != "3.0.3" {
+		t.Errorf("Expected an OpenAPI 3.0.3 document, got %v", doc["openapi"])
+	}
+}
+
+// TestOpenAPI_Contract runs real requests through the handlers and checks
+// every response against the document. Every documented operation must be
+// exercised, so routes cannot be added to or removed from either side alone.
+func TestOpenAPI_Contract(t *testing.T) {
+	spec := loadSpec(t)
+	mux := contractMux(t)
+
+	type request struct {
+		method, target, contentType, body string
+		header                            map[string]string
+		status                            int
+	}
+	admin := map[string]string{"Authorization": "Bearer secret"}
+	requests := []request{
+		{method: "GET", target: "/", status: 200},
+		{method: "GET", target: "/openapi.json", status: 200},
+		{method: "POST", target: "/openapi.json", status: 405},
+		{method: "GET", target: "/metrics", status: 200},
+		{method: "GET", target: "/debug/cache-stats", header: admin, status: 200},
+		{method: "GET", target: "/debug/cache-stats", status: 401},
+		{method: "POST", target: "/admin/scraper/rules/reload", header: admin, status: 200},
+		{method: "DELETE", target: "/admin/image-cache/978-0345376596", header: admin, status: 200},
+		{method: "DELETE", target: "/admin/image-cache/123", header: admin, status: 400},
+
+		{method: "GET", target: "/v1/covers?book_title=dune&author_name=herbert", status: 200},
+		{method: "GET", target: "/v1/covers?isbn=978-0345376597&include=image_info", status: 200},
+		{method: "GET", target: "/v1/covers?isbn=123", status: 400},
+		{method: "POST", target: "/v1/covers", status: 405},
+		{method: "GET", target: "/v1/covers/978-0345376598", status: 200},
+		{method: "GET", target: "/v1/covers/" + notFoundISBN, status: 404},
+		{method: "GET", target: "/v1/covers/" + layoutISBN, status: 502},
+		{method: "GET", target: "/v1/covers/" + unavailableISBN, status: 503},
+
+		{method: "GET", target: "/bookcover?isbn=978-0345376597&include=image_info", status: 200},
+		{method: "GET", target: "/bookcover?isbn=978-0345376597&redirect=true", status: 302},
+		{method: "GET", target: "/bookcover?isbn=" + notFoundISBN + "&redirect=true&fallback=placeholder", status: 200},
+		{method: "GET", target: "/bookcover?book_title=dune", status: 400},
+		{method: "GET", target: "/bookcover?isbn=" + notFoundISBN, status: 404},
+		{method: "POST", target: "/bookcover", status: 405},
+		{method: "GET", target: "/bookcover?isbn=" + layoutISBN, status: 502},
+		{method: "GET", target: "/bookcover?isbn=" + unavailableISBN, status: 503},
+		{method: "GET", target: "/bookcover?isbn=978-0345376597", header: map[string]string{"If-None-Match": "*"}, status: 304},
+		{method: "GET", target: "/bookcover/978-0345376597", status: 200},
+		{method: "GET", target: "/bookcover/978-0345376597", header: map[string]string{"If-None-Match": "*"}, status: 304},
+		{method: "GET", target: "/bookcover/123", status: 400},
+		{method: "GET", target: "/bookcover/" + notFoundISBN, status: 404},
+		{method: "GET", target: "/cover/978-0345376597.jpg", status: 302},
+		{method: "GET", target: "/cover/" + notFoundISBN + ".jpg?fallback=placeholder&format=png", status: 200},
+		{method: "GET", target: "/cover/" + notFoundISBN + ".jpg", status: 404},
+		{method: "GET", target: "/cover/978-0345376597", status: 404},
+		{method: "GET", target: "/bookcover/image?isbn=" + imageISBN, status: 200},
+		{method: "GET", target: "/bookcover/image?isbn=" + imageISBN + "&format=gif", status: 400},
+		{method: "GET", target: "/bookcover/image?isbn=" + unavailableISBN, status: 503},
+
+		{method: "POST", target: "/bookcover/batch?include=image_info", contentType: "application/json",
+			body: `[{"isbn": "978-0345376597"}, {"isbn": "` + notFoundISBN + `"}]`, status: 200},
+		{method: "POST", target: "/bookcover/batch", contentType: "application/json", body: `{}`, status: 400},
+		{method: "POST", target: "/bookcover/batch", contentType: "application/json",
+			body: `[{"isbn": "1"}, {"isbn": "2"}, {"isbn": "3"}]`, status: 413},
+		{method: "POST", target: "/bookcover/bulk", contentType: "application/x-ndjson",
+			body: "{\"isbn\": \"978-0345376597\"}\nnot json\n", status: 200},
+		{method: "POST", target: "/bookcover/bulk", contentType: "text/csv",
+			body: "book_title,author_name\ndune,herbert\n", status: 200},
+		{method: "POST", target: "/bookcover/bulk", contentType: "text/csv", body: "title\n", status: 400},
+		{method: "POST", target: "/bookcover/bulk", contentType: "text/plain", body: "x", status: 415},
+		{method: "GET", target: "/graphql?query=" + url.QueryEscape(`{ book(isbn: "9780345376597") { cover { url } } }`), status: 200},
+		{method: "GET", target: "/graphql", status: 400},
+		{method: "POST", target: "/graphql", contentType: "application/json",
+			body: `{"query": "{ books(isbns: [\"9780345376597\", \"` + unavailableISBN + `\"]) { isbn cover { url imageInfo { width } } } }"}`, status: 200},
+		{method: "POST", target: "/graphql", contentType: "application/json", body: `{}`, status: 400},
+		{method: "DELETE", target: "/graphql", status: 405},
+		{method: "POST", target: "/jobs", contentType: "application/json", body: `{"items": [{"isbn": "978-0345376597"}]}`, status: 202},
+		{method: "POST", target: "/jobs", contentType: "application/json", body: `{"items": []}`, status: 400},
+		{method: "POST", target: "/jobs", contentType: "application/json",
+			body: `{"items": [{"isbn": "1"}, {"isbn": "2"}, {"isbn": "3"}]}`, status: 413},
+		{method: "GET", target: "/jobs/0123456789abcdef", status: 404},
+	}
+
+	exercised := map[string]bool{}
+	var jobLocation string
+	serve := func(req request) {
+		t.Helper()
+		r := httptest.NewRequest(req.method, req.target, strings.NewReader(req.body))
+		if req.contentType != "" {
+			r.Header.Set("Content-Type", req.contentType)
+		}
+		for name, value := range req.header {
+			r.Header.Set(name, value)
+		}
+		_, pattern := mux.Handler(r)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+
+		resp := w.Result()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != req.status {
+			t.Errorf("%s %s: expected status code %d, got %d: %s", req.method, req.target, req.status, resp.StatusCode, body)
+			return
+		}
+		if err := spec.check(req.method, pattern, resp, body); err != nil {
+			t.Errorf("%s %s: %v", req.method, req.target, err)
+		}
+		exercised[strings.ToLower(req.method)+" "+pattern] = true
+		if resp.StatusCode == http.StatusAccepted {
+			jobLocation = resp.Header.Get("Location")
+		}
+	}
+
+	for _, req := range requests {
+		serve(req)
+	}
+	if jobLocation == "" {
+		t.Fatal("Expected a job to be created")
+	}
+	serve(request{method: "GET", target: jobLocation, status: 200})
+
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			if !exercised[method+" "+path] {
+				t.Errorf("%s %s is documented but not exercised by the contract test", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
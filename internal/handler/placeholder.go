@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bookcover-api/internal/config"
+	"bookcover-api/internal/imageproc"
+	"bookcover-api/internal/placeholder"
+	"bookcover-api/pkg/response"
+)
+
+const fallbackPlaceholder = "placeholder"
+
+// placeholderMaxAge is kept short so that a real cover shows up soon after it
+// becomes available.
+const placeholderMaxAge = time.Hour
+
+var (
+	errInvalidFallback = errors.New(config.InvalidFallback)
+	errInvalidFormat   = errors.New(config.InvalidFormat)
+)
+
+// placeholderFallback reports whether the client opted into generated covers
+// through fallback=placeholder. It also checks the format a placeholder would
+// be encoded in, so that a bad one is rejected before the lookup runs.
+func placeholderFallback(r *http.Request) (bool, error) {
+	if _, err := imageproc.ParseFormat(r.URL.Query().Get(formatParam)); err != nil {
+		return false, errInvalidFormat
+	}
+
+	switch r.URL.Query().Get(fallbackParam) {
+	case "":
+		return false, nil
+	case fallbackPlaceholder:
+		return true, nil
+	default:
+		return false, errInvalidFallback
+	}
+}
+
+func (q coverQuery) placeholder() placeholder.Cover {
+	width, height := q.opts.Dimensions()
+	if q.isbn != "" {
+		return placeholder.Cover{Title: "ISBN " + q.isbn, Seed: q.isbn, Width: width, Height: height}
+	}
+	return placeholder.Cover{
+		Title:  q.bookTitle,
+		Author: q.authorName,
+		Seed:   q.bookTitle + "\n" + q.authorName,
+		Width:  width,
+		Height: height,
+	}
+}
+
+// servePlaceholder answers with a generated cover. It is an SVG unless the
+// client asks for PNG or JPEG through the format parameter or Accept. The
+// format parameter must have been checked by placeholderFallback.
+func servePlaceholder(w http.ResponseWriter, r *http.Request, cover placeholder.Cover) {
+	format, err := imageproc.ParseFormat(r.URL.Query().Get(formatParam))
+	if err != nil || format == "" {
+		format = imageproc.Negotiate(r.Header.Get("Accept"))
+	}
+
+	data := cover.SVG()
+	contentType := "image/svg+xml"
+	if format != "" {
+		if data, err = imageproc.Encode(cover.Image(), format); err != nil {
+			slog.Error("failed to encode placeholder cover", "error", err)
+			w.Write(response.Error(w, http.StatusInternalServerError, config.InternalServerError))
+			return
+		}
+		contentType = format.ContentType()
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Length", strconv.Itoa(len(data)))
+	header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(placeholderMaxAge.Seconds())))
+	header.Set("Content-Security-Policy", "default-src 'none'")
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("X-Cover-Placeholder", "true")
+	header.Set("Vary", "Accept")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
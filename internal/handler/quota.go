@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"bookcover-api/internal/cache"
+	"bookcover-api/internal/middleware"
+)
+
+// QuotaMeter charges rate-limit quota from within a handler, for endpoints
+// that only learn how many lookups they make while serving the request.
+type QuotaMeter struct {
+	cache cache.CacheClient
+	cfg   middleware.RateLimitConfig
+}
+
+func NewQuotaMeter(cacheClient cache.CacheClient, cfg middleware.RateLimitConfig) *QuotaMeter {
+	return &QuotaMeter{cache: cacheClient, cfg: cfg}
+}
+
+// charge adds cost to the counters of client and reports whether it is still
+// within its quota. Like the middleware, it lets lookups through when the
+// counters cannot be updated. A nil meter charges nothing.
+func (m *QuotaMeter) charge(client string, cost int) bool {
+	if m == nil || m.cfg.Unlimited {
+		return true
+	}
+	quota, err := middleware.ChargeQuota(m.cache, m.cfg, client, cost)
+	return err != nil || !quota.Exceeded
+}
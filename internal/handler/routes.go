@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"net/http"
+
+	"bookcover-api/internal/cache"
+	"bookcover-api/internal/imagecache"
+	"bookcover-api/internal/metrics"
+	"bookcover-api/internal/middleware"
+	"bookcover-api/internal/scraper"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Routes holds what the HTTP routes are served with.
+type Routes struct {
+	Bookcover *BookcoverHandler
+	Covers    *CoversHandler
+	Images    *ImageHandler
+	Batch     *BatchHandler
+	GraphQL   *GraphQLHandler
+	Jobs      *JobsHandler
+
+	// Rules are reloaded by the admin route.
+	Rules *scraper.RuleStore
+	// ImageCache is purged by the admin route, which is left out when it is nil.
+	ImageCache *imagecache.Store
+	// Cache holds the rate limit counters.
+	Cache cache.CacheClient
+	Cors  middleware.CorsConfig
+}
+
+// NewServeMux registers every HTTP route along with its middleware.
+func NewServeMux(routes Routes) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// CORS comes before the method check and the rate limiter so that
+	// preflights are answered without being rejected or charged.
+	cors := middleware.CorsHeaderMiddlewareWithConfig(routes.Cors)
+
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/debug/cache-stats", middleware.Chain(
+		CacheStatsHandler(),
+		middleware.AuthMiddleware(),
+		middleware.HttpMethod("GET"),
+		middleware.JsonHeaderMiddleware(),
+	))
+
+	mux.HandleFunc("/admin/scraper/rules/reload", middleware.Chain(
+		ReloadRulesHandler(routes.Rules),
+		middleware.AuthMiddleware(),
+		middleware.HttpMethod("POST"),
+		middleware.JsonHeaderMiddleware(),
+	))
+
+	if routes.ImageCache != nil {
+		mux.HandleFunc("/admin/image-cache/{isbn}", middleware.Chain(
+			PurgeImageCacheHandler(routes.ImageCache),
+			middleware.AuthMiddleware(),
+			middleware.HttpMethod("DELETE"),
+			middleware.JsonHeaderMiddleware(),
+		))
+	}
+
+	mux.HandleFunc("/", middleware.Chain(
+		Home,
+		metrics.MetricsMiddleware(),
+		cors,
+	))
+
+	mux.HandleFunc("/openapi.json", middleware.Chain(
+		OpenAPI,
+		metrics.MetricsMiddleware(),
+		cors,
+		middleware.HttpMethod("GET"),
+	))
+
+	mux.HandleFunc("/v1/covers", v1Route(routes.Covers.Search, cors, routes.Cache, middleware.ProTier))
+	mux.HandleFunc("/v1/covers/{isbn}", v1Route(routes.Covers.ByISBN, cors, routes.Cache, middleware.ProTier))
+
+	mux.HandleFunc("/bookcover", middleware.Chain(
+		routes.Bookcover.Search,
+		metrics.MetricsMiddleware(),
+		cors,
+		middleware.RateLimitMiddleware(routes.Cache),
+		middleware.HttpMethod("GET"),
+		middleware.JsonHeaderMiddleware(),
+	))
+
+	mux.HandleFunc("/bookcover/batch", middleware.Chain(
+		routes.Batch.Batch,
+		metrics.MetricsMiddleware(),
+		cors,
+		middleware.RateLimitMiddlewareWithCost(routes.Cache, routes.Batch.Cost),
+		middleware.HttpMethod("POST"),
+		middleware.JsonHeaderMiddleware(),
+	))
+
+	mux.HandleFunc("/bookcover/bulk", middleware.Chain(
+		routes.Batch.Bulk,
+		metrics.MetricsMiddleware(),
+		cors,
+		middleware.HttpMethod("POST"),
+	))
+
+	mux.HandleFunc("/graphql", middleware.Chain(
+		routes.GraphQL.Query,
+		metrics.MetricsMiddleware(),
+		cors,
+		middleware.HttpMethod("GET", "POST"),
+		middleware.JsonHeaderMiddleware(),
+	))
+
+	mux.HandleFunc("/jobs", middleware.Chain(
+		routes.Jobs.Create,
+		metrics.MetricsMiddleware(),
+		cors,
+		middleware.RateLimitMiddlewareWithCost(routes.Cache, routes.Jobs.Cost),
+		middleware.HttpMethod("POST"),
+		middleware.JsonHeaderMiddleware(),
+	))
+
+	mux.HandleFunc("/jobs/{id}", middleware.Chain(
+		routes.Jobs.Get,
+		metrics.MetricsMiddleware(),
+		cors,
+		middleware.HttpMethod("GET"),
+		middleware.JsonHeaderMiddleware(),
+	))
+
+	mux.HandleFunc("/bookcover/image", middleware.Chain(
+		routes.Images.Image,
+		metrics.MetricsMiddleware(),
+		cors,
+		middleware.RateLimitMiddleware(routes.Cache),
+		middleware.HttpMethod("GET"),
+	))
+
+	mux.HandleFunc("/cover/{file}", middleware.Chain(
+		routes.Bookcover.Cover,
+		metrics.MetricsMiddleware(),
+		cors,
+		middleware.RateLimitMiddleware(routes.Cache),
+		middleware.HttpMethod("GET"),
+	))
+
+	mux.HandleFunc("/bookcover/{isbn}", middleware.Chain(
+		routes.Bookcover.ByISBN,
+		metrics.MetricsMiddleware(),
+		cors,
+		middleware.RateLimitMiddleware(routes.Cache),
+		middleware.HttpMethod("GET"),
+		middleware.JsonHeaderMiddleware(),
+	))
+
+	return mux
+}
+
+// v1Route chains f with the middleware of the /v1 routes, which answer the
+// requests they reject with the envelope too.
+func v1Route(f http.HandlerFunc, cors middleware.Middleware, cacheClient cache.CacheClient, rateLimit middleware.RateLimitConfig) http.HandlerFunc {
+	rateLimit.WriteError = writeV1Error
+	return middleware.Chain(
+		f,
+		metrics.MetricsMiddleware(),
+		cors,
+		middleware.RequestID(),
+		middleware.RateLimitMiddlewareWithConfig(cacheClient, rateLimit),
+		middleware.HttpMethodWithErrors(writeV1Error, "GET"),
+		middleware.JsonHeaderMiddleware(),
+	)
+}
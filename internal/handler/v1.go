@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"net/http"
+
+	"bookcover-api/internal/imageinfo"
+	"bookcover-api/internal/middleware"
+	"bookcover-api/internal/service"
+	"bookcover-api/pkg/response"
+)
+
+// Error codes of the versioned API. Codes are part of the API contract: new
+// ones may be added, but existing ones keep their meaning.
+const (
+	codeInvalidRequest        = "invalid_request"
+	codeNotFound              = "not_found"
+	codeProviderUnavailable   = "provider_unavailable"
+	codeProviderBusy          = "provider_busy"
+	codeProviderBlocked       = "provider_blocked"
+	codeProviderLayoutChanged = "provider_layout_changed"
+	codeMethodNotAllowed      = "method_not_allowed"
+)
+
+// rejectionCodes are the codes of the statuses middleware rejects /v1
+// requests with before the handlers run.
+var rejectionCodes = map[int]string{
+	http.StatusMethodNotAllowed: codeMethodNotAllowed,
+	http.StatusTooManyRequests:  codeRateLimitExceeded,
+}
+
+// coverData is the data of a /v1/covers response.
+type coverData struct {
+	URL       string          `json:"url"`
+	ImageInfo *imageinfo.Info `json:"image_info,omitempty"`
+}
+
+// CoversHandler serves the /v1/covers routes, which wrap every response in
+// the envelope described in the README.
+type CoversHandler struct {
+	service service.BookcoverService
+}
+
+func NewCoversHandler(svc service.BookcoverService) *CoversHandler {
+	return &CoversHandler{
+		service: svc,
+	}
+}
+
+// Search answers /v1/covers?isbn= and /v1/covers?book_title=&author_name=.
+func (h *CoversHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query, message := readCoverQuery(r)
+	h.respond(w, r, query, message)
+}
+
+// ByISBN answers /v1/covers/{isbn}.
+func (h *CoversHandler) ByISBN(w http.ResponseWriter, r *http.Request) {
+	query, message := readISBNPath(r)
+	h.respond(w, r, query, message)
+}
+
+// respond looks the query up and writes the envelope. A non-empty message
+// means the query is invalid and is answered with invalid_request.
+func (h *CoversHandler) respond(w http.ResponseWriter, r *http.Request, query coverQuery, message string) {
+	meta := requestMeta(r)
+	if message != "" {
+		w.Write(response.EnvelopeError(w, http.StatusBadRequest, codeInvalidRequest, message, meta))
+		return
+	}
+
+	cover, err := query.resolve(r.Context(), h.service)
+	if err != nil {
+		status, code, message := lookupStatus(err)
+		w.Write(response.EnvelopeError(w, status, code, message, meta))
+		return
+	}
+
+	meta.Source = cover.Source
+	meta.Cache = string(cover.CacheStatus)
+	w.Write(response.Envelope(w, coverData{URL: cover.URL, ImageInfo: cover.ImageInfo}, meta))
+}
+
+// readISBNPath reads the ISBN from the path and the image parameters from the
+// query string, like readCoverQuery.
+func readISBNPath(r *http.Request) (coverQuery, string) {
+	query := coverQuery{isbn: r.PathValue(isbnParam)}
+
+	var err error
+	if query.opts, err = imageOptions(r); err != nil {
+		return query, err.Error()
+	}
+	if query.imageInfo, err = imageInfoRequested(r); err != nil {
+		return query, err.Error()
+	}
+	return query, query.validate()
+}
+
+// writeV1Error is the middleware.ErrorWriter of the /v1 routes, so that
+// rejected requests get the envelope as well.
+func writeV1Error(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(response.EnvelopeError(w, statusCode, rejectionCodes[statusCode], message, requestMeta(r)))
+}
+
+func requestMeta(r *http.Request) response.Meta {
+	return response.Meta{RequestID: middleware.RequestIDFromContext(r.Context())}
+}
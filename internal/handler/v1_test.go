@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bookcover-api/internal/config"
+	"bookcover-api/internal/middleware"
+	"bookcover-api/internal/service"
+	"bookcover-api/mocks"
+)
+
+type v1Envelope struct {
+	Data *struct {
+		URL       string          `json:"url"`
+		ImageInfo json.RawMessage `json:"image_info"`
+	} `json:"data"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	Meta struct {
+		RequestID string `json:"request_id"`
+		Source    string `json:"source"`
+		Cache     string `json:"cache"`
+	} `json:"meta"`
+}
+
+// serveV1 runs the request through the RequestID middleware like the server does.
+func serveV1(t *testing.T, handler http.HandlerFunc, req *http.Request) (*http.Response, v1Envelope) {
+	t.Helper()
+	req.Header.Set(middleware.RequestIDHeader, "test-request")
+	w := httptest.NewRecorder()
+	middleware.Chain(handler, middleware.RequestID())(w, req)
+
+	resp := w.Result()
+	var body v1Envelope
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+	if body.Meta.RequestID != "test-request" {
+		t.Errorf("Expected request ID test-request, got %q", body.Meta.RequestID)
+	}
+	return resp, body
+}
+
+func TestCoversByISBN_Envelope(t *testing.T) {
+	handler := NewCoversHandler(service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()))
+
+	for _, cache := range []string{"miss", "hit"} {
+		req := httptest.NewRequest("GET", "/v1/covers/978-0345376596", nil)
+		req.SetPathValue("isbn", "978-0345376596")
+		resp, body := serveV1(t, handler.ByISBN, req)
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status code 200, got %d", resp.StatusCode)
+		}
+		if body.Data == nil || body.Data.URL != "https://example.com/9780345376596.jpg" {
+			t.Errorf("Expected the cover URL in data, got %+v", body.Data)
+		}
+		if body.Error != nil {
+			t.Errorf("Expected no error, got %+v", body.Error)
+		}
+		if body.Meta.Source != "counting" || body.Meta.Cache != cache {
+			t.Errorf("Expected source counting and cache %s, got %+v", cache, body.Meta)
+		}
+	}
+}
+
+func TestCoversSearch_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		svc     service.BookcoverService
+		status  int
+		code    string
+		message string
+	}{
+		{
+			name:    "invalid request",
+			target:  "/v1/covers?isbn=123",
+			svc:     service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()),
+			status:  http.StatusBadRequest,
+			code:    codeInvalidRequest,
+			message: config.InvalidISBN,
+		},
+		{
+			name:   "not found",
+			target: "/v1/covers?isbn=" + notFoundISBN,
+			svc:    service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()),
+			status: http.StatusNotFound,
+			code:   codeNotFound,
+		},
+		{
+			name:    "provider unavailable",
+			target:  "/v1/covers?book_title=dune&author_name=herbert",
+			svc:     service.NewBookcoverService(unavailableScraper{}, mocks.NewMockCache()),
+			status:  http.StatusServiceUnavailable,
+			code:    codeProviderUnavailable,
+			message: config.ProviderUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := serveV1(t, NewCoversHandler(tt.svc).Search, httptest.NewRequest("GET", tt.target, nil))
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("Expected status code %d, got %d", tt.status, resp.StatusCode)
+			}
+			if body.Data != nil {
+				t.Errorf("Expected null data, got %+v", body.Data)
+			}
+			if body.Error == nil || body.Error.Code != tt.code {
+				t.Fatalf("Expected error code %s, got %+v", tt.code, body.Error)
+			}
+			if tt.message != "" && body.Error.Message != tt.message {
+				t.Errorf("Expected error message %s, got %s", tt.message, body.Error.Message)
+			}
+		})
+	}
+}
+
+func TestCovers_RejectionsUseEnvelope(t *testing.T) {
+	handler := NewCoversHandler(service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()))
+	rateLimit := middleware.RateLimitConfig{DailyLimit: 2, MonthlyLimit: 1000}
+	route := v1Route(handler.Search, middleware.CorsHeaderMiddleware(), mocks.NewMockCache(), rateLimit)
+
+	tests := []struct {
+		name    string
+		method  string
+		status  int
+		code    string
+		message string
+	}{
+		{"wrong method", "POST", http.StatusMethodNotAllowed, codeMethodNotAllowed, config.MethodNotAllowed},
+		{"within limit", "GET", http.StatusOK, "", ""},
+		{"over limit", "GET", http.StatusTooManyRequests, codeRateLimitExceeded, config.RateLimitExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/v1/covers?isbn=978-0345376596", nil)
+			resp, body := serveV1(t, route, req)
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("Expected status code %d, got %d", tt.status, resp.StatusCode)
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Expected Content-Type application/json, got %q", ct)
+			}
+			if tt.code == "" {
+				return
+			}
+			if body.Data != nil || body.Error == nil || body.Error.Code != tt.code || body.Error.Message != tt.message {
+				t.Errorf("Expected error %s %q, got %+v", tt.code, tt.message, body.Error)
+			}
+		})
+	}
+}
+
+func TestBookcoverByISBN_DeprecationHeaders(t *testing.T) {
+	handler := NewBookcoverHandler(service.NewBookcoverService(&countingScraper{}, mocks.NewMockCache()))
+
+	req := httptest.NewRequest("GET", "/bookcover/978-0345376596", nil)
+	w := httptest.NewRecorder()
+	handler.ByISBN(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status code 200, got %d", resp.StatusCode)
+	}
+	headers := map[string]string{
+		"Deprecation": "@1792368000",
+		"Sunset":      "Wed, 30 Jun 2027 00:00:00 GMT",
+		"Link":        `</v1/covers/978-0345376596>; rel="successor-version"`,
+	}
+	for name, want := range headers {
+		if got := resp.Header.Get(name); got != want {
+			t.Errorf("Expected %s %q, got %q", name, want, got)
+		}
+	}
+
+	var response map[string]string
+	json.NewDecoder(resp.Body).Decode(&response)
+	if response["url"] != "https://example.com/9780345376596.jpg" {
+		t.Errorf("Expected the legacy body, got %v", response)
+	}
+}
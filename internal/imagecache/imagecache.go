@@ -0,0 +1,320 @@
+package imagecache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"bookcover-api/internal/metrics"
+)
+
+const (
+	dataExt = ".img"
+	metaExt = ".json"
+)
+
+type Config struct {
+	// Dir is where cached images are stored. It is created if missing.
+	Dir string
+	// MaxBytes caps the total size of the cached images. The least recently
+	// used images are evicted once it is exceeded.
+	MaxBytes int64
+}
+
+// StoreFromEnv opens the store configured by IMAGE_CACHE_DIR and
+// IMAGE_CACHE_MAX_BYTES. It returns nil when no directory is configured.
+func StoreFromEnv() (*Store, error) {
+	dir := os.Getenv("IMAGE_CACHE_DIR")
+	if dir == "" {
+		return nil, nil
+	}
+
+	cfg := Config{Dir: dir, MaxBytes: 1 << 30} // 1GB
+	if raw := os.Getenv("IMAGE_CACHE_MAX_BYTES"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid IMAGE_CACHE_MAX_BYTES %q", raw)
+		}
+		cfg.MaxBytes = n
+	}
+	return NewStore(cfg)
+}
+
+// Meta describes a cached image. ISBN is only known for images first cached
+// by an ISBN lookup. ETag and LastModified are the upstream validators, when
+// the bytes are the upstream ones.
+type Meta struct {
+	URL          string `json:"url"`
+	Variant      string `json:"variant"`
+	ISBN         string `json:"isbn,omitempty"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+type entry struct {
+	key  string
+	meta Meta
+}
+
+// Store is a disk-backed LRU cache of image bytes keyed by upstream URL and
+// size variant.
+type Store struct {
+	cfg Config
+
+	mu    sync.Mutex
+	order *list.List // front is most recently used
+	index map[string]*list.Element
+	size  int64
+}
+
+// NewStore opens the store and indexes the images already on disk.
+func NewStore(cfg Config) (*Store, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create image cache directory: %w", err)
+	}
+
+	s := &Store{cfg: cfg, order: list.New(), index: make(map[string]*list.Element)}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	s.evict()
+	metrics.SetImageCacheSize(s.size)
+	return s, nil
+}
+
+// load indexes the images already on disk and drops partially written ones.
+func (s *Store) load() error {
+	files, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to read image cache directory: %w", err)
+	}
+
+	type loaded struct {
+		entry
+		modTime int64
+	}
+	var entries []loaded
+	for _, file := range files {
+		name := file.Name()
+		if strings.HasPrefix(name, ".tmp-") {
+			os.Remove(filepath.Join(s.cfg.Dir, name))
+			continue
+		}
+		if !strings.HasSuffix(name, metaExt) {
+			continue
+		}
+
+		key := strings.TrimSuffix(name, metaExt)
+		meta, err := s.readMeta(key)
+		info, statErr := os.Stat(s.dataPath(key))
+		if err != nil || statErr != nil || info.Size() != meta.Size {
+			s.remove(key)
+			continue
+		}
+		entries = append(entries, loaded{entry{key: key, meta: meta}, info.ModTime().UnixNano()})
+	}
+
+	// Rebuild the recency order from modification times, oldest at the back.
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime > entries[j].modTime })
+	for i := range entries {
+		s.index[entries[i].key] = s.order.PushBack(&entries[i].entry)
+		s.size += entries[i].meta.Size
+	}
+	return nil
+}
+
+// Get opens the cached image for url and variant. The caller must close the
+// returned file.
+func (s *Store) Get(url, variant string) (*os.File, Meta, bool) {
+	key := cacheKey(url, variant)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.index[key]
+	if !ok {
+		return nil, Meta{}, false
+	}
+
+	file, err := os.Open(s.dataPath(key))
+	if err != nil {
+		s.drop(element)
+		return nil, Meta{}, false
+	}
+	s.order.MoveToFront(element)
+	return file, element.Value.(*entry).meta, true
+}
+
+// Create starts writing a new image. Nothing is visible to readers until
+// Commit succeeds.
+func (s *Store) Create(meta Meta) (*Writer, error) {
+	file, err := os.CreateTemp(s.cfg.Dir, ".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image cache file: %w", err)
+	}
+	return &Writer{store: s, file: file, meta: meta}, nil
+}
+
+// PurgeISBN removes every cached image that was stored for isbn. Images first
+// cached by a title and author lookup carry no ISBN and are left alone; they
+// are only evicted.
+func (s *Store) PurgeISBN(isbn string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for element := s.order.Front(); element != nil; {
+		next := element.Next()
+		if element.Value.(*entry).meta.ISBN == isbn {
+			s.drop(element)
+			purged++
+		}
+		element = next
+	}
+	metrics.SetImageCacheSize(s.size)
+	return purged
+}
+
+// Size is the total size of the cached images in bytes.
+func (s *Store) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+func (s *Store) commit(file *os.File, meta Meta) error {
+	key := cacheKey(meta.URL, meta.Variant)
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.index[key]; ok {
+		s.drop(element)
+	}
+	if err := os.WriteFile(s.metaPath(key), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write image cache metadata: %w", err)
+	}
+	if err := os.Rename(file.Name(), s.dataPath(key)); err != nil {
+		os.Remove(s.metaPath(key))
+		return fmt.Errorf("failed to store cached image: %w", err)
+	}
+
+	s.index[key] = s.order.PushFront(&entry{key: key, meta: meta})
+	s.size += meta.Size
+	s.evict()
+	metrics.SetImageCacheSize(s.size)
+	return nil
+}
+
+// evict drops least recently used entries until the store fits in MaxBytes.
+// Callers must hold s.mu, except during NewStore.
+func (s *Store) evict() {
+	for s.size > s.cfg.MaxBytes && s.order.Len() > 0 {
+		s.drop(s.order.Back())
+		metrics.RecordImageCacheEviction()
+	}
+}
+
+// drop must be called with s.mu held.
+func (s *Store) drop(element *list.Element) {
+	e := s.order.Remove(element).(*entry)
+	delete(s.index, e.key)
+	s.size -= e.meta.Size
+	s.remove(e.key)
+}
+
+func (s *Store) remove(key string) {
+	for _, path := range []string{s.dataPath(key), s.metaPath(key)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to remove cached image", "path", path, "error", err)
+		}
+	}
+}
+
+func (s *Store) readMeta(key string) (Meta, error) {
+	var meta Meta
+	data, err := os.ReadFile(s.metaPath(key))
+	if err != nil {
+		return meta, err
+	}
+	return meta, json.Unmarshal(data, &meta)
+}
+
+func (s *Store) dataPath(key string) string {
+	return filepath.Join(s.cfg.Dir, key+dataExt)
+}
+
+func (s *Store) metaPath(key string) string {
+	return filepath.Join(s.cfg.Dir, key+metaExt)
+}
+
+func cacheKey(url, variant string) string {
+	sum := sha256.Sum256([]byte(url + "\n" + variant))
+	return hex.EncodeToString(sum[:])
+}
+
+// Writer receives the bytes of an image while it is being streamed to a
+// client. Write never fails so that a full disk does not interrupt the
+// response; the error is reported by Commit instead.
+type Writer struct {
+	store *Store
+	file  *os.File
+	meta  Meta
+	size  int64
+	err   error
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.err == nil {
+		var n int
+		n, w.err = w.file.Write(p)
+		w.size += int64(n)
+	}
+	return len(p), nil
+}
+
+// Commit makes the image available to readers.
+func (w *Writer) Commit() error {
+	closeErr := w.file.Close()
+	if w.err == nil {
+		w.err = closeErr
+	}
+	if w.err != nil {
+		os.Remove(w.file.Name())
+		return w.err
+	}
+	if w.size > w.store.cfg.MaxBytes {
+		os.Remove(w.file.Name())
+		return errors.New("image is larger than the whole cache")
+	}
+
+	w.meta.Size = w.size
+	if err := w.store.commit(w.file, w.meta); err != nil {
+		os.Remove(w.file.Name())
+		return err
+	}
+	return nil
+}
+
+// Abort discards the partially written image.
+func (w *Writer) Abort() {
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
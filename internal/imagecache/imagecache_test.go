@@ -0,0 +1,129 @@
+package imagecache
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func put(t *testing.T, s *Store, meta Meta, body string) {
+	t.Helper()
+	w, err := s.Create(meta)
+	if err != nil {
+		t.Fatalf("Create() unexpected error: %v", err)
+	}
+	io.Copy(w, strings.NewReader(body))
+	if err := w.Commit(); err != nil {
+		t.Fatalf("Commit() unexpected error: %v", err)
+	}
+}
+
+func read(t *testing.T, s *Store, url, variant string) (string, bool) {
+	t.Helper()
+	file, _, ok := s.Get(url, variant)
+	if !ok {
+		return "", false
+	}
+	defer file.Close()
+	data, _ := io.ReadAll(file)
+	return string(data), true
+}
+
+func TestStore_PutAndGet(t *testing.T) {
+	s, err := NewStore(Config{Dir: t.TempDir(), MaxBytes: 1024})
+	if err != nil {
+		t.Fatalf("NewStore() unexpected error: %v", err)
+	}
+
+	put(t, s, Meta{URL: "https://a/1.jpg", Variant: "large", ContentType: "image/jpeg"}, "large bytes")
+	put(t, s, Meta{URL: "https://a/1.jpg", Variant: "small", ContentType: "image/jpeg"}, "small")
+
+	if got, ok := read(t, s, "https://a/1.jpg", "large"); !ok || got != "large bytes" {
+		t.Errorf("Get(large) = %q, %v, want %q", got, ok, "large bytes")
+	}
+	if got, ok := read(t, s, "https://a/1.jpg", "small"); !ok || got != "small" {
+		t.Errorf("Get(small) = %q, %v, want %q", got, ok, "small")
+	}
+	if _, ok := read(t, s, "https://a/1.jpg", "medium"); ok {
+		t.Error("Get(medium) expected a miss")
+	}
+	if s.Size() != int64(len("large bytes")+len("small")) {
+		t.Errorf("Size() = %d, want %d", s.Size(), len("large bytes")+len("small"))
+	}
+}
+
+func TestStore_EvictsLeastRecentlyUsed(t *testing.T) {
+	s, _ := NewStore(Config{Dir: t.TempDir(), MaxBytes: 20})
+
+	put(t, s, Meta{URL: "https://a/1.jpg"}, strings.Repeat("1", 8))
+	put(t, s, Meta{URL: "https://a/2.jpg"}, strings.Repeat("2", 8))
+	read(t, s, "https://a/1.jpg", "")
+	put(t, s, Meta{URL: "https://a/3.jpg"}, strings.Repeat("3", 8))
+
+	if _, ok := read(t, s, "https://a/2.jpg", ""); ok {
+		t.Error("expected least recently used image to be evicted")
+	}
+	for _, url := range []string{"https://a/1.jpg", "https://a/3.jpg"} {
+		if _, ok := read(t, s, url, ""); !ok {
+			t.Errorf("expected %s to stay cached", url)
+		}
+	}
+	if s.Size() > 20 {
+		t.Errorf("Size() = %d, want at most 20", s.Size())
+	}
+}
+
+func TestStore_PurgeISBN(t *testing.T) {
+	s, _ := NewStore(Config{Dir: t.TempDir(), MaxBytes: 1024})
+
+	put(t, s, Meta{URL: "https://a/1.jpg", Variant: "large", ISBN: "9780345376596"}, "a")
+	put(t, s, Meta{URL: "https://a/1.jpg", Variant: "small", ISBN: "9780345376596"}, "b")
+	put(t, s, Meta{URL: "https://a/2.jpg", Variant: "large", ISBN: "9780000000000"}, "c")
+
+	if purged := s.PurgeISBN("9780345376596"); purged != 2 {
+		t.Errorf("PurgeISBN() = %d, want 2", purged)
+	}
+	if _, ok := read(t, s, "https://a/1.jpg", "large"); ok {
+		t.Error("expected purged image to be gone")
+	}
+	if _, ok := read(t, s, "https://a/2.jpg", "large"); !ok {
+		t.Error("expected other ISBN to stay cached")
+	}
+}
+
+func TestStore_SurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := NewStore(Config{Dir: dir, MaxBytes: 1024})
+	put(t, s, Meta{URL: "https://a/1.jpg", ContentType: "image/png"}, "cover")
+
+	reopened, err := NewStore(Config{Dir: dir, MaxBytes: 1024})
+	if err != nil {
+		t.Fatalf("NewStore() unexpected error: %v", err)
+	}
+	file, meta, ok := reopened.Get("https://a/1.jpg", "")
+	if !ok {
+		t.Fatal("expected image to survive a restart")
+	}
+	file.Close()
+	if meta.ContentType != "image/png" || meta.Size != 5 {
+		t.Errorf("Get() meta = %+v, want image/png of 5 bytes", meta)
+	}
+}
+
+func TestWriter_AbortLeavesNothingBehind(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := NewStore(Config{Dir: dir, MaxBytes: 1024})
+
+	w, _ := s.Create(Meta{URL: "https://a/1.jpg"})
+	w.Write([]byte("partial"))
+	w.Abort()
+
+	if _, ok := read(t, s, "https://a/1.jpg", ""); ok {
+		t.Error("expected aborted image to be a miss")
+	}
+	files, _ := os.ReadDir(dir)
+	if len(files) != 0 {
+		t.Errorf("expected empty cache directory, found %d files", len(files))
+	}
+}
@@ -0,0 +1,158 @@
+package imageinfo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+
+	"bookcover-api/internal/imageproc"
+	"bookcover-api/internal/imageproxy"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// sampleSize is the longest side images are scaled down to before the colors
+// and BlurHash are computed.
+const sampleSize = 64
+
+// Info describes a cover image.
+type Info struct {
+	Width         int    `json:"width"`
+	Height        int    `json:"height"`
+	Bytes         int64  `json:"bytes"`
+	MIMEType      string `json:"mime_type"`
+	DominantColor string `json:"dominant_color"`
+	AverageColor  string `json:"average_color"`
+	BlurHash      string `json:"blurhash"`
+}
+
+// Compute decodes a JPEG, PNG or GIF image and describes it.
+func Compute(data []byte, mimeType string) (*Info, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", imageproc.ErrUnsupportedFormat, err)
+	}
+	if cfg.Width*cfg.Height > imageproc.MaxPixels {
+		return nil, imageproc.ErrTooManyPixels
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	sample := downsample(img)
+	return &Info{
+		Width:         cfg.Width,
+		Height:        cfg.Height,
+		Bytes:         int64(len(data)),
+		MIMEType:      mimeType,
+		DominantColor: hex(dominantColor(sample)),
+		AverageColor:  hex(averageColor(sample)),
+		BlurHash:      BlurHash(sample, 4, 3),
+	}, nil
+}
+
+// Analyzer downloads covers through the image proxy client, so the same host
+// allowlist and size limit apply, and describes them.
+type Analyzer struct {
+	images *imageproxy.Client
+}
+
+func NewAnalyzer(images *imageproxy.Client) *Analyzer {
+	return &Analyzer{images: images}
+}
+
+func (a *Analyzer) Analyze(ctx context.Context, url string) (*Info, error) {
+	img, err := a.images.Open(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer img.Body.Close()
+
+	data, err := io.ReadAll(img.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	return Compute(data, img.ContentType)
+}
+
+func downsample(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > sampleSize || height > sampleSize {
+		if width >= height {
+			width, height = sampleSize, max(1, height*sampleSize/width)
+		} else {
+			width, height = max(1, width*sampleSize/height), sampleSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+type rgb struct {
+	r, g, b float64
+}
+
+func averageColor(img *image.RGBA) rgb {
+	var sum rgb
+	pixels := 0
+	forEachPixel(img, func(r, g, b uint8) {
+		sum.r += float64(r)
+		sum.g += float64(g)
+		sum.b += float64(b)
+		pixels++
+	})
+	return rgb{sum.r / float64(pixels), sum.g / float64(pixels), sum.b / float64(pixels)}
+}
+
+// dominantColor buckets the pixels by their 4 most significant bits per
+// channel and returns the average color of the most populated bucket.
+func dominantColor(img *image.RGBA) rgb {
+	type bucket struct {
+		sum   rgb
+		count int
+	}
+	buckets := make(map[int]*bucket)
+	var best *bucket
+	forEachPixel(img, func(r, g, b uint8) {
+		key := int(r>>4)<<8 | int(g>>4)<<4 | int(b>>4)
+		bk := buckets[key]
+		if bk == nil {
+			bk = &bucket{}
+			buckets[key] = bk
+		}
+		bk.sum.r += float64(r)
+		bk.sum.g += float64(g)
+		bk.sum.b += float64(b)
+		bk.count++
+		if best == nil || bk.count > best.count {
+			best = bk
+		}
+	})
+	n := float64(best.count)
+	return rgb{best.sum.r / n, best.sum.g / n, best.sum.b / n}
+}
+
+func forEachPixel(img *image.RGBA, fn func(r, g, b uint8)) {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			offset := img.PixOffset(x, y)
+			fn(img.Pix[offset], img.Pix[offset+1], img.Pix[offset+2])
+		}
+	}
+}
+
+func hex(c rgb) string {
+	return fmt.Sprintf("#%02x%02x%02x", uint8(c.r+0.5), uint8(c.g+0.5), uint8(c.b+0.5))
+}
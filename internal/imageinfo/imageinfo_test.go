@@ -0,0 +1,104 @@
+package imageinfo
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"testing"
+
+	"bookcover-api/internal/imageproc"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode test image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompute_SolidImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 300))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 0x33, G: 0x66, B: 0x99, A: 255}), image.Point{}, draw.Src)
+	data := encodePNG(t, img)
+
+	info, err := Compute(data, "image/png")
+	if err != nil {
+		t.Fatalf("Compute() unexpected error: %v", err)
+	}
+
+	if info.Width != 200 || info.Height != 300 {
+		t.Errorf("Compute() size = %dx%d, want 200x300", info.Width, info.Height)
+	}
+	if info.Bytes != int64(len(data)) {
+		t.Errorf("Compute() bytes = %d, want %d", info.Bytes, len(data))
+	}
+	if info.MIMEType != "image/png" {
+		t.Errorf("Compute() mime type = %q, want image/png", info.MIMEType)
+	}
+	if info.DominantColor != "#336699" {
+		t.Errorf("Compute() dominant color = %q, want #336699", info.DominantColor)
+	}
+	if info.AverageColor != "#336699" {
+		t.Errorf("Compute() average color = %q, want #336699", info.AverageColor)
+	}
+	// 1 size flag + 1 max AC + 4 DC + 2 per AC component (11 for 4x3).
+	if len(info.BlurHash) != 28 {
+		t.Errorf("Compute() blurhash = %q, want 28 characters", info.BlurHash)
+	}
+}
+
+func TestCompute_WebP(t *testing.T) {
+	data, err := os.ReadFile("testdata/cover.webp")
+	if err != nil {
+		t.Fatalf("ReadFile() unexpected error: %v", err)
+	}
+
+	info, err := Compute(data, "image/webp")
+	if err != nil {
+		t.Fatalf("Compute() unexpected error: %v", err)
+	}
+	if info.Width != 150 || info.Height != 100 || info.BlurHash == "" {
+		t.Errorf("Compute() = %+v, want a described 150x100 image", info)
+	}
+}
+
+func TestCompute_DominantColorDiffersFromAverage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 40, 10), image.Black, image.Point{}, draw.Src)
+
+	info, err := Compute(encodePNG(t, img), "image/png")
+	if err != nil {
+		t.Fatalf("Compute() unexpected error: %v", err)
+	}
+
+	if info.DominantColor != "#ffffff" {
+		t.Errorf("Compute() dominant color = %q, want #ffffff", info.DominantColor)
+	}
+	if info.AverageColor == info.DominantColor {
+		t.Errorf("Compute() average color = %q, want a mix of black and white", info.AverageColor)
+	}
+}
+
+func TestCompute_RejectsGarbage(t *testing.T) {
+	_, err := Compute([]byte("not an image"), "image/jpeg")
+	if !errors.Is(err, imageproc.ErrUnsupportedFormat) {
+		t.Errorf("Compute() error = %v, want %v", err, imageproc.ErrUnsupportedFormat)
+	}
+}
+
+func TestBlurHash_KnownValue(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	// A uniform white image has no AC energy and a white DC component.
+	if got, want := BlurHash(img, 1, 1), "00TSUA"; got != want {
+		t.Errorf("BlurHash() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,221 @@
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxPixels bounds the decoded size of an image to keep decompression bombs
+// from exhausting memory.
+const MaxPixels = 40_000_000
+
+const jpegQuality = 85
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrTooManyPixels     = errors.New("image dimensions are too large")
+)
+
+type Format string
+
+const (
+	JPEG Format = "jpeg"
+	PNG  Format = "png"
+)
+
+// Source formats that are decoded but never encoded.
+const (
+	GIF     Format = "gif"
+	WebP    Format = "webp"
+	Unknown Format = "unknown"
+)
+
+func (f Format) ContentType() string {
+	return "image/" + string(f)
+}
+
+// ParseFormat reads the value of the format query parameter.
+func ParseFormat(raw string) (Format, error) {
+	switch strings.ToLower(raw) {
+	case "":
+		return "", nil
+	case "jpeg", "jpg":
+		return JPEG, nil
+	case "png":
+		return PNG, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// Negotiate picks the output format from an Accept header. It returns "" when
+// the client has no preference between the formats we can encode.
+func Negotiate(accept string) Format {
+	var best Format
+	bestQuality := 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		var format Format
+		switch mediaType {
+		case "image/jpeg":
+			format = JPEG
+		case "image/png":
+			format = PNG
+		default:
+			continue
+		}
+
+		quality := 1.0
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil {
+			quality = q
+		}
+		if quality > bestQuality {
+			best, bestQuality = format, quality
+		}
+	}
+	return best
+}
+
+// FormatOf maps a Content-Type to the format of the image, or Unknown.
+func FormatOf(contentType string) Format {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return Unknown
+	}
+	switch format := Format(strings.TrimPrefix(mediaType, "image/")); format {
+	case JPEG, PNG, GIF, WebP:
+		return format
+	default:
+		return Unknown
+	}
+}
+
+type Options struct {
+	// Width and Height bound the output. Zero means unconstrained; the aspect
+	// ratio is always preserved and images are never upscaled.
+	Width  int
+	Height int
+	// Pad places the resized image on a Width x Height canvas.
+	Pad bool
+	// Format is the output encoding. Empty keeps the source format.
+	Format Format
+}
+
+// Process decodes a JPEG, PNG, GIF or WebP image, resizes it and re-encodes
+// it. It returns the encoded bytes and their content type.
+func Process(r io.Reader, opts Options) ([]byte, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image: %w", err)
+	}
+
+	cfg, source, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, "", ErrTooManyPixels
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	img = resize(img, opts)
+
+	format := OutputFormat(Format(source), opts.Format)
+	out, err := Encode(img, format)
+	if err != nil {
+		return nil, "", err
+	}
+	return out, format.ContentType(), nil
+}
+
+// OutputFormat is the format Process encodes a source image in when asked for
+// format. Sources we cannot encode, such as GIFs, become PNGs.
+func OutputFormat(source, format Format) Format {
+	switch {
+	case format != "":
+		return format
+	case source == JPEG:
+		return JPEG
+	default:
+		return PNG
+	}
+}
+
+// Encode encodes img as format.
+func Encode(img image.Image, format Format) ([]byte, error) {
+	var out bytes.Buffer
+	var err error
+	switch format {
+	case JPEG:
+		err = jpeg.Encode(&out, flatten(img), &jpeg.Options{Quality: jpegQuality})
+	default:
+		err = png.Encode(&out, img)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+func resize(img image.Image, opts Options) image.Image {
+	bounds := img.Bounds()
+	width, height := fit(bounds.Dx(), bounds.Dy(), opts.Width, opts.Height)
+
+	canvas := image.Rect(0, 0, width, height)
+	offset := image.Point{}
+	if opts.Pad && opts.Width > 0 && opts.Height > 0 {
+		canvas = image.Rect(0, 0, opts.Width, opts.Height)
+		offset = image.Pt((opts.Width-width)/2, (opts.Height-height)/2)
+	} else if width == bounds.Dx() && height == bounds.Dy() {
+		return img
+	}
+
+	dst := image.NewRGBA(canvas)
+	draw.Draw(dst, canvas, image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, image.Rectangle{Min: offset, Max: offset.Add(image.Pt(width, height))}, img, bounds, draw.Over, nil)
+	return dst
+}
+
+// fit scales width x height down to fit within maxWidth x maxHeight.
+func fit(width, height, maxWidth, maxHeight int) (int, int) {
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && float64(height)*scale > float64(maxHeight) {
+		scale = float64(maxHeight) / float64(height)
+	}
+	return max(1, int(float64(width)*scale+0.5)), max(1, int(float64(height)*scale+0.5))
+}
+
+// flatten composites transparent pixels onto white since JPEG has no alpha.
+func flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+	bounds := img.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Over)
+	return dst
+}
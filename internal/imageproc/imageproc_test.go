@@ -0,0 +1,166 @@
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"testing"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode() unexpected error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func decode(t *testing.T, data []byte) (image.Config, string) {
+	t.Helper()
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeConfig() unexpected error: %v", err)
+	}
+	return cfg, format
+}
+
+func TestProcess_Resize(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           Options
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{"width only", Options{Width: 100}, 100, 150},
+		{"height only", Options{Height: 300}, 200, 300},
+		{"contain", Options{Width: 100, Height: 100}, 67, 100},
+		{"pad", Options{Width: 100, Height: 100, Pad: true}, 100, 100},
+		{"never upscales", Options{Width: 800}, 200, 300},
+	}
+
+	source := testPNG(t, 200, 300)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, contentType, err := Process(bytes.NewReader(source), tt.opts)
+			if err != nil {
+				t.Fatalf("Process() unexpected error: %v", err)
+			}
+			if contentType != "image/png" {
+				t.Errorf("Process() content type = %q, want image/png", contentType)
+			}
+			cfg, _ := decode(t, data)
+			if cfg.Width != tt.expectedWidth || cfg.Height != tt.expectedHeight {
+				t.Errorf("Process() = %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.expectedWidth, tt.expectedHeight)
+			}
+		})
+	}
+}
+
+func TestProcess_ConvertsFormat(t *testing.T) {
+	data, contentType, err := Process(bytes.NewReader(testPNG(t, 20, 30)), Options{Format: JPEG})
+	if err != nil {
+		t.Fatalf("Process() unexpected error: %v", err)
+	}
+	if contentType != "image/jpeg" {
+		t.Errorf("Process() content type = %q, want image/jpeg", contentType)
+	}
+	if _, format := decode(t, data); format != "jpeg" {
+		t.Errorf("Process() encoded %s, want jpeg", format)
+	}
+
+	var jpg bytes.Buffer
+	jpeg.Encode(&jpg, image.NewRGBA(image.Rect(0, 0, 10, 10)), nil)
+	data, contentType, err = Process(&jpg, Options{Format: PNG})
+	if err != nil {
+		t.Fatalf("Process() unexpected error: %v", err)
+	}
+	if _, format := decode(t, data); format != "png" || contentType != "image/png" {
+		t.Errorf("Process() encoded %s (%s), want png", format, contentType)
+	}
+}
+
+func TestProcess_ConvertsWebP(t *testing.T) {
+	source, err := os.ReadFile("testdata/cover.webp")
+	if err != nil {
+		t.Fatalf("ReadFile() unexpected error: %v", err)
+	}
+
+	data, contentType, err := Process(bytes.NewReader(source), Options{Width: 75, Format: PNG})
+	if err != nil {
+		t.Fatalf("Process() unexpected error: %v", err)
+	}
+	cfg, format := decode(t, data)
+	if format != "png" || contentType != "image/png" || cfg.Width != 75 || cfg.Height != 50 {
+		t.Errorf("Process() = %dx%d %s (%s), want 75x50 png", cfg.Width, cfg.Height, format, contentType)
+	}
+}
+
+func TestProcess_RejectsUnsupportedInput(t *testing.T) {
+	_, _, err := Process(bytes.NewReader([]byte("<svg></svg>")), Options{Width: 10})
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Process() error = %v, want %v", err, ErrUnsupportedFormat)
+	}
+}
+
+func TestProcess_RejectsHugeDimensions(t *testing.T) {
+	// A GIF header announcing a 65535x65535 canvas.
+	header := []byte("GIF89a\xff\xff\xff\xff\x00\x00\x00;")
+	_, _, err := Process(bytes.NewReader(header), Options{Width: 10})
+	if !errors.Is(err, ErrTooManyPixels) {
+		t.Errorf("Process() error = %v, want %v", err, ErrTooManyPixels)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := map[string]Format{
+		"":    "",
+		"*/*": "",
+		"image/avif,image/webp,image/*,*/*;q=0.8": "",
+		"image/png":                         PNG,
+		"image/jpeg;q=0.5, image/png;q=0.9": PNG,
+		"image/png;q=0, image/jpeg":         JPEG,
+	}
+
+	for accept, expected := range tests {
+		if got := Negotiate(accept); got != expected {
+			t.Errorf("Negotiate(%q) = %q, want %q", accept, got, expected)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if format, err := ParseFormat("JPG"); err != nil || format != JPEG {
+		t.Errorf("ParseFormat(JPG) = %q, %v, want jpeg", format, err)
+	}
+	if _, err := ParseFormat("webp"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("ParseFormat(webp) error = %v, want %v", err, ErrUnsupportedFormat)
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	tests := map[string]Format{
+		"image/jpeg":                JPEG,
+		"image/png; charset=binary": PNG,
+		"image/gif":                 GIF,
+		"image/webp":                WebP,
+		"text/html":                 Unknown,
+		"":                          Unknown,
+	}
+
+	for contentType, expected := range tests {
+		if got := FormatOf(contentType); got != expected {
+			t.Errorf("FormatOf(%q) = %q, want %q", contentType, got, expected)
+		}
+	}
+}
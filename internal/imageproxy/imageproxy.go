@@ -0,0 +1,260 @@
+package imageproxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrHostNotAllowed = errors.New("image host is not allowed")
+	ErrTooLarge       = errors.New("image exceeds the maximum size")
+	ErrNotImage       = errors.New("upstream did not return a supported image")
+)
+
+// allowedTypes are the formats we are willing to serve from our own origin,
+// which are the ones imageproc can decode for resizing and image info. SVG is
+// deliberately left out since it can carry scripts.
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// acceptHeader asks upstreams for the allowed types only, so that CDNs which
+// negotiate do not answer with AVIF.
+const acceptHeader = "image/webp,image/jpeg,image/png,image/gif"
+
+// StatusError is returned when the upstream answers with anything but 200.
+type StatusError struct {
+	StatusCode int
+	URL        string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("upstream returned status %d for %s", e.StatusCode, e.URL)
+}
+
+type Config struct {
+	// AllowedHosts lists the hosts (and their subdomains) images may be fetched from.
+	AllowedHosts []string
+	// MaxBytes caps the size of a proxied image.
+	MaxBytes int64
+	// MaxRedirects caps how many redirects are followed. Every hop must be allowlisted.
+	MaxRedirects int
+	Timeout      time.Duration
+	// MaxAge is advertised to clients through Cache-Control.
+	MaxAge time.Duration
+	// Transport overrides the HTTP transport, mostly for tests.
+	Transport http.RoundTripper
+}
+
+func DefaultConfig() Config {
+	return Config{
+		AllowedHosts: []string{
+			"gr-assets.com",
+			"goodreads.com",
+			"media-amazon.com",
+			"images-amazon.com",
+			"ssl-images-amazon.com",
+		},
+		MaxBytes:     10 << 20, // 10MB
+		MaxRedirects: 3,
+		Timeout:      10 * time.Second,
+		MaxAge:       24 * time.Hour,
+	}
+}
+
+// ConfigFromEnv applies IMAGE_PROXY_ALLOWED_HOSTS and IMAGE_PROXY_MAX_BYTES on
+// top of DefaultConfig.
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	if raw := os.Getenv("IMAGE_PROXY_ALLOWED_HOSTS"); raw != "" {
+		cfg.AllowedHosts = nil
+		for _, host := range strings.Split(raw, ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				cfg.AllowedHosts = append(cfg.AllowedHosts, strings.ToLower(host))
+			}
+		}
+	}
+	if raw := os.Getenv("IMAGE_PROXY_MAX_BYTES"); raw != "" {
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil && n > 0 {
+			cfg.MaxBytes = n
+		}
+	}
+	return cfg
+}
+
+// Image is an upstream image ready to be streamed to a client. The caller
+// must close Body.
+type Image struct {
+	Body          io.ReadCloser
+	ContentType   string
+	ContentLength int64
+	ETag          string
+	LastModified  string
+}
+
+// Client fetches cover images from allowlisted hosts only.
+type Client struct {
+	cfg  Config
+	http *http.Client
+}
+
+func NewClient(cfg Config) *Client {
+	c := &Client{cfg: cfg}
+
+	transport := cfg.Transport
+	if transport == nil {
+		transport = &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		}
+	}
+	c.http = &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > cfg.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", cfg.MaxRedirects)
+			}
+			return c.checkURL(req.URL)
+		},
+	}
+	return c
+}
+
+// MaxAge is how long clients may cache a proxied image.
+func (c *Client) MaxAge() time.Duration {
+	return c.cfg.MaxAge
+}
+
+// Open starts downloading rawURL. Images without a Content-Length are
+// buffered up to MaxBytes so that the length is always known.
+func (c *Client) Open(ctx context.Context, rawURL string) (*Image, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image URL: %w", err)
+	}
+	if err := c.checkURL(target); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", acceptHeader)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrHostNotAllowed) {
+			return nil, ErrHostNotAllowed
+		}
+		return nil, fmt.Errorf("failed to fetch image: %w", err)
+	}
+
+	image, err := c.read(resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return image, nil
+}
+
+// Fetch downloads the whole image at rawURL, under the same host and size
+// limits as Open.
+func (c *Client) Fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	image, err := c.Open(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer image.Body.Close()
+
+	data, err := io.ReadAll(image.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	return data, nil
+}
+
+func (c *Client) read(resp *http.Response) (*Image, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode, URL: resp.Request.URL.String()}
+	}
+	if resp.ContentLength > c.cfg.MaxBytes {
+		return nil, ErrTooLarge
+	}
+
+	body := bufio.NewReaderSize(resp.Body, 512)
+	head, _ := body.Peek(512)
+	contentType := mediaType(resp.Header.Get("Content-Type"), head)
+	if !allowedTypes[contentType] {
+		return nil, ErrNotImage
+	}
+
+	var reader io.Reader = body
+	length := resp.ContentLength
+	if length < 0 {
+		data, err := io.ReadAll(io.LimitReader(body, c.cfg.MaxBytes+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image: %w", err)
+		}
+		if int64(len(data)) > c.cfg.MaxBytes {
+			return nil, ErrTooLarge
+		}
+		reader = bytes.NewReader(data)
+		length = int64(len(data))
+	}
+
+	return &Image{
+		Body:          readCloser{io.LimitReader(reader, length), resp.Body},
+		ContentType:   contentType,
+		ContentLength: length,
+		ETag:          resp.Header.Get("ETag"),
+		LastModified:  resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// checkURL only lets https requests to allowlisted hosts through.
+func (c *Client) checkURL(target *url.URL) error {
+	if target.Scheme != "https" || target.User != nil {
+		return ErrHostNotAllowed
+	}
+
+	host := strings.ToLower(target.Hostname())
+	for _, allowed := range c.cfg.AllowedHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return nil
+		}
+	}
+	return ErrHostNotAllowed
+}
+
+// mediaType trusts the upstream Content-Type unless it is missing or generic,
+// in which case the content is sniffed.
+func mediaType(header string, head []byte) string {
+	if parsed, _, err := mime.ParseMediaType(header); err == nil && parsed != "application/octet-stream" {
+		return parsed
+	}
+	parsed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	return parsed
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,108 @@
+package imageurl
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw    string
+		name   string
+		tokens []string
+		ext    string
+	}{
+		{"https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1388620656i/55030.jpg", "55030", nil, "jpg"},
+		{"https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1388620656i/55030._SX98_.jpg", "55030", []string{"SX98"}, "jpg"},
+		{"https://m.media-amazon.com/images/I/51Yf1dbJd2L._AC_SY400_.jpg", "51Yf1dbJd2L", []string{"AC", "SY400"}, "jpg"},
+		{"https://example.com/cover._SX318_SY475_.png", "cover", []string{"SX318", "SY475"}, "png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			u, ok := Parse(tt.raw)
+			if !ok {
+				t.Fatalf("Parse(%q) failed", tt.raw)
+			}
+			if u.Name != tt.name || u.Ext != tt.ext || len(u.Tokens) != len(tt.tokens) {
+				t.Fatalf("Parse(%q) = %+v", tt.raw, u)
+			}
+			for i := range tt.tokens {
+				if u.Tokens[i] != tt.tokens[i] {
+					t.Errorf("token %d = %q, want %q", i, u.Tokens[i], tt.tokens[i])
+				}
+			}
+			if got := u.String(); got != tt.raw {
+				t.Errorf("String() = %q, want round trip to %q", got, tt.raw)
+			}
+		})
+	}
+}
+
+func TestParse_NoExtension(t *testing.T) {
+	if _, ok := Parse("https://example.com/covers/55030"); ok {
+		t.Error("Parse() expected to fail for a URL without extension")
+	}
+}
+
+func TestWithoutSize(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{"https://example.com/cover_SX98_.jpg", "https://example.com/cover_SX98_.jpg"},
+		{"https://example.com/cover._SX98_.jpg", "https://example.com/cover.jpg"},
+		{"https://example.com/cover._SY75_.jpg?v=1", "https://example.com/cover.jpg?v=1"},
+		{"https://m.media-amazon.com/images/I/51Yf1dbJd2L._AC_SR320,320_.jpg", "https://m.media-amazon.com/images/I/51Yf1dbJd2L._AC_.jpg"},
+		{"https://example.com/cover.__SY75__.jpg", "https://example.com/cover.jpg"},
+	}
+
+	for _, tt := range tests {
+		u, _ := Parse(tt.raw)
+		if got := u.WithoutSize().String(); got != tt.expected {
+			t.Errorf("WithoutSize(%q) = %q, want %q", tt.raw, got, tt.expected)
+		}
+	}
+}
+
+func TestWithSize(t *testing.T) {
+	u, _ := Parse("https://i.gr-assets.com/books/1388620656i/55030._SX98_.jpg")
+
+	tests := []struct {
+		width, height int
+		expected      string
+	}{
+		{0, 75, "https://i.gr-assets.com/books/1388620656i/55030._SY75_.jpg"},
+		{200, 0, "https://i.gr-assets.com/books/1388620656i/55030._SX200_.jpg"},
+		{200, 300, "https://i.gr-assets.com/books/1388620656i/55030._SX200_SY300_.jpg"},
+		{0, 0, "https://i.gr-assets.com/books/1388620656i/55030.jpg"},
+	}
+
+	for _, tt := range tests {
+		if got := u.WithSize(tt.width, tt.height).String(); got != tt.expected {
+			t.Errorf("WithSize(%d, %d) = %q, want %q", tt.width, tt.height, got, tt.expected)
+		}
+	}
+}
+
+func TestWithPaddedSize(t *testing.T) {
+	u, _ := Parse("https://m.media-amazon.com/images/I/51Yf1dbJd2L._AC_SY400_.jpg")
+
+	expected := "https://m.media-amazon.com/images/I/51Yf1dbJd2L._AC_SR200,300_.jpg"
+	if got := u.WithPaddedSize(200, 300).String(); got != expected {
+		t.Errorf("WithPaddedSize(200, 300) = %q, want %q", got, expected)
+	}
+}
+
+func TestResizable(t *testing.T) {
+	tests := map[string]bool{
+		"https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1i/1.jpg": true,
+		"https://m.media-amazon.com/images/I/51Yf1dbJd2L.jpg":                            true,
+		"https://images-na.ssl-images-amazon.com/images/I/51Yf1dbJd2L.jpg":               true,
+		"https://example.com/cover.jpg":                                                  false,
+		"https://gr-assets.com.evil.example/cover.jpg":                                   false,
+	}
+
+	for raw, expected := range tests {
+		if got := Resizable(raw); got != expected {
+			t.Errorf("Resizable(%q) = %v, want %v", raw, got, expected)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"bookcover-api/internal/service"
+)
+
+var (
+	ErrNoItems           = errors.New("job has no items")
+	ErrTooManyItems      = errors.New("job has too many items")
+	ErrInvalidCallback   = errors.New("callback_url must be an http or https URL")
+	ErrCallbacksDisabled = errors.New("callbacks are disabled because no webhook secret is configured")
+	ErrQueueFull         = errors.New("too many jobs are queued")
+	ErrShuttingDown      = errors.New("job manager is shutting down")
+)
+
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+)
+
+// Item identifies one book of a job by ISBN, or by title and author.
+type Item struct {
+	ISBN       string `json:"isbn,omitempty"`
+	BookTitle  string `json:"book_title,omitempty"`
+	AuthorName string `json:"author_name,omitempty"`
+}
+
+// Result is the outcome of one item, with the HTTP status a single lookup
+// would have answered.
+type Result struct {
+	Item
+	Status int    `json:"status"`
+	URL    string `json:"url,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Callback tracks the webhook sent when a job completes.
+type Callback struct {
+	URL       string `json:"url"`
+	Delivered bool   `json:"delivered"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+func (c *Callback) done() bool {
+	return c.Delivered || c.Attempts >= webhookAttempts
+}
+
+// Job is a list of lookups resolved in the background. Items are resolved in
+// order, so Results always holds the outcome of the first len(Results) items.
+type Job struct {
+	ID          string               `json:"id"`
+	Status      Status               `json:"status"`
+	Options     service.ImageOptions `json:"options"`
+	Items       []Item               `json:"items"`
+	Results     []Result             `json:"results"`
+	Callback    *Callback            `json:"callback,omitempty"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+	CompletedAt *time.Time           `json:"completed_at,omitempty"`
+}
+
+// Finished reports whether there is nothing left to do for the job,
+// including delivering its webhook.
+func (j *Job) Finished() bool {
+	return j.Status == StatusCompleted && (j.Callback == nil || j.Callback.done())
+}
+
+func (j *Job) clone() *Job {
+	c := *j
+	c.Items = append([]Item(nil), j.Items...)
+	c.Results = append([]Result(nil), j.Results...)
+	if j.Callback != nil {
+		callback := *j.Callback
+		c.Callback = &callback
+	}
+	if j.CompletedAt != nil {
+		completedAt := *j.CompletedAt
+		c.CompletedAt = &completedAt
+	}
+	return &c
+}
+
+// View is what clients see of a job, both when polling and in the webhook.
+type View struct {
+	ID          string     `json:"id"`
+	Status      Status     `json:"status"`
+	Total       int        `json:"total"`
+	Completed   int        `json:"completed"`
+	Results     []Result   `json:"results"`
+	CallbackURL string     `json:"callback_url,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+func (j *Job) View() View {
+	view := View{
+		ID:          j.ID,
+		Status:      j.Status,
+		Total:       len(j.Items),
+		Completed:   len(j.Results),
+		Results:     j.Results,
+		CreatedAt:   j.CreatedAt,
+		UpdatedAt:   j.UpdatedAt,
+		CompletedAt: j.CompletedAt,
+	}
+	if view.Results == nil {
+		view.Results = []Result{}
+	}
+	if j.Callback != nil {
+		view.CallbackURL = j.Callback.URL
+	}
+	return view
+}
+
+// Resolver looks up one item of a job. ctx is canceled when a shutdown runs
+// out of time.
+type Resolver func(ctx context.Context, item Item, opts service.ImageOptions) Result
+
+type Config struct {
+	// Workers is how many jobs run at the same time.
+	Workers int
+	// MaxItems is the largest job accepted.
+	MaxItems int
+	// QueueSize is how many jobs may wait for a worker.
+	QueueSize int
+	// Retention is how long finished jobs are kept.
+	Retention time.Duration
+	// CheckpointInterval is how often the progress of a running job is saved.
+	CheckpointInterval time.Duration
+	// WebhookSecret signs the completion webhooks. Callbacks are rejected
+	// when it is empty.
+	WebhookSecret string
+	// Webhooks delivers the completion webhooks. Defaults to a client that
+	// refuses to connect to private and loopback addresses.
+	Webhooks Doer
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Workers:            2,
+		MaxItems:           10000,
+		QueueSize:          100,
+		Retention:          24 * time.Hour,
+		CheckpointInterval: time.Second,
+	}
+}
+
+// ConfigFromEnv reads JOBS_WORKERS, JOBS_MAX_ITEMS, JOBS_RETENTION and
+// JOBS_WEBHOOK_SECRET on top of the defaults.
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	if n, err := strconv.Atoi(os.Getenv("JOBS_WORKERS")); err == nil && n > 0 {
+		cfg.Workers = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("JOBS_MAX_ITEMS")); err == nil && n > 0 {
+		cfg.MaxItems = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("JOBS_RETENTION")); err == nil && d > 0 {
+		cfg.Retention = d
+	}
+	cfg.WebhookSecret = os.Getenv("JOBS_WEBHOOK_SECRET")
+	return cfg
+}
+
+// Manager queues jobs, runs them in the background and keeps their state in
+// the store.
+type Manager struct {
+	store   Store
+	resolve Resolver
+	cfg     Config
+	queue   chan string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// lookups is the context of running lookups. It outlives ctx so that
+	// the lookups in flight at shutdown can finish.
+	lookups       context.Context
+	cancelLookups context.CancelFunc
+}
+
+func NewManager(store Store, resolve Resolver, cfg Config) *Manager {
+	defaults := DefaultConfig()
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaults.Workers
+	}
+	if cfg.MaxItems <= 0 {
+		cfg.MaxItems = defaults.MaxItems
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaults.QueueSize
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = defaults.Retention
+	}
+	if cfg.CheckpointInterval <= 0 {
+		cfg.CheckpointInterval = defaults.CheckpointInterval
+	}
+	if cfg.Webhooks == nil {
+		cfg.Webhooks = newWebhookClient()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lookups, cancelLookups := context.WithCancel(context.Background())
+	return &Manager{
+		store:         store,
+		resolve:       resolve,
+		cfg:           cfg,
+		queue:         make(chan string, cfg.QueueSize),
+		ctx:           ctx,
+		cancel:        cancel,
+		lookups:       lookups,
+		cancelLookups: cancelLookups,
+	}
+}
+
+// Start resumes the jobs a previous process left unfinished and starts the
+// workers.
+func (m *Manager) Start() error {
+	unfinished, err := m.store.Unfinished()
+	if err != nil {
+		return fmt.Errorf("failed to load unfinished jobs: %w", err)
+	}
+
+	for range m.cfg.Workers {
+		m.wg.Add(1)
+		go m.work()
+	}
+	m.wg.Add(1)
+	go m.prune()
+
+	if len(unfinished) > 0 {
+		slog.Info("resuming jobs", "count", len(unfinished))
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			for _, job := range unfinished {
+				select {
+				case m.queue <- job.ID:
+				case <-m.ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// Shutdown stops picking up jobs and waits for the running ones to save their
+// progress. They are resumed by the next Start on a durable store. Lookups
+// still running when ctx is done are canceled.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.cancel()
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		m.cancelLookups()
+		return ctx.Err()
+	}
+}
+
+// MaxItems is the largest job Submit accepts.
+func (m *Manager) MaxItems() int {
+	return m.cfg.MaxItems
+}
+
+// Submit validates and queues a new job.
+func (m *Manager) Submit(items []Item, opts service.ImageOptions, callbackURL string) (*Job, error) {
+	if len(items) == 0 {
+		return nil, ErrNoItems
+	}
+	if len(items) > m.cfg.MaxItems {
+		return nil, ErrTooManyItems
+	}
+	if m.ctx.Err() != nil {
+		return nil, ErrShuttingDown
+	}
+
+	now := time.Now().UTC()
+	job := &Job{
+		ID:        newID(),
+		Status:    StatusQueued,
+		Options:   opts,
+		Items:     items,
+		Results:   []Result{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if callbackURL != "" {
+		if m.cfg.WebhookSecret == "" {
+			return nil, ErrCallbacksDisabled
+		}
+		parsed, err := url.Parse(callbackURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, ErrInvalidCallback
+		}
+		job.Callback = &Callback{URL: callbackURL}
+	}
+
+	if err := m.store.Save(job); err != nil {
+		return nil, err
+	}
+	select {
+	case m.queue <- job.ID:
+		return job, nil
+	default:
+		if err := m.store.Delete(job.ID); err != nil {
+			slog.Error("failed to delete rejected job", "job", job.ID, "error", err)
+		}
+		return nil, ErrQueueFull
+	}
+}
+
+func (m *Manager) Get(id string) (*Job, error) {
+	return m.store.Get(id)
+}
+
+func (m *Manager) work() {
+	defer m.wg.Done()
+	for {
+		select {
+		case id := <-m.queue:
+			m.run(id)
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// run resolves the items that have no result yet, then delivers the webhook.
+func (m *Manager) run(id string) {
+	job, err := m.store.Get(id)
+	if err != nil {
+		slog.Error("failed to load job", "job", id, "error", err)
+		return
+	}
+
+	if job.Status != StatusCompleted {
+		job.Status = StatusRunning
+		m.save(job)
+
+		lastSave := time.Now()
+		for len(job.Results) < len(job.Items) {
+			if m.ctx.Err() != nil {
+				m.save(job)
+				return
+			}
+			result := m.resolve(m.lookups, job.Items[len(job.Results)], job.Options)
+			if m.lookups.Err() != nil {
+				// The lookup was cut short; it is redone when the job resumes.
+				m.save(job)
+				return
+			}
+			job.Results = append(job.Results, result)
+			if time.Since(lastSave) >= m.cfg.CheckpointInterval {
+				m.save(job)
+				lastSave = time.Now()
+			}
+		}
+
+		completedAt := time.Now().UTC()
+		job.Status = StatusCompleted
+		job.CompletedAt = &completedAt
+		m.save(job)
+	}
+
+	if job.Callback != nil && !job.Callback.done() {
+		m.deliver(job)
+	}
+}
+
+func (m *Manager) save(job *Job) {
+	job.UpdatedAt = time.Now().UTC()
+	if err := m.store.Save(job); err != nil {
+		slog.Error("failed to save job", "job", job.ID, "error", err)
+	}
+}
+
+// prune deletes finished jobs once they are older than the retention period.
+func (m *Manager) prune() {
+	defer m.wg.Done()
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if n, err := m.store.Prune(time.Now().Add(-m.cfg.Retention)); err != nil {
+				slog.Error("failed to prune jobs", "error", err)
+			} else if n > 0 {
+				slog.Info("pruned jobs", "count", n)
+			}
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,227 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bookcover-api/internal/service"
+)
+
+func echoResolver(ctx context.Context, item Item, opts service.ImageOptions) Result {
+	return Result{Item: item, Status: http.StatusOK, URL: "https://example.com/" + item.ISBN + ".jpg"}
+}
+
+// waitFor polls the store until the job is finished.
+func waitFor(t *testing.T, m *Manager, id string) *Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Get() unexpected error: %v", err)
+		}
+		if job.Finished() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return nil
+}
+
+func startManager(t *testing.T, store Store, resolve Resolver, cfg Config) *Manager {
+	t.Helper()
+	m := NewManager(store, resolve, cfg)
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start() unexpected error: %v", err)
+	}
+	t.Cleanup(func() { m.Shutdown(context.Background()) })
+	return m
+}
+
+func TestManager_ResolvesItemsInOrder(t *testing.T) {
+	m := startManager(t, NewMemoryStore(), echoResolver, Config{})
+
+	items := []Item{{ISBN: "9780345376596"}, {ISBN: "9780441013593"}, {BookTitle: "Dune", AuthorName: "Frank Herbert"}}
+	job, err := m.Submit(items, service.ImageOptions{}, "")
+	if err != nil {
+		t.Fatalf("Submit() unexpected error: %v", err)
+	}
+	if job.Status != StatusQueued {
+		t.Errorf("Submit() status = %s, want %s", job.Status, StatusQueued)
+	}
+
+	job = waitFor(t, m, job.ID)
+	view := job.View()
+	if view.Status != StatusCompleted || view.Total != 3 || view.Completed != 3 || view.CompletedAt == nil {
+		t.Errorf("unexpected finished job %+v", view)
+	}
+	for i, result := range view.Results {
+		if result.Item != items[i] || result.Status != http.StatusOK {
+			t.Errorf("result %d = %+v, want item %+v", i, result, items[i])
+		}
+	}
+}
+
+func TestManager_SubmitValidation(t *testing.T) {
+	m := startManager(t, NewMemoryStore(), echoResolver, Config{MaxItems: 2})
+	signed := startManager(t, NewMemoryStore(), echoResolver, Config{WebhookSecret: "secret"})
+
+	tests := []struct {
+		name     string
+		manager  *Manager
+		items    []Item
+		callback string
+		want     error
+	}{
+		{"no items", m, nil, "", ErrNoItems},
+		{"too many items", m, []Item{{ISBN: "1"}, {ISBN: "2"}, {ISBN: "3"}}, "", ErrTooManyItems},
+		{"callback without secret", m, []Item{{ISBN: "1"}}, "https://example.com/hook", ErrCallbacksDisabled},
+		{"callback with another scheme", signed, []Item{{ISBN: "1"}}, "ftp://example.com/hook", ErrInvalidCallback},
+		{"relative callback", signed, []Item{{ISBN: "1"}}, "/hook", ErrInvalidCallback},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.manager.Submit(tt.items, service.ImageOptions{}, tt.callback); !errors.Is(err, tt.want) {
+				t.Errorf("Submit() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_DeliversSignedWebhook(t *testing.T) {
+	defer func(backoff time.Duration) { webhookBackoff = backoff }(webhookBackoff)
+	webhookBackoff = time.Millisecond
+
+	var calls atomic.Int32
+	deliveries := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first delivery fails and is retried.
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- r
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	m := startManager(t, NewMemoryStore(), echoResolver, Config{WebhookSecret: "secret", Webhooks: srv.Client()})
+	job, err := m.Submit([]Item{{ISBN: "9780345376596"}}, service.ImageOptions{}, srv.URL+"/hook")
+	if err != nil {
+		t.Fatalf("Submit() unexpected error: %v", err)
+	}
+
+	job = waitFor(t, m, job.ID)
+	if !job.Callback.Delivered || job.Callback.Attempts != 2 {
+		t.Errorf("unexpected callback state %+v", job.Callback)
+	}
+
+	req, body := <-deliveries, <-bodies
+	timestamp := req.Header.Get(TimestampHeader)
+	if got, want := req.Header.Get(SignatureHeader), Sign("secret", timestamp, body); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+
+	var view View
+	if err := json.Unmarshal(body, &view); err != nil {
+		t.Fatalf("webhook body is not a job: %v", err)
+	}
+	if view.ID != job.ID || view.Status != StatusCompleted || len(view.Results) != 1 {
+		t.Errorf("unexpected webhook body %s", body)
+	}
+}
+
+func TestManager_ResumesUnfinishedJobs(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore() unexpected error: %v", err)
+	}
+
+	// A job the previous process was halfway through.
+	now := time.Now().UTC()
+	store.Save(&Job{
+		ID:        "0123456789abcdef",
+		Status:    StatusRunning,
+		Items:     []Item{{ISBN: "1"}, {ISBN: "2"}, {ISBN: "3"}},
+		Results:   []Result{{Item: Item{ISBN: "1"}, Status: http.StatusOK, URL: "https://example.com/1.jpg"}},
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+
+	var mu sync.Mutex
+	var resolved []string
+	m := startManager(t, store, func(ctx context.Context, item Item, opts service.ImageOptions) Result {
+		mu.Lock()
+		resolved = append(resolved, item.ISBN)
+		mu.Unlock()
+		return echoResolver(ctx, item, opts)
+	}, Config{})
+
+	job := waitFor(t, m, "0123456789abcdef")
+	if len(job.Results) != 3 {
+		t.Errorf("expected 3 results, got %d", len(job.Results))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(resolved) != 2 || resolved[0] != "2" || resolved[1] != "3" {
+		t.Errorf("expected only the remaining items to be resolved, got %v", resolved)
+	}
+}
+
+func TestManager_ShutdownSavesProgress(t *testing.T) {
+	store := NewMemoryStore()
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	m := NewManager(store, func(ctx context.Context, item Item, opts service.ImageOptions) Result {
+		started <- struct{}{}
+		<-release
+		return echoResolver(ctx, item, opts)
+	}, Config{})
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start() unexpected error: %v", err)
+	}
+
+	job, err := m.Submit([]Item{{ISBN: "1"}, {ISBN: "2"}, {ISBN: "3"}}, service.ImageOptions{}, "")
+	if err != nil {
+		t.Fatalf("Submit() unexpected error: %v", err)
+	}
+	<-started
+
+	done := make(chan error)
+	go func() { done <- m.Shutdown(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Shutdown() unexpected error: %v", err)
+	}
+
+	saved, _ := store.Get(job.ID)
+	if saved.Status != StatusRunning || len(saved.Results) != 1 {
+		t.Errorf("expected a running job with 1 result, got status %s with %d results", saved.Status, len(saved.Results))
+	}
+	if _, err := m.Submit([]Item{{ISBN: "1"}}, service.ImageOptions{}, ""); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("Submit() after shutdown error = %v, want %v", err, ErrShuttingDown)
+	}
+}
+
+func TestWebhookClient_RefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	_, err := newWebhookClient().Post(srv.URL, "application/json", nil)
+	if !errors.Is(err, errPrivateAddress) {
+		t.Errorf("Post() error = %v, want %v", err, errPrivateAddress)
+	}
+}
@@ -0,0 +1,226 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrNotFound = errors.New("job was not found")
+
+// Store persists jobs. Implementations must be safe for concurrent use and
+// must not share memory with the jobs they are given or return.
+type Store interface {
+	// Save creates or replaces a job.
+	Save(job *Job) error
+	Get(id string) (*Job, error)
+	Delete(id string) error
+	// Unfinished returns the jobs that were queued or running, to resume them.
+	Unfinished() ([]*Job, error)
+	// Prune deletes the jobs that finished before t and returns how many.
+	Prune(before time.Time) (int, error)
+}
+
+// MemoryStore keeps jobs in memory. They are lost on restart.
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]*Job)}
+}
+
+func (s *MemoryStore) Save(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job.clone()
+	return nil
+}
+
+func (s *MemoryStore) Get(id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return job.clone(), nil
+}
+
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+	return nil
+}
+
+func (s *MemoryStore) Unfinished() ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var jobs []*Job
+	for _, job := range s.jobs {
+		if !job.Finished() {
+			jobs = append(jobs, job.clone())
+		}
+	}
+	return jobs, nil
+}
+
+func (s *MemoryStore) Prune(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pruned := 0
+	for id, job := range s.jobs {
+		if job.Finished() && job.CompletedAt.Before(before) {
+			delete(s.jobs, id)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// FileStore keeps one JSON file per job in a directory, so jobs survive
+// restarts.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore opens the store, creating dir if missing.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create job store directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// StoreFromEnv opens a FileStore in JOBS_STORE_DIR, or a MemoryStore when it
+// is not set.
+func StoreFromEnv() (Store, error) {
+	if dir := os.Getenv("JOBS_STORE_DIR"); dir != "" {
+		return NewFileStore(dir)
+	}
+	return NewMemoryStore(), nil
+}
+
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// Save writes the job to a temporary file and renames it into place, so a
+// crash never leaves a half-written job behind.
+func (s *FileStore) Save(job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, job.ID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(job.ID)); err != nil {
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) Get(id string) (*Job, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	return s.read(s.path(id))
+}
+
+func (s *FileStore) Delete(id string) error {
+	if !validID(id) {
+		return nil
+	}
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete job: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) read(path string) (*Job, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job: %w", err)
+	}
+
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to decode job %s: %w", filepath.Base(path), err)
+	}
+	return &job, nil
+}
+
+func (s *FileStore) all() ([]*Job, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*Job, 0, len(paths))
+	for _, path := range paths {
+		job, err := s.read(path)
+		if err != nil {
+			slog.Error("skipping unreadable job", "path", path, "error", err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func (s *FileStore) Unfinished() ([]*Job, error) {
+	jobs, err := s.all()
+	if err != nil {
+		return nil, err
+	}
+	unfinished := jobs[:0]
+	for _, job := range jobs {
+		if !job.Finished() {
+			unfinished = append(unfinished, job)
+		}
+	}
+	return unfinished, nil
+}
+
+func (s *FileStore) Prune(before time.Time) (int, error) {
+	jobs, err := s.all()
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for _, job := range jobs {
+		if job.Finished() && job.CompletedAt.Before(before) {
+			if err := s.Delete(job.ID); err != nil {
+				return pruned, err
+			}
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// validID keeps client-supplied IDs from escaping the store directory.
+func validID(id string) bool {
+	return id != "" && strings.Trim(id, "0123456789abcdef") == ""
+}
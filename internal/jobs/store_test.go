@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testStores(t *testing.T) map[string]Store {
+	fileStore, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore() unexpected error: %v", err)
+	}
+	return map[string]Store{"memory": NewMemoryStore(), "file": fileStore}
+}
+
+func TestStore_SaveGetAndPrune(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			old := time.Now().Add(-48 * time.Hour)
+			recent := time.Now()
+			jobs := []*Job{
+				{ID: "aa", Status: StatusRunning, Items: []Item{{ISBN: "1"}}},
+				{ID: "bb", Status: StatusCompleted, CompletedAt: &old},
+				{ID: "cc", Status: StatusCompleted, CompletedAt: &recent},
+				{ID: "dd", Status: StatusCompleted, CompletedAt: &old, Callback: &Callback{URL: "https://example.com/hook"}},
+			}
+			for _, job := range jobs {
+				if err := store.Save(job); err != nil {
+					t.Fatalf("Save() unexpected error: %v", err)
+				}
+			}
+
+			// Jobs handed to or returned by the store are copies.
+			jobs[0].Items[0].ISBN = "changed"
+			got, err := store.Get("aa")
+			if err != nil {
+				t.Fatalf("Get() unexpected error: %v", err)
+			}
+			if got.Items[0].ISBN != "1" {
+				t.Errorf("Get() item = %q, want 1", got.Items[0].ISBN)
+			}
+
+			unfinished, err := store.Unfinished()
+			if err != nil {
+				t.Fatalf("Unfinished() unexpected error: %v", err)
+			}
+			if len(unfinished) != 2 {
+				t.Errorf("Unfinished() returned %d jobs, want the running job and the pending webhook", len(unfinished))
+			}
+
+			pruned, err := store.Prune(time.Now().Add(-24 * time.Hour))
+			if err != nil {
+				t.Fatalf("Prune() unexpected error: %v", err)
+			}
+			if pruned != 1 {
+				t.Errorf("Prune() = %d, want 1", pruned)
+			}
+			if _, err := store.Get("bb"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() pruned job error = %v, want %v", err, ErrNotFound)
+			}
+			if _, err := store.Get("cc"); err != nil {
+				t.Errorf("Get() recent job unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestFileStore_RejectsPathsOutsideTheDirectory(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := NewFileStore(filepath.Join(dir, "jobs"))
+	os.WriteFile(filepath.Join(dir, "secret.json"), []byte(`{"id": "secret"}`), 0o644)
+
+	if _, err := store.Get("../secret"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestFileStore_SkipsCorruptJobs(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := NewFileStore(dir)
+	store.Save(&Job{ID: "aa", Status: StatusQueued})
+	os.WriteFile(filepath.Join(dir, "bb.json"), []byte("{"), 0o644)
+
+	unfinished, err := store.Unfinished()
+	if err != nil {
+		t.Fatalf("Unfinished() unexpected error: %v", err)
+	}
+	if len(unfinished) != 1 || unfinished[0].ID != "aa" {
+		t.Errorf("Unfinished() = %v, want only the readable job", unfinished)
+	}
+}
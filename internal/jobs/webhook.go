@@ -0,0 +1,133 @@
+package jobs
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	webhookAttempts = 5
+	webhookTimeout  = 10 * time.Second
+
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the
+	// timestamp, a dot and the body, keyed with the webhook secret.
+	SignatureHeader = "X-Bookcover-Signature"
+	// TimestampHeader carries the Unix time the webhook was signed at, so
+	// receivers can reject replayed deliveries.
+	TimestampHeader = "X-Bookcover-Timestamp"
+)
+
+var errPrivateAddress = errors.New("callback address is not public")
+
+// webhookBackoff is the delay before the first retry; it doubles after each one.
+var webhookBackoff = time.Second
+
+// Doer sends HTTP requests. *http.Client implements it.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Sign computes the signature sent in SignatureHeader.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newWebhookClient returns a client that only talks to public addresses and
+// does not follow redirects, so callbacks cannot be used to reach internal
+// services.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+				ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+				return fmt.Errorf("%w: %s", errPrivateAddress, host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// deliver posts the job to its callback URL, retrying with exponential
+// backoff until it is accepted or the attempts run out.
+func (m *Manager) deliver(job *Job) {
+	body, err := json.Marshal(job.View())
+	if err != nil {
+		slog.Error("failed to encode webhook", "job", job.ID, "error", err)
+		return
+	}
+
+	for !job.Callback.done() {
+		if job.Callback.Attempts > 0 {
+			delay := webhookBackoff << (job.Callback.Attempts - 1)
+			select {
+			case <-time.After(delay):
+			case <-m.ctx.Done():
+				return
+			}
+		}
+
+		err := m.post(job.Callback.URL, body)
+		if err != nil && m.ctx.Err() != nil {
+			// Interrupted by a shutdown; the next Start tries again.
+			return
+		}
+		job.Callback.Attempts++
+		if err == nil {
+			job.Callback.Delivered = true
+			job.Callback.LastError = ""
+		} else {
+			job.Callback.LastError = err.Error()
+			slog.Warn("failed to deliver job webhook", "job", job.ID, "attempt", job.Callback.Attempts, "error", err)
+		}
+		m.save(job)
+	}
+}
+
+func (m *Manager) post(url string, body []byte) error {
+	req, err := http.NewRequestWithContext(m.ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(m.cfg.WebhookSecret, timestamp, body))
+
+	resp, err := m.cfg.Webhooks.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback answered with status %d", resp.StatusCode)
+	}
+	return nil
+}
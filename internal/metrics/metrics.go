@@ -30,11 +30,137 @@ var (
 		},
 		[]string{"path", "method"},
 	)
+
+	grpcRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bookcover_grpc_requests_total",
+			Help: "Total number of gRPC calls.",
+		},
+		[]string{"method", "code"},
+	)
+
+	grpcRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "bookcover_grpc_request_duration_seconds",
+			Help:    "Duration of gRPC calls in seconds.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method"},
+	)
+
+	circuitBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bookcover_circuit_breaker_state",
+			Help: "State of the upstream provider circuit breaker (0=closed, 1=half-open, 2=open).",
+		},
+		[]string{"provider"},
+	)
+
+	scrapedPagesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bookcover_scraper_pages_total",
+			Help: "Total number of upstream pages scraped, by provider and page class.",
+		},
+		[]string{"provider", "class"},
+	)
+
+	proxyHealthy = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bookcover_scraper_proxy_healthy",
+			Help: "Whether an egress proxy is in rotation (1) or quarantined (0).",
+		},
+		[]string{"proxy"},
+	)
+
+	proxyBlockedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bookcover_scraper_proxy_blocked_total",
+			Help: "Total number of blocked responses (403 or captcha) served through an egress proxy.",
+		},
+		[]string{"proxy"},
+	)
+
+	imageBytesServedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bookcover_image_bytes_served_total",
+			Help: "Total number of proxied image bytes, by where they were read from (cache or upstream).",
+		},
+		[]string{"source"},
+	)
+
+	imageCacheSizeBytes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "bookcover_image_cache_size_bytes",
+			Help: "Total size of the images in the local image cache.",
+		},
+	)
+
+	imageCacheEvictionsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "bookcover_image_cache_evictions_total",
+			Help: "Total number of images evicted from the local image cache.",
+		},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(httpRequestsTotal)
 	prometheus.MustRegister(httpRequestDuration)
+	prometheus.MustRegister(grpcRequestsTotal)
+	prometheus.MustRegister(grpcRequestDuration)
+	prometheus.MustRegister(circuitBreakerState)
+	prometheus.MustRegister(scrapedPagesTotal)
+	prometheus.MustRegister(proxyHealthy)
+	prometheus.MustRegister(proxyBlockedTotal)
+	prometheus.MustRegister(imageBytesServedTotal)
+	prometheus.MustRegister(imageCacheSizeBytes)
+	prometheus.MustRegister(imageCacheEvictionsTotal)
+}
+
+// RecordGRPCRequest counts a finished gRPC call by its full method name and
+// status code.
+func RecordGRPCRequest(method, code string, duration time.Duration) {
+	grpcRequestsTotal.WithLabelValues(method, code).Inc()
+	grpcRequestDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+// SetCircuitBreakerState publishes the current breaker state of a provider.
+func SetCircuitBreakerState(provider string, state int) {
+	circuitBreakerState.WithLabelValues(provider).Set(float64(state))
+}
+
+// RecordScrapedPage counts an upstream page by the class it was recognized as.
+func RecordScrapedPage(provider, class string) {
+	scrapedPagesTotal.WithLabelValues(provider, class).Inc()
+}
+
+// SetProxyHealthy publishes whether an egress proxy is in rotation.
+func SetProxyHealthy(proxy string, healthy bool) {
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	proxyHealthy.WithLabelValues(proxy).Set(value)
+}
+
+// RecordProxyBlocked counts a blocked response served through an egress proxy.
+func RecordProxyBlocked(proxy string) {
+	proxyBlockedTotal.WithLabelValues(proxy).Inc()
+}
+
+// RecordImageBytesServed counts proxied image bytes read from source ("cache" or "upstream").
+func RecordImageBytesServed(source string, n int64) {
+	imageBytesServedTotal.WithLabelValues(source).Add(float64(n))
+}
+
+// SetImageCacheSize publishes the total size of the local image cache.
+func SetImageCacheSize(bytes int64) {
+	imageCacheSizeBytes.Set(float64(bytes))
+}
+
+// RecordImageCacheEviction counts an image evicted from the local image cache.
+func RecordImageCacheEviction() {
+	imageCacheEvictionsTotal.Inc()
 }
 
 type statusRecorder struct {
@@ -47,10 +173,25 @@ func (r *statusRecorder) WriteHeader(code int) {
 	r.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush streamed responses.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 // normalizePath replaces dynamic ISBN segments to avoid high-cardinality labels.
 func normalizePath(path string) string {
-	if strings.HasPrefix(path, "/bookcover/") {
+	switch {
+	case path == "/bookcover/image", path == "/bookcover/batch", path == "/bookcover/bulk":
+		return path
+	case strings.HasPrefix(path, "/bookcover/"):
 		return "/bookcover/:isbn"
+	case strings.HasPrefix(path, "/cover/"):
+		return "/cover/:isbn"
+	case strings.HasPrefix(path, "/jobs/"):
+		return "/jobs/:id"
+	case strings.HasPrefix(path, "/v1/covers/"):
+		return "/v1/covers/:isbn"
 	}
 	return path
 }
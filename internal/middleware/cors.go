@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultCorsMaxAge = 10 * time.Minute
+
+type CorsConfig struct {
+	// AllowedOrigins are the origins browsers may call the API from. "*"
+	// allows any origin, and an entry may hold one wildcard, as in
+	// "https://*.example.com".
+	AllowedOrigins []string
+	// AllowedMethods and AllowedHeaders are what preflight requests may ask for.
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers scripts may read.
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send cookies and HTTP authentication.
+	// Allowed origins are then echoed back instead of "*". It is ignored
+	// when AllowedOrigins holds "*", since any site could then make
+	// credentialed requests.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+// DefaultCorsConfig allows any origin to make the requests the public
+// endpoints accept, and to read the rate-limit headers.
+func DefaultCorsConfig() CorsConfig {
+	return CorsConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{http.MethodGet, http.MethodHead, http.MethodPost},
+		AllowedHeaders: []string{"Authorization", "Content-Type", "If-None-Match", RequestIDHeader},
+		ExposedHeaders: []string{
+			"X-RateLimit-Limit-Daily",
+			"X-RateLimit-Remaining-Daily",
+			"X-RateLimit-Limit-Monthly",
+			"X-RateLimit-Remaining-Monthly",
+			RequestIDHeader,
+			"ETag",
+			"Deprecation",
+			"Sunset",
+			"Link",
+		},
+		MaxAge: DefaultCorsMaxAge,
+	}
+}
+
+// CorsConfigFromEnv reads CORS_ALLOWED_ORIGINS (comma-separated),
+// CORS_ALLOW_CREDENTIALS and CORS_MAX_AGE, keeping the defaults for unset or
+// invalid values.
+func CorsConfigFromEnv() CorsConfig {
+	cfg := DefaultCorsConfig()
+	if raw := os.Getenv("CORS_ALLOWED_ORIGINS"); raw != "" {
+		var origins []string
+		for _, origin := range strings.Split(raw, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				origins = append(origins, origin)
+			}
+		}
+		if len(origins) > 0 {
+			cfg.AllowedOrigins = origins
+		}
+	}
+	if allow, err := strconv.ParseBool(os.Getenv("CORS_ALLOW_CREDENTIALS")); err == nil {
+		cfg.AllowCredentials = allow
+	}
+	if d, err := time.ParseDuration(os.Getenv("CORS_MAX_AGE")); err == nil && d >= 0 {
+		cfg.MaxAge = d
+	}
+	return cfg
+}
+
+func CorsHeaderMiddleware() Middleware {
+	return CorsHeaderMiddlewareWithConfig(DefaultCorsConfig())
+}
+
+// CorsHeaderMiddlewareWithConfig sets the CORS headers of allowed origins and
+// answers preflight requests itself. It must come before HttpMethod in a
+// chain so that preflights are not rejected.
+func CorsHeaderMiddlewareWithConfig(cfg CorsConfig) Middleware {
+	anyOrigin := false
+	for _, origin := range cfg.AllowedOrigins {
+		anyOrigin = anyOrigin || origin == "*"
+	}
+	if anyOrigin && cfg.AllowCredentials {
+		slog.Warn("ignoring CORS credentials, which cannot be allowed for any origin; list the allowed origins instead")
+		cfg.AllowCredentials = false
+	}
+	allowMethods := strings.Join(cfg.AllowedMethods, ", ")
+	allowHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(f http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+			origin := r.Header.Get("Origin")
+
+			preflight := r.Method == http.MethodOptions && origin != "" && r.Header.Get("Access-Control-Request-Method") != ""
+			if preflight {
+				header.Add("Vary", "Origin")
+				header.Add("Vary", "Access-Control-Request-Method")
+				header.Add("Vary", "Access-Control-Request-Headers")
+				if cfg.allowsOrigin(origin) && cfg.allowsPreflight(r) {
+					cfg.setAllowOrigin(header, origin, anyOrigin)
+					header.Set("Access-Control-Allow-Methods", allowMethods)
+					if allowHeaders != "" {
+						header.Set("Access-Control-Allow-Headers", allowHeaders)
+					}
+					header.Set("Access-Control-Max-Age", maxAge)
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			if !anyOrigin {
+				header.Add("Vary", "Origin")
+			}
+			if origin == "" && anyOrigin {
+				header.Set("Access-Control-Allow-Origin", "*")
+			} else if origin != "" && cfg.allowsOrigin(origin) {
+				cfg.setAllowOrigin(header, origin, anyOrigin)
+				if exposeHeaders != "" {
+					header.Set("Access-Control-Expose-Headers", exposeHeaders)
+				}
+			}
+
+			f(w, r)
+		}
+	}
+}
+
+func (cfg CorsConfig) setAllowOrigin(header http.Header, origin string, anyOrigin bool) {
+	if anyOrigin {
+		header.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	if cfg.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (cfg CorsConfig) allowsOrigin(origin string) bool {
+	for _, allowed := range cfg.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+		if prefix, suffix, ok := strings.Cut(allowed, "*"); ok &&
+			len(origin) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsPreflight reports whether the method and headers a preflight asks
+// for are allowed. Header names are compared case-insensitively.
+func (cfg CorsConfig) allowsPreflight(r *http.Request) bool {
+	method := r.Header.Get("Access-Control-Request-Method")
+	allowed := false
+	for _, m := range cfg.AllowedMethods {
+		allowed = allowed || m == method
+	}
+	if !allowed {
+		return false
+	}
+
+	for _, requested := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		requested = strings.TrimSpace(requested)
+		if requested == "" {
+			continue
+		}
+		found := false
+		for _, h := range cfg.AllowedHeaders {
+			found = found || strings.EqualFold(h, requested)
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func corsRequest(method, origin string, header map[string]string) *http.Request {
+	req := httptest.NewRequest(method, "/bookcover", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	return req
+}
+
+func TestCors_PreflightIsAnsweredBeforeHttpMethod(t *testing.T) {
+	called := false
+	handler := Chain(func(w http.ResponseWriter, r *http.Request) { called = true },
+		CorsHeaderMiddleware(),
+		HttpMethod(http.MethodGet),
+	)
+
+	rr := httptest.NewRecorder()
+	handler(rr, corsRequest(http.MethodOptions, "https://app.example.com", map[string]string{
+		"Access-Control-Request-Method":  "GET",
+		"Access-Control-Request-Headers": "authorization, x-request-id",
+	}))
+
+	if called {
+		t.Error("handler should not be called for a preflight")
+	}
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rr.Code)
+	}
+	if origin := rr.Header().Get("Access-Control-Allow-Origin"); origin != "*" {
+		t.Errorf("expected Access-Control-Allow-Origin *, got %q", origin)
+	}
+	if methods := rr.Header().Get("Access-Control-Allow-Methods"); methods != "GET, HEAD, POST" {
+		t.Errorf("unexpected Access-Control-Allow-Methods %q", methods)
+	}
+	if headers := rr.Header().Get("Access-Control-Allow-Headers"); headers != "Authorization, Content-Type, If-None-Match, X-Request-ID" {
+		t.Errorf("unexpected Access-Control-Allow-Headers %q", headers)
+	}
+	if maxAge := rr.Header().Get("Access-Control-Max-Age"); maxAge != "600" {
+		t.Errorf("expected Access-Control-Max-Age 600, got %q", maxAge)
+	}
+}
+
+func TestCors_PreflightRejectsUnknownMethodOrHeader(t *testing.T) {
+	handler := CorsHeaderMiddleware()(okHandler)
+
+	for _, header := range []map[string]string{
+		{"Access-Control-Request-Method": "DELETE"},
+		{"Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "X-Custom"},
+	} {
+		rr := httptest.NewRecorder()
+		handler(rr, corsRequest(http.MethodOptions, "https://app.example.com", header))
+
+		if rr.Code != http.StatusNoContent {
+			t.Errorf("expected 204, got %d", rr.Code)
+		}
+		if origin := rr.Header().Get("Access-Control-Allow-Origin"); origin != "" {
+			t.Errorf("expected no Access-Control-Allow-Origin for %v, got %q", header, origin)
+		}
+	}
+}
+
+func TestCors_AllowedOrigins(t *testing.T) {
+	handler := CorsHeaderMiddlewareWithConfig(CorsConfig{
+		AllowedOrigins: []string{"https://bookshelf.example", "https://*.example.com"},
+		ExposedHeaders: []string{"X-RateLimit-Remaining-Daily"},
+	})(okHandler)
+
+	tests := []struct {
+		origin string
+		want   string
+	}{
+		{"https://bookshelf.example", "https://bookshelf.example"},
+		{"https://app.example.com", "https://app.example.com"},
+		{"https://example.com", ""},
+		{"https://app.example.com.evil.test", ""},
+		{"https://other.example", ""},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		handler(rr, corsRequest(http.MethodGet, tt.origin, nil))
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected the handler to run for %s, got %d", tt.origin, rr.Code)
+		}
+		if origin := rr.Header().Get("Access-Control-Allow-Origin"); origin != tt.want {
+			t.Errorf("expected Access-Control-Allow-Origin %q for %s, got %q", tt.want, tt.origin, origin)
+		}
+		if rr.Header().Get("Vary") != "Origin" {
+			t.Errorf("expected Vary: Origin, got %q", rr.Header().Get("Vary"))
+		}
+		exposed := rr.Header().Get("Access-Control-Expose-Headers")
+		if (tt.want != "") != (exposed == "X-RateLimit-Remaining-Daily") {
+			t.Errorf("unexpected Access-Control-Expose-Headers %q for %s", exposed, tt.origin)
+		}
+	}
+}
+
+func TestCors_Credentials(t *testing.T) {
+	handler := CorsHeaderMiddlewareWithConfig(CorsConfig{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		AllowedMethods:   []string{http.MethodGet},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	})(okHandler)
+
+	rr := httptest.NewRecorder()
+	handler(rr, corsRequest(http.MethodGet, "https://app.example.com", nil))
+	if origin := rr.Header().Get("Access-Control-Allow-Origin"); origin != "https://app.example.com" {
+		t.Errorf("expected the origin to be echoed with credentials, got %q", origin)
+	}
+	if rr.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Error("expected Access-Control-Allow-Credentials true")
+	}
+
+	rr = httptest.NewRecorder()
+	handler(rr, corsRequest(http.MethodOptions, "https://app.example.com", map[string]string{"Access-Control-Request-Method": "GET"}))
+	if rr.Header().Get("Access-Control-Allow-Credentials") != "true" || rr.Header().Get("Access-Control-Max-Age") != "3600" {
+		t.Errorf("unexpected preflight headers %v", rr.Header())
+	}
+}
+
+func TestCors_CredentialsIgnoredForAnyOrigin(t *testing.T) {
+	handler := CorsHeaderMiddlewareWithConfig(CorsConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "*"},
+		AllowedMethods:   []string{http.MethodGet},
+		AllowCredentials: true,
+	})(okHandler)
+
+	for _, req := range []*http.Request{
+		corsRequest(http.MethodGet, "https://evil.example", nil),
+		corsRequest(http.MethodOptions, "https://evil.example", map[string]string{"Access-Control-Request-Method": "GET"}),
+	} {
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		if origin := rr.Header().Get("Access-Control-Allow-Origin"); origin != "*" {
+			t.Errorf("%s: expected any origin without credentials, got %q", req.Method, origin)
+		}
+		if credentials := rr.Header().Get("Access-Control-Allow-Credentials"); credentials != "" {
+			t.Errorf("%s: expected no Access-Control-Allow-Credentials, got %q", req.Method, credentials)
+		}
+	}
+}
+
+func TestCorsConfigFromEnv(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://a.example, https://*.b.example")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	t.Setenv("CORS_MAX_AGE", "1h")
+
+	cfg := CorsConfigFromEnv()
+	if len(cfg.AllowedOrigins) != 2 || cfg.AllowedOrigins[1] != "https://*.b.example" {
+		t.Errorf("unexpected origins %q", cfg.AllowedOrigins)
+	}
+	if !cfg.AllowCredentials || cfg.MaxAge != time.Hour {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if len(cfg.ExposedHeaders) == 0 {
+		t.Error("expected the default exposed headers to be kept")
+	}
+}
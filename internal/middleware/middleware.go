@@ -1,9 +1,25 @@
 package middleware
 
-import "net/http"
+import (
+	"net/http"
+	"strings"
+
+	"bookcover-api/internal/config"
+	"bookcover-api/pkg/response"
+)
 
 type Middleware func(http.HandlerFunc) http.HandlerFunc
 
+// ErrorWriter writes the response to a request a middleware rejects, for
+// routes whose errors have a body of their own.
+type ErrorWriter func(w http.ResponseWriter, r *http.Request, statusCode int, message string)
+
+// WriteError writes the plain {"error": message} JSON body.
+func WriteError(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(response.Error(w, statusCode, message))
+}
+
 func Chain(f http.HandlerFunc, middlewares ...Middleware) http.HandlerFunc {
 	size := len(middlewares)
 	for i := 0; i < size; i++ {
@@ -12,15 +28,40 @@ func Chain(f http.HandlerFunc, middlewares ...Middleware) http.HandlerFunc {
 	return f
 }
 
-func HttpMethod(method string) Middleware {
+// HttpMethod lets requests with one of methods through, and HEAD requests
+// too when GET is allowed. Other methods get a JSON 405 listing the allowed
+// ones in the Allow header.
+func HttpMethod(methods ...string) Middleware {
+	return HttpMethodWithErrors(WriteError, methods...)
+}
+
+// HttpMethodWithErrors is HttpMethod with the 405 written by writeError.
+func HttpMethodWithErrors(writeError ErrorWriter, methods ...string) Middleware {
+	allowed := make(map[string]bool)
+	var list []string
+	add := func(method string) {
+		if !allowed[method] {
+			allowed[method] = true
+			list = append(list, method)
+		}
+	}
+	for _, method := range methods {
+		add(method)
+		if method == http.MethodGet {
+			add(http.MethodHead)
+		}
+	}
+	allow := strings.Join(list, ", ")
+
 	return func(f http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			switch r.Method {
-			case method:
+			if allowed[r.Method] {
 				f(w, r)
-			default:
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
 			}
+
+			w.Header().Set("Allow", allow)
+			writeError(w, r, http.StatusMethodNotAllowed, config.MethodNotAllowed)
 		}
 	}
 }
@@ -33,12 +74,3 @@ func JsonHeaderMiddleware() Middleware {
 		}
 	}
 }
-
-func CorsHeaderMiddleware() Middleware {
-	return func(f http.HandlerFunc) http.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			f(w, r)
-		}
-	}
-}
@@ -114,6 +114,59 @@ func TestHttpMethod_DisallowedMethod(t *testing.T) {
 	if rr.Code != http.StatusMethodNotAllowed {
 		t.Errorf("expected 405, got %d", rr.Code)
 	}
+	if allow := rr.Header().Get("Allow"); allow != "GET, HEAD" {
+		t.Errorf("expected Allow GET, HEAD, got %q", allow)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %q", ct)
+	}
+	if body := rr.Body.String(); body != `{"error":"Method not allowed."}` {
+		t.Errorf("unexpected body %s", body)
+	}
+}
+
+func TestHttpMethod_HeadAllowedForGet(t *testing.T) {
+	called := false
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}
+
+	mw := HttpMethod(http.MethodGet)(handler)
+	mw(httptest.NewRecorder(), httptest.NewRequest(http.MethodHead, "/", nil))
+	if !called {
+		t.Error("expected handler to be called for HEAD on a GET route")
+	}
+
+	called = false
+	rr := httptest.NewRecorder()
+	HttpMethod(http.MethodPost)(handler)(rr, httptest.NewRequest(http.MethodHead, "/", nil))
+	if called || rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for HEAD on a POST route, got %d", rr.Code)
+	}
+}
+
+func TestHttpMethod_MultipleMethods(t *testing.T) {
+	var calls []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method)
+	}
+
+	mw := HttpMethod(http.MethodGet, http.MethodPost)(handler)
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodHead} {
+		mw(httptest.NewRecorder(), httptest.NewRequest(method, "/", nil))
+	}
+	if len(calls) != 3 {
+		t.Errorf("expected GET, POST and HEAD to be allowed, got %v", calls)
+	}
+
+	rr := httptest.NewRecorder()
+	mw(rr, httptest.NewRequest(http.MethodDelete, "/", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rr.Code)
+	}
+	if allow := rr.Header().Get("Allow"); allow != "GET, HEAD, POST" {
+		t.Errorf("expected Allow GET, HEAD, POST, got %q", allow)
+	}
 }
 
 func TestHttpMethod_PostAllowed(t *testing.T) {
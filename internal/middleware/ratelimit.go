@@ -7,7 +7,7 @@ import (
 	"strconv"
 
 	"bookcover-api/internal/cache"
-	"bookcover-api/pkg/response"
+	"bookcover-api/internal/config"
 
 	"github.com/bradfitz/gomemcache/memcache"
 )
@@ -23,6 +23,11 @@ type RateLimitConfig struct {
 	DailyLimit   int
 	MonthlyLimit int
 	Unlimited    bool
+	// Cost returns how much quota a request uses. Defaults to 1 per request.
+	Cost func(r *http.Request) int
+	// WriteError writes the 429 of a request over the limit. Defaults to
+	// WriteError.
+	WriteError ErrorWriter
 }
 
 var (
@@ -39,6 +44,14 @@ func RateLimitMiddleware(cacheClient cache.CacheClient) Middleware {
 	return RateLimitMiddlewareWithConfig(cacheClient, ProTier)
 }
 
+// RateLimitMiddlewareWithCost charges each request the quota returned by cost,
+// for endpoints that do the work of several lookups at once.
+func RateLimitMiddlewareWithCost(cacheClient cache.CacheClient, cost func(r *http.Request) int) Middleware {
+	cfg := ProTier
+	cfg.Cost = cost
+	return RateLimitMiddlewareWithConfig(cacheClient, cfg)
+}
+
 func RateLimitMiddlewareWithConfig(cacheClient cache.CacheClient, cfg RateLimitConfig) Middleware {
 	return func(f http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
@@ -49,30 +62,28 @@ func RateLimitMiddlewareWithConfig(cacheClient cache.CacheClient, cfg RateLimitC
 				return
 			}
 
-			ip := getClientIP(r)
-
-			dailyKey := fmt.Sprintf("ratelimit:%s:daily", ip)
-			monthlyKey := fmt.Sprintf("ratelimit:%s:monthly", ip)
-
-			dailyCount, err := incrementCounter(cacheClient, dailyKey, dailyTTL)
-			if err != nil {
-				f(w, r)
-				return
+			cost := 1
+			if activeCfg.Cost != nil {
+				cost = activeCfg.Cost(r)
 			}
 
-			monthlyCount, err := incrementCounter(cacheClient, monthlyKey, monthlyTTL)
+			quota, err := ChargeQuota(cacheClient, activeCfg, ClientIP(r), cost)
 			if err != nil {
 				f(w, r)
 				return
 			}
 
-			w.Header().Set("X-RateLimit-Limit-Daily", strconv.Itoa(activeCfg.DailyLimit))
-			w.Header().Set("X-RateLimit-Remaining-Daily", strconv.Itoa(max(0, activeCfg.DailyLimit-int(dailyCount))))
-			w.Header().Set("X-RateLimit-Limit-Monthly", strconv.Itoa(activeCfg.MonthlyLimit))
-			w.Header().Set("X-RateLimit-Remaining-Monthly", strconv.Itoa(max(0, activeCfg.MonthlyLimit-int(monthlyCount))))
-
-			if int(dailyCount) > activeCfg.DailyLimit || int(monthlyCount) > activeCfg.MonthlyLimit {
-				w.Write(response.Error(w, http.StatusTooManyRequests, "Rate limit exceeded"))
+			w.Header().Set("X-RateLimit-Limit-Daily", strconv.Itoa(quota.DailyLimit))
+			w.Header().Set("X-RateLimit-Remaining-Daily", strconv.Itoa(quota.DailyRemaining))
+			w.Header().Set("X-RateLimit-Limit-Monthly", strconv.Itoa(quota.MonthlyLimit))
+			w.Header().Set("X-RateLimit-Remaining-Monthly", strconv.Itoa(quota.MonthlyRemaining))
+
+			if quota.Exceeded {
+				writeError := activeCfg.WriteError
+				if writeError == nil {
+					writeError = WriteError
+				}
+				writeError(w, r, http.StatusTooManyRequests, config.RateLimitExceeded)
 				return
 			}
 
@@ -81,27 +92,64 @@ func RateLimitMiddlewareWithConfig(cacheClient cache.CacheClient, cfg RateLimitC
 	}
 }
 
-func incrementCounter(c cache.CacheClient, key string, ttl int32) (uint64, error) {
-	newVal, err := c.Increment(key, 1)
+// Quota is the state of a client's counters once a request was charged.
+type Quota struct {
+	DailyLimit       int
+	DailyRemaining   int
+	MonthlyLimit     int
+	MonthlyRemaining int
+	// Exceeded is set when the request went over either limit.
+	Exceeded bool
+}
+
+// ChargeQuota adds cost, at least 1, to the daily and monthly counters of
+// client. The error is set when the counters could not be updated, in which
+// case the request should be let through.
+func ChargeQuota(cacheClient cache.CacheClient, cfg RateLimitConfig, client string, cost int) (Quota, error) {
+	dailyKey := fmt.Sprintf("ratelimit:%s:daily", client)
+	monthlyKey := fmt.Sprintf("ratelimit:%s:monthly", client)
+	delta := uint64(max(1, cost))
+
+	dailyCount, err := incrementCounter(cacheClient, dailyKey, delta, dailyTTL)
+	if err != nil {
+		return Quota{}, err
+	}
+	monthlyCount, err := incrementCounter(cacheClient, monthlyKey, delta, monthlyTTL)
+	if err != nil {
+		return Quota{}, err
+	}
+
+	return Quota{
+		DailyLimit:       cfg.DailyLimit,
+		DailyRemaining:   max(0, cfg.DailyLimit-int(dailyCount)),
+		MonthlyLimit:     cfg.MonthlyLimit,
+		MonthlyRemaining: max(0, cfg.MonthlyLimit-int(monthlyCount)),
+		Exceeded:         int(dailyCount) > cfg.DailyLimit || int(monthlyCount) > cfg.MonthlyLimit,
+	}, nil
+}
+
+func incrementCounter(c cache.CacheClient, key string, delta uint64, ttl int32) (uint64, error) {
+	newVal, err := c.Increment(key, delta)
 	if err == memcache.ErrCacheMiss {
 		err = c.Add(&memcache.Item{
 			Key:        key,
-			Value:      []byte("1"),
+			Value:      []byte(strconv.FormatUint(delta, 10)),
 			Expiration: ttl,
 		})
 		if err == memcache.ErrNotStored {
 			// Another request created it; retry increment
-			return c.Increment(key, 1)
+			return c.Increment(key, delta)
 		}
 		if err != nil {
 			return 0, err
 		}
-		return 1, nil
+		return delta, nil
 	}
 	return newVal, err
 }
 
-func getClientIP(r *http.Request) string {
+// ClientIP returns the address whose counters a request is charged to.
+func ClientIP(r *http.Request) string {
 	if cfIP := r.Header.Get("CF-Connecting-IP"); cfIP != "" {
 		return cfIP
 	}
@@ -206,3 +206,35 @@ func TestRateLimit_CounterTTL(t *testing.T) {
 		t.Errorf("monthly TTL: expected %d, got %d", monthlyTTL, monthlyItem.Expiration)
 	}
 }
+
+func TestRateLimit_ChargesCost(t *testing.T) {
+	mc := newMockCache()
+	cfg := RateLimitConfig{
+		DailyLimit:   5,
+		MonthlyLimit: 1000,
+		Cost:         func(r *http.Request) int { return 3 },
+	}
+	mw := RateLimitMiddlewareWithConfig(mc, cfg)(okHandler)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/bookcover/batch", nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	mw(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("X-RateLimit-Remaining-Daily"); got != "2" {
+		t.Errorf("expected remaining 2, got %s", got)
+	}
+
+	// The second batch needs 3 more units but only 2 are left
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/bookcover/batch", nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	mw(rr, req)
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestID_Generated(t *testing.T) {
+	var seen string
+	handler := RequestID()(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	})
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if seen == "" || len(seen) != 32 {
+		t.Errorf("expected a generated 32 character ID, got %q", seen)
+	}
+	if got := rr.Header().Get(RequestIDHeader); got != seen {
+		t.Errorf("expected %s header %q, got %q", RequestIDHeader, seen, got)
+	}
+}
+
+func TestRequestID_KeepsClientID(t *testing.T) {
+	tests := []struct {
+		name string
+		sent string
+		kept bool
+	}{
+		{"valid", "abc-123_x.y", true},
+		{"invalid characters", "abc 123", false},
+		{"too long", string(make([]byte, maxRequestIDLength+1)), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(RequestIDHeader, tt.sent)
+			rr := httptest.NewRecorder()
+			RequestID()(func(w http.ResponseWriter, r *http.Request) {})(rr, req)
+
+			if kept := rr.Header().Get(RequestIDHeader) == tt.sent; kept != tt.kept {
+				t.Errorf("request ID %q kept = %v, want %v", tt.sent, kept, tt.kept)
+			}
+		})
+	}
+}
+
+func TestRequestIDFromContext_Missing(t *testing.T) {
+	if id := RequestIDFromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()); id != "" {
+		t.Errorf("expected no request ID, got %q", id)
+	}
+}
@@ -0,0 +1,280 @@
+package placeholder
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	DefaultWidth  = 400
+	DefaultHeight = 600
+
+	maxTitleLines  = 4
+	maxAuthorLines = 2
+	ellipsis       = "…"
+	// averageCharWidth approximates the advance of a character in em, used to
+	// wrap SVG text without access to the client's font metrics.
+	averageCharWidth = 0.55
+)
+
+// Cover describes a generated cover. Seed picks the color, so the same book
+// always gets the same cover.
+type Cover struct {
+	Title  string
+	Author string
+	Seed   string
+	Width  int
+	Height int
+}
+
+// layout holds the positions shared by the SVG and raster renderers.
+type layout struct {
+	width, height   int
+	margin, spine   int
+	titleSize       float64
+	authorSize      float64
+	titleTop        float64
+	authorBottom    float64
+	background, ink color.RGBA
+	spineColor      color.RGBA
+	maxTextWidth    float64
+}
+
+func (c Cover) layout() layout {
+	width, height := c.Width, c.Height
+	switch {
+	case width <= 0 && height <= 0:
+		width, height = DefaultWidth, DefaultHeight
+	case width <= 0:
+		width = height * 2 / 3
+	case height <= 0:
+		height = width * 3 / 2
+	}
+	width, height = max(width, 1), max(height, 1)
+
+	hue := float64(hash(c.Seed) % 360)
+	margin := width / 10
+	spine := width / 20
+	return layout{
+		width:        width,
+		height:       height,
+		margin:       margin,
+		spine:        spine,
+		titleSize:    float64(width) / 10,
+		authorSize:   float64(width) / 16,
+		titleTop:     float64(height) * 0.2,
+		authorBottom: float64(height - margin),
+		background:   hsl(hue, 0.45, 0.38),
+		spineColor:   hsl(hue, 0.45, 0.28),
+		ink:          color.RGBA{R: 255, G: 255, B: 255, A: 255},
+		maxTextWidth: float64(width - 2*margin - spine),
+	}
+}
+
+// SVG renders the cover as an SVG document.
+func (c Cover) SVG() []byte {
+	l := c.layout()
+	estimate := func(size float64) func(string) float64 {
+		return func(s string) float64 {
+			return float64(len([]rune(s))) * size * averageCharWidth
+		}
+	}
+	titleLines := wrap(c.Title, l.maxTextWidth, maxTitleLines, estimate(l.titleSize))
+	authorLines := wrap(c.Author, l.maxTextWidth, maxAuthorLines, estimate(l.authorSize))
+	center := float64(l.spine) + float64(l.width-l.spine)/2
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, l.width, l.height, l.width, l.height)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="%s"/>`, hex(l.background))
+	fmt.Fprintf(&buf, `<rect width="%d" height="100%%" fill="%s"/>`, l.spine, hex(l.spineColor))
+	buf.WriteString(`<g font-family="Go, Helvetica, Arial, sans-serif" text-anchor="middle" fill="` + hex(l.ink) + `">`)
+	for i, line := range titleLines {
+		y := l.titleTop + l.titleSize*(1+1.2*float64(i))
+		fmt.Fprintf(&buf, `<text x="%.1f" y="%.1f" font-size="%.1f" font-weight="bold">%s</text>`, center, y, l.titleSize, html.EscapeString(line))
+	}
+	for i, line := range authorLines {
+		y := l.authorBottom - l.authorSize*1.2*float64(len(authorLines)-1-i)
+		fmt.Fprintf(&buf, `<text x="%.1f" y="%.1f" font-size="%.1f">%s</text>`, center, y, l.authorSize, html.EscapeString(line))
+	}
+	buf.WriteString(`</g></svg>`)
+	return buf.Bytes()
+}
+
+// Image renders the cover as a raster image.
+func (c Cover) Image() image.Image {
+	l := c.layout()
+	dst := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(l.background), image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(0, 0, l.spine, l.height), image.NewUniform(l.spineColor), image.Point{}, draw.Src)
+
+	titleFace := newFace(boldFont(), l.titleSize)
+	defer titleFace.Close()
+	authorFace := newFace(regularFont(), l.authorSize)
+	defer authorFace.Close()
+
+	titleLines := wrap(c.Title, l.maxTextWidth, maxTitleLines, measure(titleFace))
+	for i, line := range titleLines {
+		y := l.titleTop + l.titleSize*(1+1.2*float64(i))
+		drawCentered(dst, titleFace, line, l, y)
+	}
+	authorLines := wrap(c.Author, l.maxTextWidth, maxAuthorLines, measure(authorFace))
+	for i, line := range authorLines {
+		y := l.authorBottom - l.authorSize*1.2*float64(len(authorLines)-1-i)
+		drawCentered(dst, authorFace, line, l, y)
+	}
+	return dst
+}
+
+func drawCentered(dst draw.Image, face font.Face, text string, l layout, baseline float64) {
+	d := font.Drawer{Dst: dst, Src: image.NewUniform(l.ink), Face: face}
+	width := d.MeasureString(text)
+	center := fixed.I(l.spine + (l.width-l.spine)/2)
+	d.Dot = fixed.Point26_6{X: center - width/2, Y: fixed.Int26_6(baseline * 64)}
+	d.DrawString(text)
+}
+
+// wrap breaks text into at most maxLines lines no wider than maxWidth. Words
+// that do not fit on a line of their own are split, and the last line is
+// ellipsized when the text does not fit.
+func wrap(text string, maxWidth float64, maxLines int, measure func(string) float64) []string {
+	var lines []string
+	line := ""
+	words := strings.Fields(text)
+	for len(words) > 0 && len(lines) < maxLines {
+		candidate := words[0]
+		if line != "" {
+			candidate = line + " " + words[0]
+		}
+		if measure(candidate) <= maxWidth {
+			line = candidate
+			words = words[1:]
+			continue
+		}
+
+		if line == "" {
+			head := fitPrefix(words[0], maxWidth, measure)
+			line = head
+			words[0] = words[0][len(head):]
+		}
+		lines = append(lines, line)
+		line = ""
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	if len(words) > 0 && len(lines) > 0 {
+		last := len(lines) - 1
+		lines[last] = ellipsize(lines[last], maxWidth, measure)
+	}
+	return lines
+}
+
+// fitPrefix returns the longest prefix of word that fits, and at least one rune.
+func fitPrefix(word string, maxWidth float64, measure func(string) float64) string {
+	runes := []rune(word)
+	n := 1
+	for n < len(runes) && measure(string(runes[:n+1])) <= maxWidth {
+		n++
+	}
+	return string(runes[:n])
+}
+
+func ellipsize(line string, maxWidth float64, measure func(string) float64) string {
+	runes := []rune(line)
+	for len(runes) > 0 && measure(string(runes)+ellipsis) > maxWidth {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimRight(string(runes), " ") + ellipsis
+}
+
+func measure(face font.Face) func(string) float64 {
+	return func(s string) float64 {
+		return float64(font.MeasureString(face, s)) / 64
+	}
+}
+
+var (
+	fontsOnce sync.Once
+	regular   *opentype.Font
+	bold      *opentype.Font
+)
+
+func loadFonts() {
+	fontsOnce.Do(func() {
+		regular, _ = opentype.Parse(goregular.TTF)
+		bold, _ = opentype.Parse(gobold.TTF)
+	})
+}
+
+func regularFont() *opentype.Font {
+	loadFonts()
+	return regular
+}
+
+func boldFont() *opentype.Font {
+	loadFonts()
+	return bold
+}
+
+func newFace(f *opentype.Font, size float64) font.Face {
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		// Only possible with invalid options, which we never pass.
+		panic(err)
+	}
+	return face
+}
+
+func hash(seed string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(seed))
+	return h.Sum32()
+}
+
+// hsl converts a hue in degrees, saturation and lightness to RGB.
+func hsl(hue, saturation, lightness float64) color.RGBA {
+	chroma := (1 - math.Abs(2*lightness-1)) * saturation
+	x := chroma * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+	m := lightness - chroma/2
+
+	var r, g, b float64
+	switch {
+	case hue < 60:
+		r, g, b = chroma, x, 0
+	case hue < 120:
+		r, g, b = x, chroma, 0
+	case hue < 180:
+		r, g, b = 0, chroma, x
+	case hue < 240:
+		r, g, b = 0, x, chroma
+	case hue < 300:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+	return color.RGBA{
+		R: uint8(math.Round((r + m) * 255)),
+		G: uint8(math.Round((g + m) * 255)),
+		B: uint8(math.Round((b + m) * 255)),
+		A: 255,
+	}
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
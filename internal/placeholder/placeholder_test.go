@@ -0,0 +1,87 @@
+package placeholder
+
+import (
+	"strings"
+	"testing"
+)
+
+// monospace measures every rune as 10 pixels wide.
+func monospace(s string) float64 {
+	return float64(len([]rune(s))) * 10
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxLines int
+		expected []string
+	}{
+		{"fits on one line", "Dune", 3, []string{"Dune"}},
+		{"wraps on words", "The Pale Blue Dot", 3, []string{"The Pale", "Blue Dot"}},
+		{"splits long words", "Supercalifragilistic", 3, []string{"Supercalif", "ragilistic"}},
+		{"ellipsizes overflow", "One Two Three Four Five Six", 2, []string{"One Two", "Three Fou…"}},
+		{"empty", "", 3, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrap(tt.text, 100, tt.maxLines, monospace)
+			if strings.Join(got, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("wrap(%q) = %q, want %q", tt.text, got, tt.expected)
+			}
+			for _, line := range got {
+				if monospace(line) > 100 {
+					t.Errorf("wrap(%q) produced line %q wider than 100", tt.text, line)
+				}
+			}
+		})
+	}
+}
+
+func TestCover_ColorIsDeterministic(t *testing.T) {
+	a := Cover{Seed: "9780345376596"}.layout()
+	b := Cover{Seed: "9780345376596"}.layout()
+	c := Cover{Seed: "9780000000000"}.layout()
+
+	if a.background != b.background {
+		t.Errorf("expected the same seed to give the same color, got %v and %v", a.background, b.background)
+	}
+	if a.background == c.background {
+		t.Errorf("expected different seeds to give different colors, both got %v", a.background)
+	}
+}
+
+func TestCover_SVG(t *testing.T) {
+	svg := string(Cover{Title: "Tom & Jerry <3", Author: "Someone", Seed: "x"}.SVG())
+
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>") {
+		t.Errorf("expected an SVG document, got %q", svg)
+	}
+	if !strings.Contains(svg, ">Tom &amp; Jerry<") || !strings.Contains(svg, ">&lt;3<") {
+		t.Errorf("expected title to be escaped, got %q", svg)
+	}
+	if !strings.Contains(svg, `width="400" height="600"`) {
+		t.Errorf("expected default 400x600 size, got %q", svg)
+	}
+}
+
+func TestCover_ImageSize(t *testing.T) {
+	tests := []struct {
+		cover          Cover
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{Cover{}, DefaultWidth, DefaultHeight},
+		{Cover{Width: 200}, 200, 300},
+		{Cover{Height: 75}, 50, 75},
+		{Cover{Width: 300, Height: 300}, 300, 300},
+	}
+
+	for _, tt := range tests {
+		bounds := tt.cover.Image().Bounds()
+		if bounds.Dx() != tt.expectedWidth || bounds.Dy() != tt.expectedHeight {
+			t.Errorf("Image() for %+v = %dx%d, want %dx%d", tt.cover, bounds.Dx(), bounds.Dy(), tt.expectedWidth, tt.expectedHeight)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"bookcover-api/internal/metrics"
+)
+
+type BreakerState int
+
+const (
+	StateClosed BreakerState = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit.
+	FailureThreshold int
+	// CoolDown is how long the circuit stays open before letting a probe through.
+	CoolDown time.Duration
+	// HalfOpenMaxRequests limits concurrent probes while half-open.
+	HalfOpenMaxRequests int
+	// SuccessThreshold is the number of successful probes needed to close the circuit.
+	SuccessThreshold int
+}
+
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold:    5,
+		CoolDown:            30 * time.Second,
+		HalfOpenMaxRequests: 1,
+		SuccessThreshold:    1,
+	}
+}
+
+// BreakerConfigFromEnv returns the default config with overrides taken from
+// SCRAPER_BREAKER_FAILURE_THRESHOLD, SCRAPER_BREAKER_COOLDOWN,
+// SCRAPER_BREAKER_HALF_OPEN_REQUESTS and SCRAPER_BREAKER_SUCCESS_THRESHOLD.
+func BreakerConfigFromEnv() BreakerConfig {
+	cfg := DefaultBreakerConfig()
+	if n, err := strconv.Atoi(os.Getenv("SCRAPER_BREAKER_FAILURE_THRESHOLD")); err == nil && n > 0 {
+		cfg.FailureThreshold = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("SCRAPER_BREAKER_COOLDOWN")); err == nil && d > 0 {
+		cfg.CoolDown = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("SCRAPER_BREAKER_HALF_OPEN_REQUESTS")); err == nil && n > 0 {
+		cfg.HalfOpenMaxRequests = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("SCRAPER_BREAKER_SUCCESS_THRESHOLD")); err == nil && n > 0 {
+		cfg.SuccessThreshold = n
+	}
+	return cfg
+}
+
+// CircuitBreaker wraps a Scraper and short-circuits calls while the upstream is failing.
+type CircuitBreaker struct {
+	scraper Scraper
+	cfg     BreakerConfig
+	now     func() time.Time
+
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	successes int
+	probes    int
+	openedAt  time.Time
+	// generation counts state changes, so that outcomes can be matched to the
+	// state their call was admitted in.
+	generation int
+}
+
+func NewCircuitBreaker(s Scraper, cfg BreakerConfig) *CircuitBreaker {
+	b := &CircuitBreaker{
+		scraper: s,
+		cfg:     cfg,
+		now:     time.Now,
+	}
+	metrics.SetCircuitBreakerState(s.Name(), int(StateClosed))
+	return b
+}
+
+func (b *CircuitBreaker) Name() string {
+	return b.scraper.Name()
+}
+
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState()
+}
+
+func (b *CircuitBreaker) FetchByTitleAuthor(ctx context.Context, bookTitle, authorName string) (string, error) {
+	return b.call(ctx, func() (string, error) {
+		return b.scraper.FetchByTitleAuthor(ctx, bookTitle, authorName)
+	})
+}
+
+func (b *CircuitBreaker) FetchByISBN(ctx context.Context, isbn string) (string, error) {
+	return b.call(ctx, func() (string, error) {
+		return b.scraper.FetchByISBN(ctx, isbn)
+	})
+}
+
+func (b *CircuitBreaker) call(ctx context.Context, fetch func() (string, error)) (string, error) {
+	ticket, err := b.allow()
+	if err != nil {
+		return "", err
+	}
+
+	url, err := fetch()
+	if ctx.Err() != nil || isLocalFailure(err) {
+		// The caller gave up, or the request never left, which says nothing
+		// about the provider.
+		b.release(ticket)
+		return url, err
+	}
+	b.record(ticket, isProviderFailure(err))
+	return url, err
+}
+
+// admission records the state a call was let through in. generation tells
+// apart the successive times the circuit was in that state.
+type admission struct {
+	state      BreakerState
+	generation int
+}
+
+func (b *CircuitBreaker) allow() (admission, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ticket := admission{state: b.currentState(), generation: b.generation}
+	switch ticket.state {
+	case StateOpen:
+		return ticket, fmt.Errorf("%s: %w", b.Name(), ErrCircuitOpen)
+	case StateHalfOpen:
+		if b.probes >= b.cfg.HalfOpenMaxRequests {
+			return ticket, fmt.Errorf("%s: %w", b.Name(), ErrCircuitOpen)
+		}
+		b.probes++
+	}
+	return ticket, nil
+}
+
+// release gives back the probe slot of a call whose outcome is not recorded.
+func (b *CircuitBreaker) release(ticket admission) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.releaseProbe(ticket)
+}
+
+// record applies the outcome of a call. Outcomes of calls admitted before the
+// circuit last changed state are ignored: a slow call let through while
+// closed says nothing about a later half-open probe.
+func (b *CircuitBreaker) record(ticket admission, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.releaseProbe(ticket)
+	if ticket.generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case StateHalfOpen:
+		if failed {
+			b.setState(StateOpen)
+			return
+		}
+		b.successes++
+		if b.successes >= b.cfg.SuccessThreshold {
+			b.setState(StateClosed)
+		}
+	case StateClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.setState(StateOpen)
+		}
+	}
+}
+
+// releaseProbe frees the slot a half-open probe held, unless the circuit has
+// left that half-open state since. Callers must hold b.mu.
+func (b *CircuitBreaker) releaseProbe(ticket admission) {
+	if ticket.state == StateHalfOpen && ticket.generation == b.generation {
+		b.probes--
+	}
+}
+
+// currentState moves an open circuit to half-open once the cool-down has elapsed.
+// Callers must hold b.mu.
+func (b *CircuitBreaker) currentState() BreakerState {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.cfg.CoolDown {
+		b.setState(StateHalfOpen)
+	}
+	return b.state
+}
+
+// setState must be called with b.mu held.
+func (b *CircuitBreaker) setState(state BreakerState) {
+	b.state = state
+	b.generation++
+	b.failures = 0
+	b.successes = 0
+	b.probes = 0
+	if state == StateOpen {
+		b.openedAt = b.now()
+	}
+	metrics.SetCircuitBreakerState(b.Name(), int(state))
+}
+
+// isProviderFailure reports whether err says something bad about the
+// provider's health. A book that simply has no cover is a healthy answer.
+func isProviderFailure(err error) bool {
+	return err != nil && !errors.Is(err, ErrNotFound)
+}
+
+// isLocalFailure reports whether err means the request was never sent, because
+// our own outbound budget or egress proxies ran out. It says nothing about the
+// upstream either way.
+func isLocalFailure(err error) bool {
+	return errors.Is(err, ErrBudgetExhausted) || errors.Is(err, ErrNoProxyAvailable)
+}
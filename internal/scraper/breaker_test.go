@@ -0,0 +1,223 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type stubScraper struct {
+	err   error
+	calls int
+}
+
+func (s *stubScraper) Name() string {
+	return "stub"
+}
+
+func (s *stubScraper) FetchByTitleAuthor(ctx context.Context, bookTitle, authorName string) (string, error) {
+	return s.FetchByISBN(ctx, "")
+}
+
+func (s *stubScraper) FetchByISBN(ctx context.Context, isbn string) (string, error) {
+	s.calls++
+	if s.err != nil {
+		return "", s.err
+	}
+	return "https://example.com/cover.jpg", nil
+}
+
+func newTestBreaker(s Scraper, now *time.Time) *CircuitBreaker {
+	b := NewCircuitBreaker(s, BreakerConfig{
+		FailureThreshold:    2,
+		CoolDown:            time.Minute,
+		HalfOpenMaxRequests: 1,
+		SuccessThreshold:    1,
+	})
+	b.now = func() time.Time { return *now }
+	return b
+}
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	now := time.Now()
+	stub := &stubScraper{err: errors.New("upstream down")}
+	b := newTestBreaker(stub, &now)
+
+	b.FetchByISBN(context.Background(), "1")
+	b.FetchByISBN(context.Background(), "1")
+	if b.State() != StateOpen {
+		t.Fatalf("expected state open, got %s", b.State())
+	}
+
+	_, err := b.FetchByISBN(context.Background(), "1")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if stub.calls != 2 {
+		t.Errorf("expected upstream to be called twice, got %d", stub.calls)
+	}
+}
+
+func TestCircuitBreaker_NotFoundIsNotAFailure(t *testing.T) {
+	now := time.Now()
+	stub := &stubScraper{err: ErrNotFound}
+	b := newTestBreaker(stub, &now)
+
+	for i := 0; i < 5; i++ {
+		b.FetchByISBN(context.Background(), "1")
+	}
+	if b.State() != StateClosed {
+		t.Errorf("expected state closed, got %s", b.State())
+	}
+}
+
+func TestCircuitBreaker_CanceledCallIsNotAFailure(t *testing.T) {
+	now := time.Now()
+	stub := &stubScraper{err: context.Canceled}
+	b := newTestBreaker(stub, &now)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 5; i++ {
+		b.FetchByISBN(ctx, "1")
+	}
+	if b.State() != StateClosed {
+		t.Errorf("expected state closed, got %s", b.State())
+	}
+}
+
+func TestCircuitBreaker_NoProxyIsNotAnOutcome(t *testing.T) {
+	now := time.Now()
+	stub := &stubScraper{err: ErrNoProxyAvailable}
+	b := newTestBreaker(stub, &now)
+
+	for i := 0; i < 5; i++ {
+		b.FetchByISBN(context.Background(), "1")
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("expected state closed, got %s", b.State())
+	}
+
+	// Nor does it count as a successful half-open probe.
+	stub.err = errors.New("upstream down")
+	b.FetchByISBN(context.Background(), "1")
+	b.FetchByISBN(context.Background(), "1")
+	now = now.Add(2 * time.Minute)
+	stub.err = ErrNoProxyAvailable
+	b.FetchByISBN(context.Background(), "1")
+	if b.State() != StateHalfOpen {
+		t.Errorf("expected state to stay half-open, got %s", b.State())
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	now := time.Now()
+	stub := &stubScraper{err: errors.New("upstream down")}
+	b := newTestBreaker(stub, &now)
+
+	b.FetchByISBN(context.Background(), "1")
+	b.FetchByISBN(context.Background(), "1")
+
+	now = now.Add(2 * time.Minute)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("expected state half-open after cool-down, got %s", b.State())
+	}
+
+	// A failed probe re-opens the circuit.
+	b.FetchByISBN(context.Background(), "1")
+	if b.State() != StateOpen {
+		t.Fatalf("expected state open after failed probe, got %s", b.State())
+	}
+
+	// A successful probe closes it.
+	now = now.Add(2 * time.Minute)
+	stub.err = nil
+	if _, err := b.FetchByISBN(context.Background(), "1"); err != nil {
+		t.Fatalf("unexpected error on probe: %v", err)
+	}
+	if b.State() != StateClosed {
+		t.Errorf("expected state closed after successful probe, got %s", b.State())
+	}
+}
+
+// blockingScraper blocks each lookup until an error, or nil, is sent on the
+// release channel of its ISBN.
+type blockingScraper struct {
+	started chan struct{}
+	release map[string]chan error
+}
+
+func (s *blockingScraper) Name() string { return "blocking" }
+
+func (s *blockingScraper) FetchByTitleAuthor(ctx context.Context, bookTitle, authorName string) (string, error) {
+	return s.FetchByISBN(ctx, bookTitle)
+}
+
+func (s *blockingScraper) FetchByISBN(ctx context.Context, isbn string) (string, error) {
+	s.started <- struct{}{}
+	return "", <-s.release[isbn]
+}
+
+func TestCircuitBreaker_IgnoresOutcomesFromEarlierState(t *testing.T) {
+	var mu sync.Mutex
+	now := time.Now()
+	stub := &blockingScraper{
+		started: make(chan struct{}),
+		release: map[string]chan error{"slow": make(chan error), "fast": make(chan error)},
+	}
+	b := NewCircuitBreaker(stub, BreakerConfig{FailureThreshold: 2, CoolDown: time.Minute, HalfOpenMaxRequests: 1, SuccessThreshold: 1})
+	b.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	fetch := func(isbn string) chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			b.FetchByISBN(context.Background(), isbn)
+			close(done)
+		}()
+		<-stub.started
+		return done
+	}
+
+	// A slow call is admitted while closed, then the circuit opens and
+	// goes half-open while it is still running.
+	slow := fetch("slow")
+	for i := 0; i < 2; i++ {
+		done := fetch("fast")
+		stub.release["fast"] <- errors.New("upstream down")
+		<-done
+	}
+	if b.State() != StateOpen {
+		t.Fatalf("expected state open, got %s", b.State())
+	}
+	mu.Lock()
+	now = now.Add(2 * time.Minute)
+	mu.Unlock()
+	if b.State() != StateHalfOpen {
+		t.Fatalf("expected state half-open after cool-down, got %s", b.State())
+	}
+
+	// The slow call succeeding must not count as a half-open probe.
+	stub.release["slow"] <- nil
+	<-slow
+	if b.State() != StateHalfOpen {
+		t.Errorf("expected state to stay half-open, got %s", b.State())
+	}
+}
+
+func TestBreakerConfigFromEnv(t *testing.T) {
+	t.Setenv("SCRAPER_BREAKER_FAILURE_THRESHOLD", "10")
+	t.Setenv("SCRAPER_BREAKER_COOLDOWN", "2m")
+	t.Setenv("SCRAPER_BREAKER_HALF_OPEN_REQUESTS", "invalid")
+	t.Setenv("SCRAPER_BREAKER_SUCCESS_THRESHOLD", "3")
+
+	cfg := BreakerConfigFromEnv()
+	want := BreakerConfig{FailureThreshold: 10, CoolDown: 2 * time.Minute, HalfOpenMaxRequests: 1, SuccessThreshold: 3}
+	if cfg != want {
+		t.Errorf("BreakerConfigFromEnv() = %+v, want %+v", cfg, want)
+	}
+}
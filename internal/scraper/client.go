@@ -0,0 +1,250 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const defaultUserAgent = "bookcover-api/1.0 (+https://bookcover.longitood.com)"
+
+var ErrBodyTooLarge = errors.New("response body exceeds size limit")
+
+// Fetcher retrieves the raw body of an upstream page.
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) ([]byte, error)
+}
+
+// StatusError is returned when an upstream answers with a non-2xx status code.
+type StatusError struct {
+	StatusCode int
+	URL        string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d from %s", e.StatusCode, e.URL)
+}
+
+// Retryable reports whether the request may succeed if sent again.
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+type ClientConfig struct {
+	UserAgent   string
+	Headers     map[string]string
+	Timeout     time.Duration
+	MaxRetries  int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	MaxBodySize int64
+	// Limiter enforces the per-host politeness budget. Nil disables it.
+	Limiter *HostLimiter
+	// Proxies routes requests through a pool of egress proxies. Nil sends
+	// requests directly.
+	Proxies *ProxyPool
+	// BlockDetector reports whether a 2xx body is a bot wall rather than
+	// real content, so the proxy that served it can be taken out of rotation.
+	BlockDetector func(body []byte) bool
+	// Transport overrides the tuned default transport, mostly for tests.
+	Transport http.RoundTripper
+}
+
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		UserAgent: defaultUserAgent,
+		Headers: map[string]string{
+			"Accept":          "text/html,application/xhtml+xml",
+			"Accept-Language": "en-US,en;q=0.9",
+		},
+		Timeout:       15 * time.Second,
+		MaxRetries:    3,
+		BaseBackoff:   250 * time.Millisecond,
+		MaxBackoff:    10 * time.Second,
+		MaxBodySize:   5 << 20, // 5MB
+		Limiter:       SharedHostLimiter(),
+		BlockDetector: isBlockedPage,
+	}
+}
+
+// ClientConfigFromEnv returns the default config with overrides taken from
+// SCRAPER_USER_AGENT and SCRAPER_MAX_RETRIES.
+func ClientConfigFromEnv() ClientConfig {
+	cfg := DefaultClientConfig()
+	if ua := os.Getenv("SCRAPER_USER_AGENT"); ua != "" {
+		cfg.UserAgent = ua
+	}
+	if retries, err := strconv.Atoi(os.Getenv("SCRAPER_MAX_RETRIES")); err == nil && retries >= 0 {
+		cfg.MaxRetries = retries
+	}
+	return cfg
+}
+
+// HTTPClient fetches upstream pages with retries, backoff and a bounded body size.
+type HTTPClient struct {
+	cfg    ClientConfig
+	client *http.Client
+}
+
+func NewHTTPClient(cfg ClientConfig) *HTTPClient {
+	transport := cfg.Transport
+	if transport == nil {
+		transport = &http.Transport{
+			Proxy:               proxyFromContext,
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		}
+	}
+
+	return &HTTPClient{
+		cfg: cfg,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   cfg.Timeout,
+		},
+	}
+}
+
+func (c *HTTPClient) Fetch(ctx context.Context, url string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		body, err := c.fetchOnce(ctx, url)
+		if err == nil {
+			return body, nil
+		}
+
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || !statusErr.Retryable() || attempt >= c.cfg.MaxRetries {
+			return nil, err
+		}
+
+		wait := c.backoff(attempt)
+		if statusErr.RetryAfter > 0 {
+			if statusErr.RetryAfter > c.cfg.MaxBackoff {
+				// Upstream asked us to stay away longer than we are willing to block.
+				return nil, err
+			}
+			wait = statusErr.RetryAfter
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *HTTPClient) fetchOnce(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	for key, value := range c.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+	if c.cfg.UserAgent != "" {
+		req.Header.Set("User-Agent", c.cfg.UserAgent)
+	}
+
+	if c.cfg.Limiter != nil {
+		release, err := c.cfg.Limiter.Acquire(ctx, req.URL.Host)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	var proxy *Proxy
+	if c.cfg.Proxies != nil {
+		if proxy = c.cfg.Proxies.Pick(); proxy == nil {
+			return nil, ErrNoProxyAvailable
+		}
+		req = req.WithContext(withProxy(ctx, proxy))
+	}
+
+	response, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		if proxy != nil && (response.StatusCode == http.StatusForbidden || response.StatusCode == http.StatusTooManyRequests) {
+			c.cfg.Proxies.ReportBlocked(proxy)
+		}
+		// Drain a little of the body so the connection can be reused.
+		io.Copy(io.Discard, io.LimitReader(response.Body, 4096))
+		return nil, &StatusError{
+			StatusCode: response.StatusCode,
+			URL:        url,
+			RetryAfter: parseRetryAfter(response.Header.Get("Retry-After")),
+		}
+	}
+
+	var reader io.Reader = response.Body
+	if c.cfg.MaxBodySize > 0 {
+		reader = io.LimitReader(response.Body, c.cfg.MaxBodySize+1)
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if c.cfg.MaxBodySize > 0 && int64(len(body)) > c.cfg.MaxBodySize {
+		return nil, ErrBodyTooLarge
+	}
+
+	if proxy != nil {
+		if c.cfg.BlockDetector != nil && c.cfg.BlockDetector(body) {
+			c.cfg.Proxies.ReportBlocked(proxy)
+		} else {
+			c.cfg.Proxies.ReportSuccess(proxy)
+		}
+	}
+
+	return body, nil
+}
+
+// backoff returns an exponential delay with equal jitter for the given attempt.
+func (c *HTTPClient) backoff(attempt int) time.Duration {
+	delay := c.cfg.BaseBackoff << attempt
+	if delay <= 0 || delay > c.cfg.MaxBackoff {
+		delay = c.cfg.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// parseRetryAfter understands both the delay-seconds and HTTP-date forms.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
@@ -0,0 +1,145 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testClientConfig() ClientConfig {
+	cfg := DefaultClientConfig()
+	cfg.BaseBackoff = time.Millisecond
+	cfg.MaxBackoff = 50 * time.Millisecond
+	cfg.Limiter = nil
+	return cfg
+}
+
+func TestHTTPClient_RetriesOnServerError(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	body, err := NewHTTPClient(testClientConfig()).Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch() unexpected error: %v", err)
+	}
+	if string(body) != "ok" {
+		t.Errorf("Fetch() body = %q, want %q", string(body), "ok")
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestHTTPClient_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	cfg := testClientConfig()
+	cfg.MaxRetries = 2
+	_, err := NewHTTPClient(cfg).Fetch(context.Background(), srv.URL)
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Fetch() error = %v, want StatusError with 429", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestHTTPClient_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	_, err := NewHTTPClient(testClientConfig()).Fetch(context.Background(), srv.URL)
+	if err == nil {
+		t.Fatal("Fetch() expected error for 404, got nil")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls.Load())
+	}
+}
+
+func TestHTTPClient_RetryAfterTooLong(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	_, err := NewHTTPClient(testClientConfig()).Fetch(context.Background(), srv.URL)
+	if err == nil {
+		t.Fatal("Fetch() expected error, got nil")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected Retry-After beyond MaxBackoff to stop retries, got %d attempts", calls.Load())
+	}
+}
+
+func TestHTTPClient_SendsUserAgentAndHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ua := r.Header.Get("User-Agent"); ua != "test-agent" {
+			t.Errorf("User-Agent = %q, want %q", ua, "test-agent")
+		}
+		if v := r.Header.Get("X-Custom"); v != "yes" {
+			t.Errorf("X-Custom = %q, want %q", v, "yes")
+		}
+	}))
+	defer srv.Close()
+
+	cfg := testClientConfig()
+	cfg.UserAgent = "test-agent"
+	cfg.Headers = map[string]string{"X-Custom": "yes"}
+	if _, err := NewHTTPClient(cfg).Fetch(context.Background(), srv.URL); err != nil {
+		t.Fatalf("Fetch() unexpected error: %v", err)
+	}
+}
+
+func TestHTTPClient_BodyTooLarge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 2048)))
+	}))
+	defer srv.Close()
+
+	cfg := testClientConfig()
+	cfg.MaxBodySize = 1024
+	_, err := NewHTTPClient(cfg).Fetch(context.Background(), srv.URL)
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("Fetch() error = %v, want %v", err, ErrBodyTooLarge)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("5"); got != 5*time.Second {
+		t.Errorf("parseRetryAfter(\"5\") = %v, want 5s", got)
+	}
+	if got := parseRetryAfter(""); got != 0 {
+		t.Errorf("parseRetryAfter(\"\") = %v, want 0", got)
+	}
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(date); got <= 0 || got > time.Minute {
+		t.Errorf("parseRetryAfter(%q) = %v, want within (0, 1m]", date, got)
+	}
+}
@@ -1,100 +1,201 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"regexp"
 	"strings"
 
+	"bookcover-api/internal/imageurl"
+
 	"github.com/PuerkitoBio/goquery"
 )
 
 const querySeparator = "+"
 
-type Goodreads struct{}
+type Goodreads struct {
+	client Fetcher
+	images Fetcher
+	rules  *RuleStore
+}
+
+type GoodreadsConfig struct {
+	// Client fetches the pages. Defaults to an HTTPClient configured from the environment.
+	Client Fetcher
+	// Rules holds the extraction selectors. Defaults to the built-in rules.
+	Rules *RuleStore
+	// Images downloads covers to compare them with the known placeholder
+	// images. When nil, placeholders are only detected by URL.
+	Images Fetcher
+}
 
 func NewGoodreads() *Goodreads {
-	return &Goodreads{}
+	return NewGoodreadsWithConfig(GoodreadsConfig{})
 }
 
-func (g *Goodreads) FetchByTitleAuthor(bookTitle, authorName string) (string, error) {
+func NewGoodreadsWithConfig(cfg GoodreadsConfig) *Goodreads {
+	if cfg.Client == nil {
+		cfg.Client = NewHTTPClient(ClientConfigFromEnv())
+	}
+	if cfg.Rules == nil {
+		cfg.Rules = DefaultRuleStore()
+	}
+	return &Goodreads{client: cfg.Client, images: cfg.Images, rules: cfg.Rules}
+}
+
+func (g *Goodreads) Name() string {
+	return "goodreads"
+}
+
+func (g *Goodreads) FetchByTitleAuthor(ctx context.Context, bookTitle, authorName string) (string, error) {
 	bookTitle = strings.ReplaceAll(bookTitle, " ", querySeparator)
 	authorName = strings.ReplaceAll(authorName, " ", querySeparator)
 
 	query := "https://www.goodreads.com/search?utf8=%E2%9C%93&q=" + bookTitle + "&search_type=books"
-	body, err := g.fetchHTML(query)
+	body, err := g.fetchHTML(ctx, query)
 	if err != nil {
 		return "", err
 	}
 
-	return g.extractURLFromSearch(body, bookTitle, authorName)
+	return g.extractURLFromSearch(ctx, body, bookTitle, authorName)
 }
 
-func (g *Goodreads) FetchByISBN(isbn string) (string, error) {
+func (g *Goodreads) FetchByISBN(ctx context.Context, isbn string) (string, error) {
 	query := "https://www.goodreads.com/search?utf8=✓&query=" + isbn
-	body, err := g.fetchHTML(query)
+	body, err := g.fetchHTML(ctx, query)
 	if err != nil {
 		return "", err
 	}
 
-	return g.extractURLFromISBN(body, isbn)
+	return g.extractURLFromISBN(ctx, body, isbn)
 }
 
-func (g *Goodreads) fetchHTML(url string) ([]byte, error) {
-	response, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch URL: %w", err)
-	}
-	defer response.Body.Close()
-
-	body, err := io.ReadAll(response.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	return body, nil
+func (g *Goodreads) fetchHTML(ctx context.Context, url string) ([]byte, error) {
+	return g.client.Fetch(ctx, url)
 }
 
-func (g *Goodreads) extractURLFromISBN(data []byte, isbn string) (string, error) {
+func (g *Goodreads) extractURLFromISBN(ctx context.Context, data []byte, isbn string) (string, error) {
 	doc, err := g.parseHTML(data)
 	if err != nil {
 		return "", err
 	}
 
-	imageURL, exists := doc.Find(".BookCover__image").First().Find("img").First().Attr("src")
+	rules := g.rules.Rules()
+	imageURL, exists := firstAttr(doc.Selection, rules.ISBN.Cover, "src")
 	if !exists {
-		return "", fmt.Errorf("image was not found for ISBN %s", isbn)
+		class := g.classify(doc, rules)
+		recordPage(g.Name(), class)
+		return "", fmt.Errorf("%w for ISBN %s", class.Err(), isbn)
 	}
 
+	if isPlaceholderURL(imageURL, rules) || g.isPlaceholderImage(ctx, imageURL, rules) {
+		recordPage(g.Name(), PagePlaceholder)
+		return "", fmt.Errorf("%w for ISBN %s: only a placeholder image", ErrNotFound, isbn)
+	}
+
+	recordPage(g.Name(), PageOK)
 	return imageURL, nil
 }
 
-func (g *Goodreads) extractURLFromSearch(data []byte, bookTitle, authorName string) (string, error) {
+func (g *Goodreads) extractURLFromSearch(ctx context.Context, data []byte, bookTitle, authorName string) (string, error) {
 	doc, err := g.parseHTML(data)
 	if err != nil {
 		return "", err
 	}
 
+	rules := g.rules.Rules()
+	rows := firstMatch(doc.Selection, rules.Search.Row)
+	if rows.Length() == 0 {
+		class := g.classify(doc, rules)
+		recordPage(g.Name(), class)
+		return "", fmt.Errorf("%w [book_title=%s, author_name=%s]", class.Err(), bookTitle, authorName)
+	}
+
 	url := ""
-	doc.Find("tr[itemscope]").Each(func(i int, s *goquery.Selection) {
-		foundURL, urlExists := s.Find(".bookCover").First().Attr("src")
+	placeholders := 0
+	rows.Each(func(i int, s *goquery.Selection) {
+		foundURL, urlExists := firstAttr(s, rules.Search.Cover, "src")
 
-		foundAuthorName := strings.Join(strings.Fields(s.Find(".authorName").First().Text()), " ")
+		foundAuthorName := strings.Join(strings.Fields(firstMatch(s, rules.Search.Author).First().Text()), " ")
 		foundAuthorName = strings.ReplaceAll(foundAuthorName, " ", querySeparator)
 
 		if url == "" && urlExists && strings.EqualFold(foundAuthorName, authorName) {
+			if isPlaceholderURL(foundURL, rules) {
+				placeholders++
+				return
+			}
 			url = foundURL
 		}
 	})
 
 	if url == "" {
-		return "", fmt.Errorf("image was not found [book_title=%s, author_name=%s]", bookTitle, authorName)
+		if placeholders > 0 {
+			recordPage(g.Name(), PagePlaceholder)
+		} else {
+			recordPage(g.Name(), PageOK)
+		}
+		return "", fmt.Errorf("%w [book_title=%s, author_name=%s]", ErrNotFound, bookTitle, authorName)
 	}
 
-	// Remove small image indicator to retrieve bigger cover image
-	imageURL := regexp.MustCompile(`_[^_]*_.`).ReplaceAllString(url, "")
-	return imageURL, nil
+	// Remove the thumbnail size tokens to retrieve the full-size cover image
+	if parsed, ok := imageurl.Parse(url); ok {
+		url = parsed.WithoutSize().String()
+	}
+	if g.isPlaceholderImage(ctx, url, rules) {
+		recordPage(g.Name(), PagePlaceholder)
+		return "", fmt.Errorf("%w [book_title=%s, author_name=%s]: only a placeholder image", ErrNotFound, bookTitle, authorName)
+	}
+
+	recordPage(g.Name(), PageOK)
+	return url, nil
+}
+
+// classify explains why a page did not contain what we were looking for.
+func (g *Goodreads) classify(doc *goquery.Document, rules *Rules) PageClass {
+	html, _ := doc.Html()
+	if isBlockedPage([]byte(html)) {
+		return PageBlocked
+	}
+
+	title := strings.ToLower(doc.Find("title").First().Text())
+	for _, loginTitle := range rules.Pages.LoginTitles {
+		if strings.Contains(title, strings.ToLower(loginTitle)) {
+			return PageLoginRequired
+		}
+	}
+	if firstMatch(doc.Selection, rules.Pages.LoginSelectors).Length() > 0 {
+		return PageLoginRequired
+	}
+
+	body := doc.Find("body").Text()
+	for _, text := range rules.Pages.NoResultsText {
+		if strings.Contains(body, text) {
+			return PageNoResults
+		}
+	}
+
+	return PageUnknownLayout
+}
+
+// firstMatch returns the matches of the first selector in the fallback list
+// that finds anything.
+func firstMatch(s *goquery.Selection, selectors []string) *goquery.Selection {
+	for _, selector := range selectors {
+		if found := s.Find(selector); found.Length() > 0 {
+			return found
+		}
+	}
+	return s.Slice(0, 0)
+}
+
+// firstAttr returns attr of the first element matched by the fallback list
+// that carries it.
+func firstAttr(s *goquery.Selection, selectors []string, attr string) (string, bool) {
+	for _, selector := range selectors {
+		if value, exists := s.Find(selector).First().Attr(attr); exists {
+			return value, true
+		}
+	}
+	return "", false
 }
 
 func (g *Goodreads) parseHTML(data []byte) (*goquery.Document, error) {
@@ -1,6 +1,8 @@
 package scraper
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -68,7 +70,7 @@ func TestExtractURLFromISBN(t *testing.T) {
 		</html>
 	`)
 
-	url, err := g.extractURLFromISBN(html, "1234567890123")
+	url, err := g.extractURLFromISBN(context.Background(), html, "1234567890123")
 	if err != nil {
 		t.Errorf("extractURLFromISBN() error = %v", err)
 	}
@@ -82,9 +84,9 @@ func TestExtractURLFromISBN(t *testing.T) {
 func TestExtractURLFromISBN_NotFound(t *testing.T) {
 	g := NewGoodreads()
 
-	html := []byte(`<html><body><div>No book cover here</div></body></html>`)
+	html := []byte(`<html><body><h3 class="searchSubNavContainer">No results.</h3></body></html>`)
 
-	_, err := g.extractURLFromISBN(html, "1234567890123")
+	_, err := g.extractURLFromISBN(context.Background(), html, "1234567890123")
 	if err == nil {
 		t.Error("extractURLFromISBN() expected error for missing image, got nil")
 	}
@@ -95,6 +97,51 @@ func TestExtractURLFromISBN_NotFound(t *testing.T) {
 	}
 }
 
+func TestExtractURLFromISBN_ClassifiesPages(t *testing.T) {
+	g := NewGoodreads()
+
+	tests := []struct {
+		name string
+		html string
+		want error
+	}{
+		{
+			name: "captcha",
+			html: `<html><body><form><div class="g-recaptcha"></div></form></body></html>`,
+			want: ErrBlocked,
+		},
+		{
+			name: "robot check",
+			html: `<html><head><title>Robot Check</title></head><body></body></html>`,
+			want: ErrBlocked,
+		},
+		{
+			name: "sign in wall",
+			html: `<html><head><title>Sign in | Goodreads</title></head><body><form action="/user/sign_in"></form></body></html>`,
+			want: ErrLoginRequired,
+		},
+		{
+			name: "no results",
+			html: `<html><body><h3 class="searchSubNavContainer">No results.</h3></body></html>`,
+			want: ErrNotFound,
+		},
+		{
+			name: "redesigned page",
+			html: `<html><body><div class="NewBookCover"><img src="https://example.com/cover.jpg" /></div></body></html>`,
+			want: ErrUnknownLayout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := g.extractURLFromISBN(context.Background(), []byte(tt.html), "1234567890123")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("extractURLFromISBN() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
 func TestExtractURLFromSearch(t *testing.T) {
 	g := NewGoodreads()
 
@@ -104,7 +151,7 @@ func TestExtractURLFromSearch(t *testing.T) {
 				<table>
 					<tr itemscope>
 						<td>
-							<img class="bookCover" src="https://example.com/cover_SX98_.jpg" />
+							<img class="bookCover" src="https://example.com/cover._SX98_.jpg" />
 						</td>
 						<td>
 							<a class="authorName">Carl Sagan</a>
@@ -115,13 +162,13 @@ func TestExtractURLFromSearch(t *testing.T) {
 		</html>
 	`)
 
-	url, err := g.extractURLFromSearch(html, "Pale+Blue+Dot", "Carl+Sagan")
+	url, err := g.extractURLFromSearch(context.Background(), html, "Pale+Blue+Dot", "Carl+Sagan")
 	if err != nil {
 		t.Errorf("extractURLFromSearch() error = %v", err)
 	}
 
-	// The regex _[^_]*_. removes _SX98_. (including the dot after the underscore)
-	expectedURL := "https://example.com/coverjpg"
+	// The _SX98_ thumbnail token is removed to get the full-size cover
+	expectedURL := "https://example.com/cover.jpg"
 	if url != expectedURL {
 		t.Errorf("extractURLFromSearch() = %v, want %v", url, expectedURL)
 	}
@@ -132,12 +179,23 @@ func TestExtractURLFromSearch_NotFound(t *testing.T) {
 
 	html := []byte(`<html><body><div>No results</div></body></html>`)
 
-	_, err := g.extractURLFromSearch(html, "NonExistent+Book", "Unknown+Author")
+	_, err := g.extractURLFromSearch(context.Background(), html, "NonExistent+Book", "Unknown+Author")
 	if err == nil {
 		t.Error("extractURLFromSearch() expected error for missing book, got nil")
 	}
 }
 
+func TestExtractURLFromSearch_UnknownLayout(t *testing.T) {
+	g := NewGoodreads()
+
+	html := []byte(`<html><body><div class="SearchResult">Pale Blue Dot</div></body></html>`)
+
+	_, err := g.extractURLFromSearch(context.Background(), html, "Pale+Blue+Dot", "Carl+Sagan")
+	if !errors.Is(err, ErrUnknownLayout) {
+		t.Errorf("extractURLFromSearch() error = %v, want %v", err, ErrUnknownLayout)
+	}
+}
+
 func TestExtractURLFromSearch_AuthorMismatch(t *testing.T) {
 	g := NewGoodreads()
 
@@ -153,7 +211,7 @@ func TestExtractURLFromSearch_AuthorMismatch(t *testing.T) {
 	`)
 
 	// Search for different author
-	_, err := g.extractURLFromSearch(html, "The+Stand", "Carl+Sagan")
+	_, err := g.extractURLFromSearch(context.Background(), html, "The+Stand", "Carl+Sagan")
 	if err == nil {
 		t.Error("extractURLFromSearch() expected error for author mismatch, got nil")
 	}
@@ -168,7 +226,7 @@ func TestFetchHTML_Success(t *testing.T) {
 	defer srv.Close()
 
 	g := NewGoodreads()
-	body, err := g.fetchHTML(srv.URL)
+	body, err := g.fetchHTML(context.Background(), srv.URL)
 	if err != nil {
 		t.Fatalf("fetchHTML() unexpected error: %v", err)
 	}
@@ -180,7 +238,7 @@ func TestFetchHTML_Success(t *testing.T) {
 func TestFetchHTML_NetworkError(t *testing.T) {
 	g := NewGoodreads()
 	// Use an address that will refuse connections
-	_, err := g.fetchHTML("http://127.0.0.1:1")
+	_, err := g.fetchHTML(context.Background(), "http://127.0.0.1:1")
 	if err == nil {
 		t.Fatal("fetchHTML() expected error for refused connection, got nil")
 	}
@@ -197,7 +255,7 @@ func TestFetchHTML_LargeBody(t *testing.T) {
 	defer srv.Close()
 
 	g := NewGoodreads()
-	body, err := g.fetchHTML(srv.URL)
+	body, err := g.fetchHTML(context.Background(), srv.URL)
 	if err != nil {
 		t.Fatalf("fetchHTML() unexpected error: %v", err)
 	}
@@ -0,0 +1,146 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+type HostLimit struct {
+	RequestsPerSecond float64
+	Burst             int
+	MaxConcurrent     int
+	// MaxWait is how long a request may queue for budget. Zero rejects
+	// requests as soon as the budget is exhausted.
+	MaxWait time.Duration
+}
+
+func DefaultHostLimit() HostLimit {
+	return HostLimit{
+		RequestsPerSecond: 1,
+		Burst:             5,
+		MaxConcurrent:     4,
+		MaxWait:           5 * time.Second,
+	}
+}
+
+// HostLimitFromEnv returns the default limit with overrides taken from
+// SCRAPER_RATE_LIMIT, SCRAPER_RATE_BURST, SCRAPER_MAX_CONCURRENCY and
+// SCRAPER_QUEUE_TIMEOUT.
+func HostLimitFromEnv() HostLimit {
+	limit := DefaultHostLimit()
+	if rps, err := strconv.ParseFloat(os.Getenv("SCRAPER_RATE_LIMIT"), 64); err == nil && rps > 0 {
+		limit.RequestsPerSecond = rps
+	}
+	if burst, err := strconv.Atoi(os.Getenv("SCRAPER_RATE_BURST")); err == nil && burst > 0 {
+		limit.Burst = burst
+	}
+	if concurrency, err := strconv.Atoi(os.Getenv("SCRAPER_MAX_CONCURRENCY")); err == nil && concurrency > 0 {
+		limit.MaxConcurrent = concurrency
+	}
+	if wait, err := time.ParseDuration(os.Getenv("SCRAPER_QUEUE_TIMEOUT")); err == nil && wait >= 0 {
+		limit.MaxWait = wait
+	}
+	return limit
+}
+
+// HostLimiter enforces a token bucket and a concurrency cap per upstream host.
+type HostLimiter struct {
+	limit HostLimit
+
+	mu    sync.Mutex
+	hosts map[string]*hostBudget
+}
+
+type hostBudget struct {
+	tokens *rate.Limiter
+	slots  chan struct{}
+}
+
+func NewHostLimiter(limit HostLimit) *HostLimiter {
+	return &HostLimiter{
+		limit: limit,
+		hosts: make(map[string]*hostBudget),
+	}
+}
+
+var (
+	sharedLimiter     *HostLimiter
+	sharedLimiterOnce sync.Once
+)
+
+// SharedHostLimiter returns the limiter used by every scraper in the process,
+// so that the politeness budget holds no matter how many scrapers exist.
+func SharedHostLimiter() *HostLimiter {
+	sharedLimiterOnce.Do(func() {
+		sharedLimiter = NewHostLimiter(HostLimitFromEnv())
+	})
+	return sharedLimiter
+}
+
+// Acquire blocks until host has budget for one more request, up to MaxWait.
+// The returned release func must be called once the request has finished.
+func (l *HostLimiter) Acquire(ctx context.Context, host string) (func(), error) {
+	budget := l.budget(host)
+	queue := l.limit.MaxWait > 0
+
+	if queue {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.limit.MaxWait)
+		defer cancel()
+	}
+
+	if !acquireSlot(ctx, budget.slots, queue) {
+		return nil, fmt.Errorf("%w for %s: too many concurrent requests", ErrBudgetExhausted, host)
+	}
+	release := func() { <-budget.slots }
+
+	allowed := budget.tokens.Allow()
+	if !allowed && queue {
+		allowed = budget.tokens.Wait(ctx) == nil
+	}
+	if !allowed {
+		release()
+		return nil, fmt.Errorf("%w for %s: rate limit reached", ErrBudgetExhausted, host)
+	}
+
+	return release, nil
+}
+
+func acquireSlot(ctx context.Context, slots chan struct{}, queue bool) bool {
+	if !queue {
+		select {
+		case slots <- struct{}{}:
+			return true
+		default:
+			return false
+		}
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (l *HostLimiter) budget(host string) *hostBudget {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	budget, ok := l.hosts[host]
+	if !ok {
+		budget = &hostBudget{
+			tokens: rate.NewLimiter(rate.Limit(l.limit.RequestsPerSecond), l.limit.Burst),
+			slots:  make(chan struct{}, max(1, l.limit.MaxConcurrent)),
+		}
+		l.hosts[host] = budget
+	}
+	return budget
+}
@@ -0,0 +1,76 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHostLimiter_RejectsWhenBurstExhausted(t *testing.T) {
+	l := NewHostLimiter(HostLimit{RequestsPerSecond: 0.001, Burst: 2, MaxConcurrent: 10})
+
+	for i := 0; i < 2; i++ {
+		release, err := l.Acquire(context.Background(), "www.goodreads.com")
+		if err != nil {
+			t.Fatalf("request %d: unexpected error: %v", i+1, err)
+		}
+		release()
+	}
+
+	_, err := l.Acquire(context.Background(), "www.goodreads.com")
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("expected ErrBudgetExhausted, got %v", err)
+	}
+}
+
+func TestHostLimiter_BudgetIsPerHost(t *testing.T) {
+	l := NewHostLimiter(HostLimit{RequestsPerSecond: 0.001, Burst: 1, MaxConcurrent: 10})
+
+	release, err := l.Acquire(context.Background(), "a.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	if _, err := l.Acquire(context.Background(), "b.example.com"); err != nil {
+		t.Errorf("expected separate budget for another host, got %v", err)
+	}
+}
+
+func TestHostLimiter_ConcurrencyCap(t *testing.T) {
+	l := NewHostLimiter(HostLimit{RequestsPerSecond: 1000, Burst: 100, MaxConcurrent: 1, MaxWait: 20 * time.Millisecond})
+
+	release, err := l.Acquire(context.Background(), "www.goodreads.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	_, err = l.Acquire(context.Background(), "www.goodreads.com")
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Fatalf("expected ErrBudgetExhausted while slot is held, got %v", err)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Errorf("expected request to queue for MaxWait before being rejected")
+	}
+
+	release()
+	release, err = l.Acquire(context.Background(), "www.goodreads.com")
+	if err != nil {
+		t.Fatalf("expected slot to be free after release, got %v", err)
+	}
+	release()
+}
+
+func TestHostLimiter_QueuesForToken(t *testing.T) {
+	l := NewHostLimiter(HostLimit{RequestsPerSecond: 50, Burst: 1, MaxConcurrent: 10, MaxWait: time.Second})
+
+	for i := 0; i < 2; i++ {
+		release, err := l.Acquire(context.Background(), "www.goodreads.com")
+		if err != nil {
+			t.Fatalf("request %d: expected to wait for a token, got %v", i+1, err)
+		}
+		release()
+	}
+}
@@ -0,0 +1,61 @@
+package scraper
+
+import (
+	"bytes"
+
+	"bookcover-api/internal/metrics"
+)
+
+// PageClass describes what kind of page a provider served.
+type PageClass string
+
+const (
+	PageOK            PageClass = "ok"
+	PageBlocked       PageClass = "blocked"
+	PageLoginRequired PageClass = "login_required"
+	PageNoResults     PageClass = "no_results"
+	PageUnknownLayout PageClass = "unknown_layout"
+	// PagePlaceholder means the page only had the generic "no cover" image.
+	PagePlaceholder PageClass = "placeholder"
+)
+
+// Err returns the error a lookup should fail with for this class of page.
+func (c PageClass) Err() error {
+	switch c {
+	case PageBlocked:
+		return ErrBlocked
+	case PageLoginRequired:
+		return ErrLoginRequired
+	case PageNoResults, PagePlaceholder:
+		return ErrNotFound
+	case PageUnknownLayout:
+		return ErrUnknownLayout
+	default:
+		return nil
+	}
+}
+
+func recordPage(provider string, class PageClass) {
+	metrics.RecordScrapedPage(provider, string(class))
+}
+
+var blockedPageMarkers = [][]byte{
+	[]byte("g-recaptcha"),
+	[]byte("h-captcha"),
+	[]byte("captcha-delivery"),
+	[]byte("validatecaptcha"),
+	[]byte("cf-challenge"),
+	[]byte("<title>robot check"),
+	[]byte("<title>access denied"),
+}
+
+// isBlockedPage spots the captchas and bot walls that are served with a 200.
+func isBlockedPage(body []byte) bool {
+	page := bytes.ToLower(body)
+	for _, marker := range blockedPageMarkers {
+		if bytes.Contains(page, marker) {
+			return true
+		}
+	}
+	return false
+}
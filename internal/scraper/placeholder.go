@@ -0,0 +1,107 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"log/slog"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"bookcover-api/internal/imageproc"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// placeholderHashDistance is how many bits two perceptual hashes may differ by
+// and still be considered the same image, to absorb re-encoding and resizing.
+const placeholderHashDistance = 6
+
+// isPlaceholderURL reports whether url points at one of the provider's generic
+// "no cover" images.
+func isPlaceholderURL(url string, rules *Rules) bool {
+	url = strings.ToLower(url)
+	for _, pattern := range rules.Placeholders.URLPatterns {
+		if strings.Contains(url, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPlaceholderImage downloads the cover and compares its perceptual hash to
+// the known placeholder images. It only runs when the rules list hashes and
+// an image fetcher is configured; download or decoding failures are logged
+// and the cover is given the benefit of the doubt.
+func (g *Goodreads) isPlaceholderImage(ctx context.Context, url string, rules *Rules) bool {
+	if g.images == nil || len(rules.Placeholders.Hashes) == 0 {
+		return false
+	}
+
+	data, err := g.images.Fetch(ctx, url)
+	if err != nil {
+		slog.Warn("failed to fetch cover for placeholder check", "url", url, "error", err)
+		return false
+	}
+	hash, err := PerceptualHash(data)
+	if err != nil {
+		slog.Warn("failed to hash cover for placeholder check", "url", url, "error", err)
+		return false
+	}
+
+	for _, known := range rules.Placeholders.Hashes {
+		value, _ := parseHash(known)
+		if bits.OnesCount64(hash^value) <= placeholderHashDistance {
+			return true
+		}
+	}
+	return false
+}
+
+// PerceptualHash computes the 64-bit difference hash (dHash) of a JPEG, PNG,
+// GIF or WebP image. Visually similar images have hashes that differ by few bits.
+func PerceptualHash(data []byte) (uint64, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", imageproc.ErrUnsupportedFormat, err)
+	}
+	if cfg.Width*cfg.Height > imageproc.MaxPixels {
+		return 0, imageproc.ErrTooManyPixels
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	gray := image.NewGray(image.Rect(0, 0, 9, 8))
+	draw.ApproxBiLinear.Scale(gray, gray.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if gray.GrayAt(x, y).Y < gray.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+	return hash, nil
+}
+
+// FormatHash renders a perceptual hash the way it is written in rule files.
+func FormatHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+func parseHash(raw string) (uint64, error) {
+	if len(raw) != 16 {
+		return 0, fmt.Errorf("hash %q must be 16 hex digits", raw)
+	}
+	return strconv.ParseUint(raw, 16, 64)
+}
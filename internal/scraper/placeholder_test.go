@@ -0,0 +1,160 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math/bits"
+	"strings"
+	"testing"
+)
+
+type imageFetcher map[string][]byte
+
+func (f imageFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	data, ok := f[url]
+	if !ok {
+		return nil, &StatusError{StatusCode: 404, URL: url}
+	}
+	return data, nil
+}
+
+// gradient renders a test image whose brightness increases from left to
+// right, or from right to left when reversed.
+func gradient(t *testing.T, width, height int, reversed bool) []byte {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(x * 255 / width)
+			if reversed {
+				v = 255 - v
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func goodreadsWithHashes(t *testing.T, images Fetcher, hashes ...string) *Goodreads {
+	t.Helper()
+	data := strings.Replace(string(defaultRules), "hashes: []", `hashes: ["`+strings.Join(hashes, `", "`)+`"]`, 1)
+	rules, err := ParseRules([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseRules() unexpected error: %v", err)
+	}
+	store := &RuleStore{}
+	store.current.Store(rules)
+	return NewGoodreadsWithConfig(GoodreadsConfig{Rules: store, Images: images})
+}
+
+func TestExtractURLFromISBN_PlaceholderURL(t *testing.T) {
+	g := NewGoodreads()
+
+	html := []byte(`<html><body><div class="BookCover__image"><img src="https://s.gr-assets.com/assets/nophoto/book/111x148-bcc042a9c91a29c1d680899eff700a03.png" /></div></body></html>`)
+
+	_, err := g.extractURLFromISBN(context.Background(), html, "1234567890123")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("extractURLFromISBN() error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestExtractURLFromSearch_SkipsPlaceholderRows(t *testing.T) {
+	g := NewGoodreads()
+
+	html := []byte(`
+		<html>
+			<body>
+				<table>
+					<tr itemscope>
+						<td><img class="bookCover" src="https://s.gr-assets.com/assets/nophoto/book/50x75-a91bf249278a81aabab721ef782c4a74.png" /></td>
+						<td><a class="authorName">Carl Sagan</a></td>
+					</tr>
+					<tr itemscope>
+						<td><img class="bookCover" src="https://example.com/cover._SX98_.jpg" /></td>
+						<td><a class="authorName">Carl Sagan</a></td>
+					</tr>
+				</table>
+			</body>
+		</html>
+	`)
+
+	url, err := g.extractURLFromSearch(context.Background(), html, "Pale+Blue+Dot", "Carl+Sagan")
+	if err != nil {
+		t.Fatalf("extractURLFromSearch() unexpected error: %v", err)
+	}
+	if url != "https://example.com/cover.jpg" {
+		t.Errorf("extractURLFromSearch() = %v, want https://example.com/cover.jpg", url)
+	}
+
+	onlyPlaceholder := []byte(strings.Replace(string(html), "https://example.com/cover._SX98_.jpg", "https://s.gr-assets.com/assets/nophoto/book/50x75.png", 1))
+	_, err = g.extractURLFromSearch(context.Background(), onlyPlaceholder, "Pale+Blue+Dot", "Carl+Sagan")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("extractURLFromSearch() error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestExtractURLFromISBN_PlaceholderImage(t *testing.T) {
+	placeholder := gradient(t, 120, 180, false)
+	hash, err := PerceptualHash(placeholder)
+	if err != nil {
+		t.Fatalf("PerceptualHash() unexpected error: %v", err)
+	}
+
+	images := imageFetcher{
+		"https://example.com/placeholder.jpg": gradient(t, 60, 90, false),
+		"https://example.com/cover.jpg":       gradient(t, 120, 180, true),
+	}
+	g := goodreadsWithHashes(t, images, FormatHash(hash))
+
+	page := func(url string) []byte {
+		return []byte(`<html><body><div class="BookCover__image"><img src="` + url + `" /></div></body></html>`)
+	}
+
+	_, err = g.extractURLFromISBN(context.Background(), page("https://example.com/placeholder.jpg"), "1234567890123")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("extractURLFromISBN() error = %v, want %v", err, ErrNotFound)
+	}
+
+	url, err := g.extractURLFromISBN(context.Background(), page("https://example.com/cover.jpg"), "1234567890123")
+	if err != nil {
+		t.Errorf("extractURLFromISBN() unexpected error: %v", err)
+	}
+	if url != "https://example.com/cover.jpg" {
+		t.Errorf("extractURLFromISBN() = %v, want https://example.com/cover.jpg", url)
+	}
+
+	// Covers that cannot be downloaded are not treated as placeholders.
+	if _, err := g.extractURLFromISBN(context.Background(), page("https://example.com/missing.jpg"), "1234567890123"); err != nil {
+		t.Errorf("extractURLFromISBN() unexpected error: %v", err)
+	}
+}
+
+func TestPerceptualHash_SimilarImages(t *testing.T) {
+	large, err := PerceptualHash(gradient(t, 300, 450, false))
+	if err != nil {
+		t.Fatalf("PerceptualHash() unexpected error: %v", err)
+	}
+	small, err := PerceptualHash(gradient(t, 50, 75, false))
+	if err != nil {
+		t.Fatalf("PerceptualHash() unexpected error: %v", err)
+	}
+	reversed, err := PerceptualHash(gradient(t, 300, 450, true))
+	if err != nil {
+		t.Fatalf("PerceptualHash() unexpected error: %v", err)
+	}
+
+	if d := bits.OnesCount64(large ^ small); d > placeholderHashDistance {
+		t.Errorf("resized image hash differs by %d bits, want at most %d", d, placeholderHashDistance)
+	}
+	if d := bits.OnesCount64(large ^ reversed); d <= placeholderHashDistance {
+		t.Errorf("different image hash differs by only %d bits", d)
+	}
+}
@@ -0,0 +1,189 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"bookcover-api/internal/metrics"
+)
+
+var ErrNoProxyAvailable = errors.New("no healthy egress proxy available")
+
+type ProxyStrategy string
+
+const (
+	ProxyRoundRobin ProxyStrategy = "round_robin"
+	ProxyHealth     ProxyStrategy = "health"
+)
+
+type ProxyPoolConfig struct {
+	Strategy ProxyStrategy
+	// FailureThreshold is the number of consecutive blocked responses that
+	// takes a proxy out of rotation.
+	FailureThreshold int
+	// Quarantine is how long a proxy stays out of rotation.
+	Quarantine time.Duration
+}
+
+func DefaultProxyPoolConfig() ProxyPoolConfig {
+	return ProxyPoolConfig{
+		Strategy:         ProxyRoundRobin,
+		FailureThreshold: 3,
+		Quarantine:       10 * time.Minute,
+	}
+}
+
+// Proxy is a single egress proxy tracked by a ProxyPool.
+type Proxy struct {
+	url   *url.URL
+	label string
+
+	// Guarded by the owning pool's mutex.
+	failures     int
+	score        float64
+	ejectedUntil time.Time
+}
+
+// Label identifies the proxy in logs and metrics without leaking credentials.
+func (p *Proxy) Label() string {
+	return p.label
+}
+
+// ProxyPool hands out egress proxies and takes misbehaving ones out of rotation.
+type ProxyPool struct {
+	cfg ProxyPoolConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	proxies []*Proxy
+	next    int
+}
+
+func NewProxyPool(rawURLs []string, cfg ProxyPoolConfig) (*ProxyPool, error) {
+	pool := &ProxyPool{cfg: cfg, now: time.Now}
+
+	for _, raw := range rawURLs {
+		proxyURL, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %w", raw, err)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q (use http, https or socks5)", proxyURL.Scheme)
+		}
+		if proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q: missing host", raw)
+		}
+
+		proxy := &Proxy{url: proxyURL, label: proxyURL.Scheme + "://" + proxyURL.Host}
+		pool.proxies = append(pool.proxies, proxy)
+		metrics.SetProxyHealthy(proxy.label, true)
+	}
+
+	if len(pool.proxies) == 0 {
+		return nil, errors.New("proxy pool needs at least one proxy")
+	}
+	return pool, nil
+}
+
+// ProxyPoolFromEnv builds a pool from the comma-separated SCRAPER_PROXIES list
+// and SCRAPER_PROXY_STRATEGY. It returns nil when no proxies are configured.
+func ProxyPoolFromEnv() (*ProxyPool, error) {
+	raw := os.Getenv("SCRAPER_PROXIES")
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	cfg := DefaultProxyPoolConfig()
+	if strategy := os.Getenv("SCRAPER_PROXY_STRATEGY"); strategy != "" {
+		switch ProxyStrategy(strategy) {
+		case ProxyRoundRobin, ProxyHealth:
+			cfg.Strategy = ProxyStrategy(strategy)
+		default:
+			return nil, fmt.Errorf("unknown proxy strategy %q", strategy)
+		}
+	}
+
+	return NewProxyPool(strings.Split(raw, ","), cfg)
+}
+
+// Pick returns the next proxy to use, or nil when every proxy is quarantined.
+func (p *ProxyPool) Pick() *Proxy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	picked := -1
+	for i := range p.proxies {
+		index := (p.next + i) % len(p.proxies)
+		candidate := p.proxies[index]
+		if !candidate.ejectedUntil.IsZero() {
+			if now.Before(candidate.ejectedUntil) {
+				continue
+			}
+			candidate.ejectedUntil = time.Time{}
+			candidate.failures = 0
+			metrics.SetProxyHealthy(candidate.label, true)
+		}
+
+		if picked == -1 || (p.cfg.Strategy == ProxyHealth && candidate.score < p.proxies[picked].score) {
+			picked = index
+		}
+		if p.cfg.Strategy != ProxyHealth {
+			break
+		}
+	}
+
+	if picked == -1 {
+		return nil
+	}
+	p.next = (picked + 1) % len(p.proxies)
+	return p.proxies[picked]
+}
+
+// ReportSuccess records a response that made it through the proxy unblocked.
+func (p *ProxyPool) ReportSuccess(proxy *Proxy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	proxy.failures = 0
+	proxy.score /= 2
+}
+
+// ReportBlocked records a 403 or captcha served through the proxy and
+// quarantines it once FailureThreshold is reached.
+func (p *ProxyPool) ReportBlocked(proxy *Proxy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	metrics.RecordProxyBlocked(proxy.label)
+	proxy.failures++
+	proxy.score++
+	if proxy.failures >= p.cfg.FailureThreshold && proxy.ejectedUntil.IsZero() {
+		proxy.ejectedUntil = p.now().Add(p.cfg.Quarantine)
+		metrics.SetProxyHealthy(proxy.label, false)
+	}
+}
+
+type proxyContextKey struct{}
+
+func withProxy(ctx context.Context, proxy *Proxy) context.Context {
+	return context.WithValue(ctx, proxyContextKey{}, proxy)
+}
+
+// proxyFromContext is used as http.Transport.Proxy so that every request is
+// routed through the proxy that was picked for it.
+func proxyFromContext(req *http.Request) (*url.URL, error) {
+	if proxy, ok := req.Context().Value(proxyContextKey{}).(*Proxy); ok {
+		return proxy.url, nil
+	}
+	return http.ProxyFromEnvironment(req)
+}
@@ -0,0 +1,154 @@
+package scraper
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+
+	"github.com/andybalholm/cascadia"
+	"gopkg.in/yaml.v3"
+)
+
+// RulesVersion is the rule file schema version this build understands.
+const RulesVersion = 1
+
+//go:embed rules/goodreads.yaml
+var defaultRules []byte
+
+// Rules holds the selectors used to extract covers from Goodreads pages.
+type Rules struct {
+	Version int `yaml:"version"`
+	ISBN    struct {
+		Cover []string `yaml:"cover"`
+	} `yaml:"isbn"`
+	Search struct {
+		Row    []string `yaml:"row"`
+		Cover  []string `yaml:"cover"`
+		Author []string `yaml:"author"`
+	} `yaml:"search"`
+	Pages struct {
+		NoResultsText  []string `yaml:"no_results_text"`
+		LoginSelectors []string `yaml:"login_selectors"`
+		LoginTitles    []string `yaml:"login_titles"`
+	} `yaml:"pages"`
+	Placeholders struct {
+		URLPatterns []string `yaml:"url_patterns"`
+		Hashes      []string `yaml:"hashes"`
+	} `yaml:"placeholders"`
+}
+
+// ParseRules decodes and validates a YAML (or JSON) rule file.
+func ParseRules(data []byte) (*Rules, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var rules Rules
+	if err := decoder.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("failed to decode rules: %w", err)
+	}
+	if err := rules.validate(); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+func (r *Rules) validate() error {
+	if r.Version != RulesVersion {
+		return fmt.Errorf("unsupported rules version %d (expected %d)", r.Version, RulesVersion)
+	}
+
+	selectors := map[string][]string{
+		"isbn.cover":            r.ISBN.Cover,
+		"search.row":            r.Search.Row,
+		"search.cover":          r.Search.Cover,
+		"search.author":         r.Search.Author,
+		"pages.login_selectors": r.Pages.LoginSelectors,
+	}
+	for field, list := range selectors {
+		if len(list) == 0 {
+			return fmt.Errorf("%s needs at least one selector", field)
+		}
+		for _, selector := range list {
+			if _, err := cascadia.Compile(selector); err != nil {
+				return fmt.Errorf("%s has an invalid selector %q: %w", field, selector, err)
+			}
+		}
+	}
+
+	if len(r.Pages.NoResultsText) == 0 {
+		return errors.New("pages.no_results_text needs at least one entry")
+	}
+	for _, hash := range r.Placeholders.Hashes {
+		if _, err := parseHash(hash); err != nil {
+			return fmt.Errorf("placeholders.hashes: %w", err)
+		}
+	}
+	return nil
+}
+
+// RuleStore holds the active rules and swaps them atomically on reload.
+type RuleStore struct {
+	path    string
+	current atomic.Pointer[Rules]
+}
+
+// NewRuleStore loads rules from path, or the built-in rules when path is empty.
+func NewRuleStore(path string) (*RuleStore, error) {
+	store := &RuleStore{path: path}
+	if _, err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// DefaultRuleStore returns a store holding the built-in rules.
+func DefaultRuleStore() *RuleStore {
+	store, err := NewRuleStore("")
+	if err != nil {
+		panic(fmt.Sprintf("built-in scraper rules are invalid: %v", err))
+	}
+	return store
+}
+
+// RuleStoreFromEnv loads rules from SCRAPER_RULES_FILE, falling back to the
+// built-in rules when it is not set.
+func RuleStoreFromEnv() (*RuleStore, error) {
+	return NewRuleStore(os.Getenv("SCRAPER_RULES_FILE"))
+}
+
+func (s *RuleStore) Rules() *Rules {
+	return s.current.Load()
+}
+
+// Source describes where the rules are loaded from.
+func (s *RuleStore) Source() string {
+	if s.path == "" {
+		return "built-in"
+	}
+	return s.path
+}
+
+// Reload re-reads the rule file. Invalid rules are rejected and the
+// previously loaded rules stay active.
+func (s *RuleStore) Reload() (*Rules, error) {
+	data := defaultRules
+	if s.path != "" {
+		var err error
+		if data, err = os.ReadFile(s.path); err != nil {
+			return nil, fmt.Errorf("failed to read rules file: %w", err)
+		}
+	}
+
+	rules, err := ParseRules(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s.current.Store(rules)
+	slog.Info("scraper rules loaded", "source", s.Source(), "version", rules.Version)
+	return rules, nil
+}
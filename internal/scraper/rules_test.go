@@ -0,0 +1,105 @@
+package scraper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultRules_AreValid(t *testing.T) {
+	if _, err := ParseRules(defaultRules); err != nil {
+		t.Fatalf("built-in rules are invalid: %v", err)
+	}
+}
+
+func TestParseRules_AcceptsJSON(t *testing.T) {
+	data := []byte(`{
+		"version": 1,
+		"isbn": {"cover": [".BookCover__image img"]},
+		"search": {"row": ["tr[itemscope]"], "cover": [".bookCover"], "author": [".authorName"]},
+		"pages": {"no_results_text": ["No results"], "login_selectors": ["form"], "login_titles": []}
+	}`)
+
+	if _, err := ParseRules(data); err != nil {
+		t.Errorf("ParseRules() with JSON error = %v", err)
+	}
+}
+
+func TestParseRules_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace [2]string
+		wantErr string
+	}{
+		{"unsupported version", [2]string{"version: 1", "version: 2"}, "unsupported rules version"},
+		{"invalid selector", [2]string{`"tr[itemscope]"`, `"tr[itemscope"`}, "invalid selector"},
+		{"empty selector list", [2]string{`    - ".authorName"`, ""}, "search.author needs at least one selector"},
+		{"unknown field", [2]string{"version: 1", "version: 1\nselectors: []"}, "field selectors not found"},
+		{"invalid placeholder hash", [2]string{"hashes: []", `hashes: ["abc"]`}, "placeholders.hashes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := strings.Replace(string(defaultRules), tt.replace[0], tt.replace[1], 1)
+			_, err := ParseRules([]byte(data))
+			if err == nil {
+				t.Fatal("ParseRules() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseRules() error = %q, want it to contain %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRuleStore_ReloadKeepsPreviousRulesOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, defaultRules, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewRuleStore(path)
+	if err != nil {
+		t.Fatalf("NewRuleStore() unexpected error: %v", err)
+	}
+	before := store.Rules()
+
+	os.WriteFile(path, []byte("version: 99"), 0o644)
+	if _, err := store.Reload(); err == nil {
+		t.Fatal("Reload() expected error for invalid rules, got nil")
+	}
+	if store.Rules() != before {
+		t.Error("expected previous rules to stay active after a failed reload")
+	}
+
+	updated := strings.Replace(string(defaultRules), `".BookCover__image img"`, `".NewCover img"`, 1)
+	os.WriteFile(path, []byte(updated), 0o644)
+	if _, err := store.Reload(); err != nil {
+		t.Fatalf("Reload() unexpected error: %v", err)
+	}
+	if got := store.Rules().ISBN.Cover[0]; got != ".NewCover img" {
+		t.Errorf("expected reloaded selector, got %q", got)
+	}
+}
+
+func TestExtractURLFromISBN_FallbackSelector(t *testing.T) {
+	data := strings.Replace(string(defaultRules), `    - ".BookCover__image img"`, "    - \".NewCover img\"\n    - \".BookCover__image img\"", 1)
+	rules, err := ParseRules([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseRules() unexpected error: %v", err)
+	}
+	store := &RuleStore{}
+	store.current.Store(rules)
+	g := NewGoodreadsWithConfig(GoodreadsConfig{Rules: store})
+
+	html := []byte(`<html><body><div class="BookCover__image"><img src="https://example.com/old.jpg" /></div></body></html>`)
+	url, err := g.extractURLFromISBN(context.Background(), html, "1234567890123")
+	if err != nil {
+		t.Fatalf("extractURLFromISBN() unexpected error: %v", err)
+	}
+	if url != "https://example.com/old.jpg" {
+		t.Errorf("extractURLFromISBN() = %v, want fallback match", url)
+	}
+}
@@ -1,6 +1,34 @@
 package scraper
 
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrNotFound means the provider answered but has no cover for the book.
+	ErrNotFound = errors.New("image was not found")
+	// ErrBlocked means the provider served a captcha or bot wall instead of content.
+	ErrBlocked = errors.New("provider is blocking requests")
+	// ErrLoginRequired means the provider asked us to sign in.
+	ErrLoginRequired = errors.New("provider requires login")
+	// ErrUnknownLayout means the page matched none of the known layouts, which
+	// usually means the provider changed its markup.
+	ErrUnknownLayout = errors.New("provider page layout is not recognized")
+	// ErrCircuitOpen means the provider is failing and calls are short-circuited.
+	ErrCircuitOpen = errors.New("provider circuit breaker is open")
+	// ErrBudgetExhausted means the outbound politeness budget for the host is used up.
+	ErrBudgetExhausted = errors.New("outbound request budget exhausted")
+)
+
+// IsUnavailable reports whether err means the provider could not be asked at
+// all, as opposed to having answered with an error.
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrBudgetExhausted) || errors.Is(err, ErrNoProxyAvailable)
+}
+
 type Scraper interface {
-	FetchByTitleAuthor(bookTitle, authorName string) (string, error)
-	FetchByISBN(isbn string) (string, error)
+	Name() string
+	FetchByTitleAuthor(ctx context.Context, bookTitle, authorName string) (string, error)
+	FetchByISBN(ctx context.Context, isbn string) (string, error)
 }
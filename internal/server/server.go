@@ -1,65 +1,202 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"bookcover-api/internal/cache"
+	"bookcover-api/internal/grpcserver"
 	"bookcover-api/internal/handler"
-	"bookcover-api/internal/metrics"
+	"bookcover-api/internal/imagecache"
+	"bookcover-api/internal/imageinfo"
+	"bookcover-api/internal/imageproxy"
+	"bookcover-api/internal/jobs"
 	"bookcover-api/internal/middleware"
 	"bookcover-api/internal/scraper"
 	"bookcover-api/internal/service"
 
 	"github.com/joho/godotenv"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
 )
 
 const port = 8000
 
+// shutdownTimeout bounds how long a graceful shutdown may take.
+const shutdownTimeout = 30 * time.Second
+
 func Start() error {
 	if err := godotenv.Load(); err != nil {
 		log.Print("Error loading .env file")
 	}
 
+	proxies, err := scraper.ProxyPoolFromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid scraper proxy configuration: %w", err)
+	}
+	clientConfig := scraper.ClientConfigFromEnv()
+	clientConfig.Proxies = proxies
+
+	rules, err := scraper.RuleStoreFromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid scraper rules: %w", err)
+	}
+	go reloadRulesOnSignal(rules)
+
+	imageCache, err := imagecache.StoreFromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid image cache configuration: %w", err)
+	}
+
 	cacheClient := cache.GetCache()
-	goodreadsScraper := scraper.NewGoodreads()
-	bookcoverService := service.NewBookcoverService(goodreadsScraper, cacheClient)
+	scraperClient := scraper.NewHTTPClient(clientConfig)
+	// Placeholder checks download covers through the image proxy client, so
+	// they get its host allowlist and size limit.
+	images := imageproxy.NewClient(imageproxy.ConfigFromEnv())
+	goodreads := scraper.NewGoodreadsWithConfig(scraper.GoodreadsConfig{
+		Client: scraperClient,
+		Rules:  rules,
+		Images: images,
+	})
+	goodreadsScraper := scraper.NewCircuitBreaker(goodreads, scraper.BreakerConfigFromEnv())
+	bookcoverService := service.NewBookcoverServiceWithConfig(cacheClient, service.Config{
+		Providers: []scraper.Scraper{goodreadsScraper},
+		Analyzer:  imageinfo.NewAnalyzer(images),
+		MaxAge:    service.MaxAgeFromEnv(),
+	})
 	bookcoverHandler := handler.NewBookcoverHandler(bookcoverService)
+	coversHandler := handler.NewCoversHandler(bookcoverService)
+	imageHandler := handler.NewImageHandler(bookcoverService, images, imageCache)
+	// Bulk uploads and GraphQL queries are charged per lookup as they run,
+	// at the tier the other routes use.
+	batchConfig := handler.BatchConfigFromEnv()
+	batchConfig.Quota = handler.NewQuotaMeter(cacheClient, middleware.ProTier)
+	batchHandler := handler.NewBatchHandlerWithConfig(bookcoverService, batchConfig)
+	graphQLHandler := handler.NewGraphQLHandlerWithConfig(bookcoverService, batchConfig)
 
-	http.Handle("/metrics", promhttp.Handler())
-	http.HandleFunc("/debug/cache-stats", middleware.Chain(
-		handler.CacheStatsHandler(),
-		middleware.AuthMiddleware(),
-		middleware.HttpMethod("GET"),
-		middleware.JsonHeaderMiddleware(),
-	))
-
-	http.HandleFunc("/", middleware.Chain(
-		handler.Home,
-		metrics.MetricsMiddleware(),
-		middleware.CorsHeaderMiddleware(),
-	))
-
-	http.HandleFunc("/bookcover", middleware.Chain(
-		bookcoverHandler.Search,
-		metrics.MetricsMiddleware(),
-		middleware.RateLimitMiddleware(cacheClient),
-		middleware.HttpMethod("GET"),
-		middleware.JsonHeaderMiddleware(),
-		middleware.CorsHeaderMiddleware(),
-	))
-
-	http.HandleFunc("/bookcover/{isbn}", middleware.Chain(
-		bookcoverHandler.ByISBN,
-		metrics.MetricsMiddleware(),
-		middleware.RateLimitMiddleware(cacheClient),
-		middleware.HttpMethod("GET"),
-		middleware.JsonHeaderMiddleware(),
-		middleware.CorsHeaderMiddleware(),
-	))
-
-	fmt.Printf("Server listening at port %d 🚀\n", port)
-	return http.ListenAndServe(fmt.Sprintf(":%d", port), nil)
+	jobStore, err := jobs.StoreFromEnv()
+	if err != nil {
+		return fmt.Errorf("invalid job store configuration: %w", err)
+	}
+	jobManager := jobs.NewManager(jobStore, batchHandler.ResolveJobItem, jobs.ConfigFromEnv())
+	if err := jobManager.Start(); err != nil {
+		return err
+	}
+	jobsHandler := handler.NewJobsHandler(jobManager)
+
+	mux := handler.NewServeMux(handler.Routes{
+		Bookcover:  bookcoverHandler,
+		Covers:     coversHandler,
+		Images:     imageHandler,
+		Batch:      batchHandler,
+		GraphQL:    graphQLHandler,
+		Jobs:       jobsHandler,
+		Rules:      rules,
+		ImageCache: imageCache,
+		Cache:      cacheClient,
+		Cors:       middleware.CorsConfigFromEnv(),
+	})
+
+	// Without an API key every gRPC call would be rejected, so the server is
+	// not started at all.
+	grpcConfig := grpcserver.ConfigFromEnv()
+	var grpcServer *grpc.Server
+	var grpcListener net.Listener
+	if grpcConfig.APIKey != "" {
+		grpcListener, err = net.Listen("tcp", fmt.Sprintf(":%d", grpcConfig.Port))
+		if err != nil {
+			jobManager.Shutdown(context.Background())
+			return fmt.Errorf("failed to listen for gRPC: %w", err)
+		}
+		grpcServer = grpcserver.New(bookcoverService, cacheClient, grpcConfig)
+	} else {
+		slog.Info("gRPC server disabled, GRPC_API_KEY is not set")
+	}
+
+	// Requests derive their context from requests, which is canceled when
+	// the graceful shutdown runs out of time so that scrapes stop.
+	requests, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	srv := &http.Server{
+		Addr:        fmt.Sprintf(":%d", port),
+		Handler:     mux,
+		BaseContext: func(net.Listener) context.Context { return requests },
+	}
+	serveErr := make(chan error, 2)
+	go func() {
+		fmt.Printf("Server listening at port %d 🚀\n", port)
+		serveErr <- srv.ListenAndServe()
+	}()
+	if grpcServer != nil {
+		go func() {
+			slog.Info("gRPC server listening", "port", grpcConfig.Port)
+			serveErr <- grpcServer.Serve(grpcListener)
+		}()
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-serveErr:
+		srv.Close()
+		if grpcServer != nil {
+			grpcServer.Stop()
+		}
+		jobManager.Shutdown(context.Background())
+		return err
+	case sig := <-stop:
+		slog.Info("shutting down", "signal", sig.String())
+	}
+
+	// Finish in-flight requests, then let running jobs save their progress.
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("failed to shut down HTTP server", "error", err)
+		cancelRequests()
+	}
+	stopGRPC(ctx, grpcServer)
+	if err := jobManager.Shutdown(ctx); err != nil {
+		slog.Error("failed to shut down job manager", "error", err)
+	}
+	return nil
+}
+
+// stopGRPC lets in-flight calls finish, cutting them off once ctx is done.
+// s is nil when the gRPC server was not started.
+func stopGRPC(ctx context.Context, s *grpc.Server) {
+	if s == nil {
+		return
+	}
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		slog.Error("failed to shut down gRPC server", "error", ctx.Err())
+		s.Stop()
+	}
+}
+
+// reloadRulesOnSignal reloads the scraper rules every time the process gets SIGHUP.
+func reloadRulesOnSignal(rules *scraper.RuleStore) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		if _, err := rules.Reload(); err != nil {
+			slog.Error("failed to reload scraper rules", "error", err)
+		}
+	}
 }
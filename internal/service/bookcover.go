@@ -1,11 +1,18 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
 	"log"
 	"log/slog"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"bookcover-api/internal/cache"
+	"bookcover-api/internal/imageinfo"
+	"bookcover-api/internal/imageurl"
 	"bookcover-api/internal/metrics"
 	"bookcover-api/internal/scraper"
 
@@ -14,123 +21,329 @@ import (
 
 const querySeparator = "+"
 
+// DefaultMaxAge is how long cached covers stay fresh unless COVER_MAX_AGE is set.
+const DefaultMaxAge = 30 * 24 * time.Hour
+
+// refreshTimeout bounds a background refresh of a stale cover, retries included.
+const refreshTimeout = time.Minute
+
+// analyzeRetryDelay is how long a failed image analysis is remembered, so that
+// a cover that cannot be analyzed is not downloaded again on every request.
+const analyzeRetryDelay = 15 * time.Minute
+
+// lookupFunc asks one provider for the cover of the book being looked up.
+type lookupFunc func(ctx context.Context, p scraper.Scraper) (string, error)
+
+type Config struct {
+	// Providers are asked in order until one of them returns a cover.
+	Providers []scraper.Scraper
+	// Analyzer computes image info on request. Without it image info is never included.
+	Analyzer ImageAnalyzer
+	// MaxAge is how long a cached cover is fresh. Older covers are still served,
+	// as stale, while they are refreshed in the background. Zero never expires.
+	MaxAge time.Duration
+}
+
+// MaxAgeFromEnv reads COVER_MAX_AGE, a duration such as 720h. Zero keeps
+// cached covers fresh forever.
+func MaxAgeFromEnv() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("COVER_MAX_AGE")); err == nil && d >= 0 {
+		return d
+	}
+	return DefaultMaxAge
+}
+
 type bookcoverService struct {
-	scraper scraper.Scraper
-	cache   cache.CacheClient
-	metrics *metrics.CacheMetrics
+	providers []scraper.Scraper
+	analyzer  ImageAnalyzer
+	maxAge    time.Duration
+	cache     cache.CacheClient
+	metrics   *metrics.CacheMetrics
+
+	// refreshing holds the cache keys being refreshed in the background.
+	refreshing sync.Map
+}
+
+// cacheRecord is what gets cached for a book. Entries written before records
+// carried metadata are the bare URL.
+type cacheRecord struct {
+	URL       string          `json:"url"`
+	Source    string          `json:"source,omitempty"`
+	CachedAt  int64           `json:"cached_at,omitempty"`
+	ImageInfo *imageinfo.Info `json:"image_info,omitempty"`
+	// ImageInfoFailedAt is when analyzing the cover last failed.
+	ImageInfoFailedAt int64 `json:"image_info_failed_at,omitempty"`
+}
+
+// stale reports whether the record is older than maxAge. Bare URL entries
+// have no age and never go stale.
+func (r cacheRecord) stale(maxAge time.Duration) bool {
+	return maxAge > 0 && r.CachedAt > 0 && time.Since(time.Unix(r.CachedAt, 0)) > maxAge
+}
+
+// needsAnalysis reports whether the record lacks image info and analyzing it
+// has not failed within analyzeRetryDelay.
+func (r cacheRecord) needsAnalysis() bool {
+	return r.ImageInfo == nil && time.Since(time.Unix(r.ImageInfoFailedAt, 0)) > analyzeRetryDelay
 }
 
 func NewBookcoverService(s scraper.Scraper, cache cache.CacheClient) BookcoverService {
+	return NewBookcoverServiceWithConfig(cache, Config{Providers: []scraper.Scraper{s}})
+}
+
+func NewBookcoverServiceWithConfig(cache cache.CacheClient, cfg Config) BookcoverService {
 	return &bookcoverService{
-		scraper: s,
-		cache:   cache,
-		metrics: metrics.GetCacheMetrics(),
+		providers: cfg.Providers,
+		analyzer:  cfg.Analyzer,
+		maxAge:    cfg.MaxAge,
+		cache:     cache,
+		metrics:   metrics.GetCacheMetrics(),
 	}
 }
 
-func (s *bookcoverService) GetByTitleAuthor(bookTitle, authorName, imageSize string) (string, error) {
+func (s *bookcoverService) GetByTitleAuthor(ctx context.Context, bookTitle, authorName string, opts ImageOptions) (string, error) {
+	cover, err := s.Lookup(ctx, Query{BookTitle: bookTitle, AuthorName: authorName, Options: opts})
+	if err != nil {
+		return "", err
+	}
+	return cover.URL, nil
+}
+
+func (s *bookcoverService) GetByISBN(ctx context.Context, isbn string, opts ImageOptions) (string, error) {
+	cover, err := s.Lookup(ctx, Query{ISBN: isbn, Options: opts})
+	if err != nil {
+		return "", err
+	}
+	return cover.URL, nil
+}
+
+func (s *bookcoverService) Lookup(ctx context.Context, q Query) (*Cover, error) {
+	if err := q.Options.Validate(); err != nil {
+		return nil, err
+	}
+
 	s.metrics.RecordRequest()
 
-	bookTitle = strings.ReplaceAll(bookTitle, " ", querySeparator)
-	authorName = strings.ReplaceAll(authorName, " ", querySeparator)
-	cacheKey := strings.ToLower(bookTitle + querySeparator + authorName)
+	var cacheKey string
+	var lookup lookupFunc
+	var logAttrs []any
+	if q.ISBN != "" {
+		isbn := strings.ReplaceAll(q.ISBN, "-", "")
+		cacheKey = strings.ToLower(isbn)
+		lookup = func(ctx context.Context, p scraper.Scraper) (string, error) {
+			return p.FetchByISBN(ctx, isbn)
+		}
+		logAttrs = []any{"isbn", isbn}
+	} else {
+		bookTitle := strings.ReplaceAll(q.BookTitle, " ", querySeparator)
+		authorName := strings.ReplaceAll(q.AuthorName, " ", querySeparator)
+		cacheKey = strings.ToLower(bookTitle + querySeparator + authorName)
+		lookup = func(ctx context.Context, p scraper.Scraper) (string, error) {
+			return p.FetchByTitleAuthor(ctx, bookTitle, authorName)
+		}
+		logAttrs = []any{"title", bookTitle, "author", authorName}
+	}
 
-	if cachedURL, err := s.getFromCache(cacheKey); cachedURL != "" {
+	status := CacheHit
+	record, cached := s.getFromCache(cacheKey)
+	if cached {
 		s.metrics.RecordCacheHit()
-		return applyImageSize(cachedURL, imageSize), err
+		if record.stale(s.maxAge) {
+			status = CacheStale
+			s.refresh(ctx, cacheKey, lookup, logAttrs)
+		}
+	} else {
+		status = CacheMiss
+		s.metrics.RecordCacheMiss()
+
+		var err error
+		if record, err = s.fetchRecord(ctx, lookup, logAttrs); err != nil {
+			s.metrics.RecordScrapingError()
+			return nil, err
+		}
+		if s.setCache(cacheKey, record) {
+			s.metrics.RecordNewBookCached()
+		}
 	}
 
-	s.metrics.RecordCacheMiss()
+	if q.IncludeImageInfo && record.needsAnalysis() {
+		s.analyze(ctx, cacheKey, &record)
+	}
 
-	imageURL, err := s.scraper.FetchByTitleAuthor(bookTitle, authorName)
-	if err != nil {
-		s.metrics.RecordScrapingError()
-		return "", err
+	cover := &Cover{
+		URL:         applyImageOptions(record.URL, q.Options),
+		Source:      record.Source,
+		CacheStatus: status,
+		MaxAge:      s.maxAge,
+	}
+	if record.CachedAt > 0 {
+		cover.CachedAt = time.Unix(record.CachedAt, 0)
+	}
+	if q.IncludeImageInfo {
+		cover.ImageInfo = record.ImageInfo
 	}
+	return cover, nil
+}
 
-	slog.Info("book fetch", "title", bookTitle, "author", authorName, "source", "scraper")
-	s.setCache(cacheKey, imageURL)
+func (s *bookcoverService) fetchRecord(ctx context.Context, lookup lookupFunc, logAttrs []any) (cacheRecord, error) {
+	imageURL, source, err := s.fetch(ctx, lookup)
+	if err != nil {
+		return cacheRecord{}, err
+	}
 
-	return applyImageSize(imageURL, imageSize), nil
+	slog.Info("book fetch", append(logAttrs, "source", source)...)
+	return cacheRecord{URL: imageURL, Source: source, CachedAt: time.Now().Unix()}, nil
 }
 
-func (s *bookcoverService) GetByISBN(isbn, imageSize string) (string, error) {
-	s.metrics.RecordRequest()
+// refresh looks a stale cover up again in the background. Only one refresh
+// runs per key; if it fails the stale record is kept. The refresh outlives
+// the request that noticed the stale record, but is bounded by refreshTimeout.
+func (s *bookcoverService) refresh(ctx context.Context, cacheKey string, lookup lookupFunc, logAttrs []any) {
+	if _, running := s.refreshing.LoadOrStore(cacheKey, struct{}{}); running {
+		return
+	}
 
-	isbn = strings.ReplaceAll(isbn, "-", "")
-	cacheKey := strings.ToLower(isbn)
+	go func() {
+		defer s.refreshing.Delete(cacheKey)
 
-	if cachedURL, err := s.getFromCache(cacheKey); cachedURL != "" {
-		s.metrics.RecordCacheHit()
-		return applyImageSize(cachedURL, imageSize), err
-	}
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refreshTimeout)
+		defer cancel()
+		record, err := s.fetchRecord(ctx, lookup, logAttrs)
+		if err != nil {
+			slog.Warn("failed to refresh stale cover", append(logAttrs, "error", err)...)
+			return
+		}
+		if old, ok := s.getFromCache(cacheKey); ok && old.URL == record.URL {
+			record.ImageInfo = old.ImageInfo
+			record.ImageInfoFailedAt = old.ImageInfoFailedAt
+		}
+		s.setCache(cacheKey, record)
+	}()
+}
 
-	s.metrics.RecordCacheMiss()
+// analyze computes the image info of the full-size cover and caches it with
+// the record. Failures are logged and cached as a marker, which holds off
+// further attempts for analyzeRetryDelay.
+func (s *bookcoverService) analyze(ctx context.Context, cacheKey string, record *cacheRecord) {
+	if s.analyzer == nil {
+		return
+	}
 
-	imageURL, err := s.scraper.FetchByISBN(isbn)
+	info, err := s.analyzer.Analyze(ctx, record.URL)
 	if err != nil {
-		s.metrics.RecordScrapingError()
-		return "", err
+		slog.Warn("failed to analyze cover image", "url", record.URL, "error", err)
+		record.ImageInfoFailedAt = time.Now().Unix()
+		s.setCache(cacheKey, *record)
+		return
 	}
+	record.ImageInfo = info
+	record.ImageInfoFailedAt = 0
+	s.setCache(cacheKey, *record)
+}
 
-	slog.Info("book fetch", "isbn", isbn, "source", "scraper")
-	s.setCache(cacheKey, imageURL)
+// fetch asks each provider in turn and returns the cover URL along with the
+// name of the provider that found it. Unavailable providers are skipped; their
+// error is only returned when no provider gave a definitive answer.
+func (s *bookcoverService) fetch(ctx context.Context, lookup lookupFunc) (string, string, error) {
+	var definitiveErr, unavailableErr error
+	for _, provider := range s.providers {
+		if err := ctx.Err(); err != nil {
+			return "", "", err
+		}
+		imageURL, err := lookup(ctx, provider)
+		if err == nil {
+			return imageURL, provider.Name(), nil
+		}
 
-	return applyImageSize(imageURL, imageSize), nil
-}
+		if scraper.IsUnavailable(err) {
+			if unavailableErr == nil {
+				unavailableErr = err
+			}
+			continue
+		}
+		if definitiveErr == nil {
+			definitiveErr = err
+		}
+	}
 
-func applyImageSize(url, imageSize string) string {
-	switch imageSize {
-	case "small":
-		return insertSizeSuffix(url, "__SY75__")
-	case "medium":
-		return insertSizeSuffix(url, "__SY375__")
-	default:
-		return url
+	if definitiveErr != nil {
+		return "", "", definitiveErr
+	}
+	if unavailableErr != nil {
+		return "", "", unavailableErr
 	}
+	return "", "", scraper.ErrNotFound
 }
 
-func insertSizeSuffix(url, suffix string) string {
-	dotIndex := strings.LastIndex(url, ".")
-	if dotIndex == -1 {
+// applyImageOptions rewrites the size tokens of covers served by CDNs that
+// resize on the fly. Other URLs are returned untouched.
+func applyImageOptions(url string, opts ImageOptions) string {
+	parsed, ok := imageurl.Parse(url)
+	if !ok || !imageurl.Resizable(url) {
 		return url
 	}
-	return url[:dotIndex] + "." + suffix + url[dotIndex:]
+
+	width, height := opts.Dimensions()
+	switch {
+	case opts.Fit == FitPad:
+		return parsed.WithPaddedSize(width, height).String()
+	case width > 0 || height > 0:
+		return parsed.WithSize(width, height).String()
+	default:
+		return parsed.WithoutSize().String()
+	}
 }
 
-func (s *bookcoverService) getFromCache(key string) (string, error) {
+func (s *bookcoverService) getFromCache(key string) (cacheRecord, bool) {
 	if s.cache == nil {
-		return "", nil
+		return cacheRecord{}, false
 	}
 
-	cachedURL, err := s.cache.Get(key)
+	item, err := s.cache.Get(key)
 	if err != nil {
 		if err != memcache.ErrCacheMiss {
 			log.Printf("Cache get error for key %s: %v", key, err)
 		}
-		return "", nil
+		return cacheRecord{}, false
 	}
-
-	if cachedURL != nil {
-		return string(cachedURL.Value), nil
+	if item == nil || len(item.Value) == 0 {
+		return cacheRecord{}, false
 	}
 
-	return "", nil
+	if item.Value[0] != '{' {
+		return cacheRecord{URL: string(item.Value)}, true
+	}
+	var record cacheRecord
+	if err := json.Unmarshal(item.Value, &record); err != nil || record.URL == "" {
+		log.Printf("Invalid cache record for key %s: %v", key, err)
+		return cacheRecord{}, false
+	}
+	return record, true
 }
 
-func (s *bookcoverService) setCache(key, value string) {
+// setCache stores record under key and reports whether it was stored.
+func (s *bookcoverService) setCache(key string, record cacheRecord) bool {
 	if s.cache == nil {
-		return
+		return false
 	}
 
-	err := s.cache.Set(&memcache.Item{Key: key, Value: []byte(value)})
+	value := []byte(record.URL)
+	if record.Source != "" || record.CachedAt != 0 || record.ImageInfo != nil || record.ImageInfoFailedAt != 0 {
+		var err error
+		if value, err = json.Marshal(record); err != nil {
+			log.Printf("Failed to encode cache record for key %s: %v", key, err)
+			return false
+		}
+	}
+
+	err := s.cache.Set(&memcache.Item{Key: key, Value: value})
 	if err != nil {
 		log.Printf("Failed to set cache for key %s: %v", key, err)
-		return
+		return false
 	}
 
-	s.metrics.RecordNewBookCached()
 	slog.Debug("cache set", "key", key)
+	return true
 }
 
 func GetMetricsStats() metrics.Stats {
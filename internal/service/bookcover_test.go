@@ -1,9 +1,15 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"bookcover-api/internal/imageinfo"
 	"bookcover-api/internal/scraper"
 	"bookcover-api/mocks"
 
@@ -15,14 +21,18 @@ type mockScraper struct {
 	fetchByISBNFunc        func(isbn string) (string, error)
 }
 
-func (m *mockScraper) FetchByTitleAuthor(bookTitle, authorName string) (string, error) {
+func (m *mockScraper) Name() string {
+	return "mock"
+}
+
+func (m *mockScraper) FetchByTitleAuthor(ctx context.Context, bookTitle, authorName string) (string, error) {
 	if m.fetchByTitleAuthorFunc != nil {
 		return m.fetchByTitleAuthorFunc(bookTitle, authorName)
 	}
 	return "", errors.New("not implemented")
 }
 
-func (m *mockScraper) FetchByISBN(isbn string) (string, error) {
+func (m *mockScraper) FetchByISBN(ctx context.Context, isbn string) (string, error) {
 	if m.fetchByISBNFunc != nil {
 		return m.fetchByISBNFunc(isbn)
 	}
@@ -60,7 +70,7 @@ func TestGetByTitleAuthor_CacheHit(t *testing.T) {
 
 	service := NewBookcoverService(mockScraper, mockCache)
 
-	url, err := service.GetByTitleAuthor("test book", "test author", "")
+	url, err := service.GetByTitleAuthor(context.Background(), "test book", "test author", ImageOptions{})
 	if err != nil {
 		t.Errorf("GetByTitleAuthor() error = %v", err)
 	}
@@ -85,7 +95,7 @@ func TestGetByTitleAuthor_CacheMiss_ScraperSuccess(t *testing.T) {
 	mockCache := mocks.NewMockCache()
 	service := NewBookcoverService(mockScraper, mockCache)
 
-	url, err := service.GetByTitleAuthor("test book", "test author", "")
+	url, err := service.GetByTitleAuthor(context.Background(), "test book", "test author", ImageOptions{})
 	if err != nil {
 		t.Errorf("GetByTitleAuthor() error = %v", err)
 	}
@@ -97,9 +107,18 @@ func TestGetByTitleAuthor_CacheMiss_ScraperSuccess(t *testing.T) {
 	cachedItem, _ := mockCache.Get("test+book+test+author")
 	if cachedItem == nil {
 		t.Error("Expected item to be cached, but cache is empty")
-	} else if string(cachedItem.Value) != expectedURL {
-		t.Errorf("Cached value = %v, want %v", string(cachedItem.Value), expectedURL)
+	} else if record := decodeRecord(t, cachedItem.Value); record.URL != expectedURL || record.Source != "mock" {
+		t.Errorf("Cached record = %+v, want URL %v from mock", record, expectedURL)
+	}
+}
+
+func decodeRecord(t *testing.T, value []byte) cacheRecord {
+	t.Helper()
+	var record cacheRecord
+	if err := json.Unmarshal(value, &record); err != nil {
+		t.Fatalf("cached value %q is not a record: %v", value, err)
 	}
+	return record
 }
 
 func TestGetByTitleAuthor_ScraperError(t *testing.T) {
@@ -114,7 +133,7 @@ func TestGetByTitleAuthor_ScraperError(t *testing.T) {
 	mockCache := mocks.NewMockCache()
 	service := NewBookcoverService(mockScraper, mockCache)
 
-	_, err := service.GetByTitleAuthor("test book", "test author", "")
+	_, err := service.GetByTitleAuthor(context.Background(), "test book", "test author", ImageOptions{})
 	if err == nil {
 		t.Error("GetByTitleAuthor() expected error, got nil")
 	}
@@ -141,7 +160,7 @@ func TestGetByISBN_CacheHit(t *testing.T) {
 
 	service := NewBookcoverService(mockScraper, mockCache)
 
-	url, err := service.GetByISBN("978-0345376596", "")
+	url, err := service.GetByISBN(context.Background(), "978-0345376596", ImageOptions{})
 	if err != nil {
 		t.Errorf("GetByISBN() error = %v", err)
 	}
@@ -166,7 +185,7 @@ func TestGetByISBN_CacheMiss_ScraperSuccess(t *testing.T) {
 	mockCache := mocks.NewMockCache()
 	service := NewBookcoverService(mockScraper, mockCache)
 
-	url, err := service.GetByISBN("978-0345376596", "")
+	url, err := service.GetByISBN(context.Background(), "978-0345376596", ImageOptions{})
 	if err != nil {
 		t.Errorf("GetByISBN() error = %v", err)
 	}
@@ -179,8 +198,8 @@ func TestGetByISBN_CacheMiss_ScraperSuccess(t *testing.T) {
 	cachedItem, _ := mockCache.Get("9780345376596")
 	if cachedItem == nil {
 		t.Error("Expected item to be cached, but cache is empty")
-	} else if string(cachedItem.Value) != expectedURL {
-		t.Errorf("Cached value = %v, want %v", string(cachedItem.Value), expectedURL)
+	} else if record := decodeRecord(t, cachedItem.Value); record.URL != expectedURL || record.Source != "mock" {
+		t.Errorf("Cached record = %+v, want URL %v from mock", record, expectedURL)
 	}
 }
 
@@ -196,7 +215,7 @@ func TestGetByISBN_ScraperError(t *testing.T) {
 	mockCache := mocks.NewMockCache()
 	service := NewBookcoverService(mockScraper, mockCache)
 
-	_, err := service.GetByISBN("978-0000000000", "")
+	_, err := service.GetByISBN(context.Background(), "978-0000000000", ImageOptions{})
 	if err == nil {
 		t.Error("GetByISBN() expected error, got nil")
 	}
@@ -216,8 +235,9 @@ func TestGetByTitleAuthor_NilCache(t *testing.T) {
 	}
 
 	service := NewBookcoverService(mockScraper, nil)
+	cached := GetMetricsStats().NewBooksCached
 
-	url, err := service.GetByTitleAuthor("test book", "test author", "")
+	url, err := service.GetByTitleAuthor(context.Background(), "test book", "test author", ImageOptions{})
 	if err != nil {
 		t.Errorf("GetByTitleAuthor() with nil cache error = %v", err)
 	}
@@ -225,6 +245,9 @@ func TestGetByTitleAuthor_NilCache(t *testing.T) {
 	if url != expectedURL {
 		t.Errorf("GetByTitleAuthor() = %v, want %v", url, expectedURL)
 	}
+	if n := GetMetricsStats().NewBooksCached; n != cached {
+		t.Errorf("Expected no new book to be counted without a cache, got %d more", n-cached)
+	}
 }
 
 func TestGetByISBN_NilCache(t *testing.T) {
@@ -238,7 +261,7 @@ func TestGetByISBN_NilCache(t *testing.T) {
 
 	service := NewBookcoverService(mockScraper, nil)
 
-	url, err := service.GetByISBN("978-0345376596", "")
+	url, err := service.GetByISBN(context.Background(), "978-0345376596", ImageOptions{})
 	if err != nil {
 		t.Errorf("GetByISBN() with nil cache error = %v", err)
 	}
@@ -282,7 +305,7 @@ func TestGetByTitleAuthor_CacheGetError_FallsBackToScraper(t *testing.T) {
 
 	svc := NewBookcoverService(ms, &errCache{getErr: errors.New("connection refused")})
 
-	url, err := svc.GetByTitleAuthor("test book", "test author", "")
+	url, err := svc.GetByTitleAuthor(context.Background(), "test book", "test author", ImageOptions{})
 	if err != nil {
 		t.Errorf("GetByTitleAuthor() unexpected error: %v", err)
 	}
@@ -302,7 +325,7 @@ func TestGetByISBN_CacheGetError_FallsBackToScraper(t *testing.T) {
 
 	svc := NewBookcoverService(ms, &errCache{getErr: errors.New("connection refused")})
 
-	url, err := svc.GetByISBN("978-0345376596", "")
+	url, err := svc.GetByISBN(context.Background(), "978-0345376596", ImageOptions{})
 	if err != nil {
 		t.Errorf("GetByISBN() unexpected error: %v", err)
 	}
@@ -327,7 +350,7 @@ func TestGetByTitleAuthor_CacheSetError_StillReturnsURL(t *testing.T) {
 		setErr: errors.New("set error"),
 	})
 
-	url, err := svc.GetByTitleAuthor("test book", "test author", "")
+	url, err := svc.GetByTitleAuthor(context.Background(), "test book", "test author", ImageOptions{})
 	if err != nil {
 		t.Errorf("GetByTitleAuthor() unexpected error: %v", err)
 	}
@@ -350,7 +373,7 @@ func TestGetByISBN_CacheSetError_StillReturnsURL(t *testing.T) {
 		setErr: errors.New("set error"),
 	})
 
-	url, err := svc.GetByISBN("978-0345376596", "")
+	url, err := svc.GetByISBN(context.Background(), "978-0345376596", ImageOptions{})
 	if err != nil {
 		t.Errorf("GetByISBN() unexpected error: %v", err)
 	}
@@ -359,7 +382,7 @@ func TestGetByISBN_CacheSetError_StillReturnsURL(t *testing.T) {
 	}
 }
 
-func TestApplyImageSize(t *testing.T) {
+func TestApplyImageOptions(t *testing.T) {
 	baseURL := "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458.jpg"
 
 	tests := []struct {
@@ -370,12 +393,12 @@ func TestApplyImageSize(t *testing.T) {
 		{
 			name:      "small image size",
 			imageSize: "small",
-			expected:  "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458.__SY75__.jpg",
+			expected:  "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458._SY75_.jpg",
 		},
 		{
 			name:      "medium image size",
 			imageSize: "medium",
-			expected:  "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458.__SY375__.jpg",
+			expected:  "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458._SY375_.jpg",
 		},
 		{
 			name:      "large image size returns original",
@@ -387,29 +410,138 @@ func TestApplyImageSize(t *testing.T) {
 			imageSize: "",
 			expected:  baseURL,
 		},
-		{
-			name:      "invalid image size returns original",
-			imageSize: "xlarge",
-			expected:  baseURL,
-		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := applyImageSize(baseURL, tt.imageSize)
+			result := applyImageOptions(baseURL, ImageOptions{Size: tt.imageSize})
 			if result != tt.expected {
-				t.Errorf("applyImageSize(%q, %q) = %q, want %q", baseURL, tt.imageSize, result, tt.expected)
+				t.Errorf("applyImageOptions(%q, %q) = %q, want %q", baseURL, tt.imageSize, result, tt.expected)
 			}
 		})
 	}
 }
 
-func TestApplyImageSize_PngExtension(t *testing.T) {
-	url := "https://example.com/image.png"
-	result := applyImageSize(url, "small")
-	expected := "https://example.com/image.__SY75__.png"
+func TestApplyImageOptions_PngExtension(t *testing.T) {
+	url := "https://m.media-amazon.com/images/I/51Yf1dbJd2L.png"
+	result := applyImageOptions(url, ImageOptions{Size: ImageSizeSmall})
+	expected := "https://m.media-amazon.com/images/I/51Yf1dbJd2L._SY75_.png"
 	if result != expected {
-		t.Errorf("applyImageSize() = %q, want %q", result, expected)
+		t.Errorf("applyImageOptions() = %q, want %q", result, expected)
+	}
+}
+
+func TestApplyImageOptions_UnsupportedHostUnchanged(t *testing.T) {
+	url := "https://example.com/image.png"
+	if got := applyImageOptions(url, ImageOptions{Size: ImageSizeSmall}); got != url {
+		t.Errorf("applyImageOptions() = %q, want %q", got, url)
+	}
+}
+
+func TestApplyImageOptions_Dimensions(t *testing.T) {
+	baseURL := "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458._SX98_.jpg"
+	prefix := "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458."
+
+	tests := []struct {
+		name     string
+		opts     ImageOptions
+		expected string
+	}{
+		{"width only", ImageOptions{Width: 300}, prefix + "_SX300_.jpg"},
+		{"height only", ImageOptions{Height: 450}, prefix + "_SY450_.jpg"},
+		{"contain", ImageOptions{Width: 300, Height: 450, Fit: FitContain}, prefix + "_SX300_SY450_.jpg"},
+		{"pad", ImageOptions{Width: 300, Height: 450, Fit: FitPad}, prefix + "_SR300,450_.jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyImageOptions(baseURL, tt.opts); got != tt.expected {
+				t.Errorf("applyImageOptions(%+v) = %q, want %q", tt.opts, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestImageOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     ImageOptions
+		expected error
+	}{
+		{"empty", ImageOptions{}, nil},
+		{"named size", ImageOptions{Size: ImageSizeMedium}, nil},
+		{"dimensions", ImageOptions{Width: 300, Height: 450}, nil},
+		{"unknown size", ImageOptions{Size: "xlarge"}, ErrInvalidImageSize},
+		{"too wide", ImageOptions{Width: MaxImageDimension + 1}, ErrInvalidImageDimensions},
+		{"size with dimensions", ImageOptions{Size: ImageSizeSmall, Width: 100}, ErrConflictingImageParams},
+		{"contain without dimensions", ImageOptions{Fit: FitContain}, ErrInvalidFit},
+		{"pad without height", ImageOptions{Width: 300, Fit: FitPad}, ErrInvalidFit},
+		{"unknown fit", ImageOptions{Width: 300, Fit: "stretch"}, ErrInvalidFit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); err != tt.expected {
+				t.Errorf("Validate() = %v, want %v", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestQuery_ValidateBook(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    Query
+		expected error
+	}{
+		{"isbn", Query{ISBN: "978-0345376596"}, nil},
+		{"title and author", Query{BookTitle: "Dune", AuthorName: "Frank Herbert"}, nil},
+		{"short isbn", Query{ISBN: "123"}, ErrInvalidISBN},
+		{"isbn and title", Query{ISBN: "9780345376596", BookTitle: "Dune"}, ErrConflictingParams},
+		{"title only", Query{BookTitle: "Dune"}, ErrMissingParams},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.query.ValidateBook(); err != tt.expected {
+				t.Errorf("ValidateBook() = %v, want %v", err, tt.expected)
+			}
+		})
+	}
+
+	query := Query{ISBN: "978-0345376596"}
+	query.ValidateBook()
+	if query.ISBN != "9780345376596" {
+		t.Errorf("Expected the dashes to be stripped, got %s", query.ISBN)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err     error
+		failure Failure
+	}{
+		{scraper.ErrNotFound, FailureNotFound},
+		{scraper.ErrCircuitOpen, FailureProviderUnavailable},
+		{scraper.ErrBudgetExhausted, FailureProviderBusy},
+		{fmt.Errorf("fetch: %w", scraper.ErrLoginRequired), FailureProviderBlocked},
+		{scraper.ErrUnknownLayout, FailureProviderLayoutChanged},
+	}
+	for _, tt := range tests {
+		if failure, _ := Classify(tt.err); failure != tt.failure {
+			t.Errorf("Classify(%v) = %v, want %v", tt.err, failure, tt.failure)
+		}
+	}
+}
+
+func TestApplyImageOptions_ReplacesExistingSize(t *testing.T) {
+	url := "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458._SX98_.jpg"
+
+	if got := applyImageOptions(url, ImageOptions{Size: ImageSizeMedium}); got != "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458._SY375_.jpg" {
+		t.Errorf("applyImageOptions() = %q, want existing size token replaced", got)
+	}
+	if got := applyImageOptions(url, ImageOptions{Size: ImageSizeLarge}); got != "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458.jpg" {
+		t.Errorf("applyImageOptions() = %q, want size token removed", got)
 	}
 }
 
@@ -425,12 +557,12 @@ func TestGetByTitleAuthor_WithImageSize(t *testing.T) {
 	mockCache := mocks.NewMockCache()
 	svc := NewBookcoverService(ms, mockCache)
 
-	url, err := svc.GetByTitleAuthor("test book", "test author", "small")
+	url, err := svc.GetByTitleAuthor(context.Background(), "test book", "test author", ImageOptions{Size: "small"})
 	if err != nil {
 		t.Errorf("GetByTitleAuthor() unexpected error: %v", err)
 	}
 
-	expected := "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458.__SY75__.jpg"
+	expected := "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458._SY75_.jpg"
 	if url != expected {
 		t.Errorf("GetByTitleAuthor() = %v, want %v", url, expected)
 	}
@@ -448,12 +580,12 @@ func TestGetByISBN_WithImageSize(t *testing.T) {
 	mockCache := mocks.NewMockCache()
 	svc := NewBookcoverService(ms, mockCache)
 
-	url, err := svc.GetByISBN("978-0345376596", "medium")
+	url, err := svc.GetByISBN(context.Background(), "978-0345376596", ImageOptions{Size: "medium"})
 	if err != nil {
 		t.Errorf("GetByISBN() unexpected error: %v", err)
 	}
 
-	expected := "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458.__SY375__.jpg"
+	expected := "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458._SY375_.jpg"
 	if url != expected {
 		t.Errorf("GetByISBN() = %v, want %v", url, expected)
 	}
@@ -477,13 +609,292 @@ func TestGetByTitleAuthor_CacheHit_WithImageSize(t *testing.T) {
 
 	svc := NewBookcoverService(ms, mockCache)
 
-	url, err := svc.GetByTitleAuthor("test book", "test author", "small")
+	url, err := svc.GetByTitleAuthor(context.Background(), "test book", "test author", ImageOptions{Size: "small"})
 	if err != nil {
 		t.Errorf("GetByTitleAuthor() unexpected error: %v", err)
 	}
 
-	expected := "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458.__SY75__.jpg"
+	expected := "https://i.gr-assets.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458._SY75_.jpg"
 	if url != expected {
 		t.Errorf("GetByTitleAuthor() = %v, want %v", url, expected)
 	}
 }
+
+func TestGetByISBN_SkipsUnavailableProvider(t *testing.T) {
+	expectedURL := "https://example.com/fallback-cover.jpg"
+
+	unavailable := &mockScraper{
+		fetchByISBNFunc: func(isbn string) (string, error) {
+			return "", scraper.ErrCircuitOpen
+		},
+	}
+	fallback := &mockScraper{
+		fetchByISBNFunc: func(isbn string) (string, error) {
+			return expectedURL, nil
+		},
+	}
+
+	svc := NewBookcoverServiceWithConfig(mocks.NewMockCache(), Config{
+		Providers: []scraper.Scraper{unavailable, fallback},
+	})
+
+	url, err := svc.GetByISBN(context.Background(), "978-0345376596", ImageOptions{})
+	if err != nil {
+		t.Fatalf("GetByISBN() unexpected error: %v", err)
+	}
+	if url != expectedURL {
+		t.Errorf("GetByISBN() = %v, want %v", url, expectedURL)
+	}
+}
+
+func TestGetByISBN_AllProvidersUnavailable(t *testing.T) {
+	unavailable := &mockScraper{
+		fetchByISBNFunc: func(isbn string) (string, error) {
+			return "", scraper.ErrCircuitOpen
+		},
+	}
+
+	svc := NewBookcoverServiceWithConfig(mocks.NewMockCache(), Config{
+		Providers: []scraper.Scraper{unavailable, unavailable},
+	})
+
+	_, err := svc.GetByISBN(context.Background(), "978-0345376596", ImageOptions{})
+	if !errors.Is(err, scraper.ErrCircuitOpen) {
+		t.Errorf("GetByISBN() error = %v, want %v", err, scraper.ErrCircuitOpen)
+	}
+}
+
+type stubAnalyzer struct {
+	calls int
+	info  *imageinfo.Info
+	err   error
+}
+
+func (a *stubAnalyzer) Analyze(ctx context.Context, url string) (*imageinfo.Info, error) {
+	a.calls++
+	return a.info, a.err
+}
+
+func TestLookup_ImageInfoIsCached(t *testing.T) {
+	ms := &mockScraper{
+		fetchByISBNFunc: func(isbn string) (string, error) {
+			return "https://example.com/cover.jpg", nil
+		},
+	}
+	analyzer := &stubAnalyzer{info: &imageinfo.Info{Width: 300, Height: 450, MIMEType: "image/jpeg"}}
+	mockCache := mocks.NewMockCache()
+	svc := NewBookcoverServiceWithConfig(mockCache, Config{
+		Providers: []scraper.Scraper{ms},
+		Analyzer:  analyzer,
+	})
+
+	for i := 0; i < 2; i++ {
+		cover, err := svc.Lookup(context.Background(), Query{ISBN: "9780345376596", IncludeImageInfo: true})
+		if err != nil {
+			t.Fatalf("Lookup() unexpected error: %v", err)
+		}
+		if cover.ImageInfo == nil || cover.ImageInfo.Width != 300 {
+			t.Fatalf("Lookup() image info = %+v, want width 300", cover.ImageInfo)
+		}
+	}
+	if analyzer.calls != 1 {
+		t.Errorf("Analyze() called %d times, want 1", analyzer.calls)
+	}
+
+	// The URL is still readable by lookups that do not ask for image info.
+	url, err := svc.GetByISBN(context.Background(), "9780345376596", ImageOptions{})
+	if err != nil {
+		t.Fatalf("GetByISBN() unexpected error: %v", err)
+	}
+	if url != "https://example.com/cover.jpg" {
+		t.Errorf("GetByISBN() = %v, want https://example.com/cover.jpg", url)
+	}
+}
+
+func TestLookup_ImageInfoFromLegacyCacheEntry(t *testing.T) {
+	mockCache := mocks.NewMockCache()
+	mockCache.Set(&memcache.Item{Key: "9780345376596", Value: []byte("https://example.com/cover.jpg")})
+	analyzer := &stubAnalyzer{info: &imageinfo.Info{Width: 300, Height: 450}}
+	svc := NewBookcoverServiceWithConfig(mockCache, Config{Analyzer: analyzer})
+
+	cover, err := svc.Lookup(context.Background(), Query{ISBN: "9780345376596", IncludeImageInfo: true})
+	if err != nil {
+		t.Fatalf("Lookup() unexpected error: %v", err)
+	}
+	if cover.URL != "https://example.com/cover.jpg" || cover.ImageInfo == nil {
+		t.Errorf("Lookup() = %+v, want the cached URL with image info", cover)
+	}
+}
+
+func TestLookup_ImageInfoFailureStillReturnsCover(t *testing.T) {
+	ms := &mockScraper{
+		fetchByISBNFunc: func(isbn string) (string, error) {
+			return "https://example.com/cover.jpg", nil
+		},
+	}
+	analyzer := &stubAnalyzer{err: errors.New("upstream returned 500")}
+	svc := NewBookcoverServiceWithConfig(mocks.NewMockCache(), Config{
+		Providers: []scraper.Scraper{ms},
+		Analyzer:  analyzer,
+	})
+
+	for i := 0; i < 2; i++ {
+		cover, err := svc.Lookup(context.Background(), Query{ISBN: "9780345376596", IncludeImageInfo: true})
+		if err != nil {
+			t.Fatalf("Lookup() unexpected error: %v", err)
+		}
+		if cover.URL != "https://example.com/cover.jpg" {
+			t.Errorf("Lookup() URL = %v, want https://example.com/cover.jpg", cover.URL)
+		}
+		if cover.ImageInfo != nil {
+			t.Errorf("Lookup() image info = %+v, want nil", cover.ImageInfo)
+		}
+	}
+	if analyzer.calls != 1 {
+		t.Errorf("Analyze() called %d times, want 1", analyzer.calls)
+	}
+}
+
+func TestLookup_ImageInfoRetriedAfterDelay(t *testing.T) {
+	mockCache := mocks.NewMockCache()
+	failedAt := time.Now().Add(-analyzeRetryDelay - time.Minute).Unix()
+	value := fmt.Sprintf(`{"url":"https://example.com/cover.jpg","image_info_failed_at":%d}`, failedAt)
+	mockCache.Set(&memcache.Item{Key: "9780345376596", Value: []byte(value)})
+	analyzer := &stubAnalyzer{info: &imageinfo.Info{Width: 300, Height: 450}}
+	svc := NewBookcoverServiceWithConfig(mockCache, Config{Analyzer: analyzer})
+
+	cover, err := svc.Lookup(context.Background(), Query{ISBN: "9780345376596", IncludeImageInfo: true})
+	if err != nil {
+		t.Fatalf("Lookup() unexpected error: %v", err)
+	}
+	if analyzer.calls != 1 || cover.ImageInfo == nil {
+		t.Errorf("Lookup() image info = %+v after %d Analyze() calls, want it analyzed once", cover.ImageInfo, analyzer.calls)
+	}
+}
+
+func TestLookup_SourceAndCacheStatus(t *testing.T) {
+	ms := &mockScraper{
+		fetchByISBNFunc: func(isbn string) (string, error) {
+			return "https://example.com/cover.jpg", nil
+		},
+	}
+	svc := NewBookcoverService(ms, mocks.NewMockCache())
+
+	for _, want := range []CacheStatus{CacheMiss, CacheHit} {
+		cover, err := svc.Lookup(context.Background(), Query{ISBN: "9780345376596"})
+		if err != nil {
+			t.Fatalf("Lookup() unexpected error: %v", err)
+		}
+		if cover.Source != "mock" || cover.CacheStatus != want {
+			t.Errorf("Lookup() source = %q, cache = %q, want mock, %q", cover.Source, cover.CacheStatus, want)
+		}
+	}
+}
+
+func TestLookup_Freshness(t *testing.T) {
+	ms := &mockScraper{
+		fetchByISBNFunc: func(isbn string) (string, error) {
+			return "https://example.com/cover.jpg", nil
+		},
+	}
+	svc := NewBookcoverServiceWithConfig(mocks.NewMockCache(), Config{
+		Providers: []scraper.Scraper{ms},
+		MaxAge:    time.Hour,
+	})
+
+	cover, err := svc.Lookup(context.Background(), Query{ISBN: "9780345376596"})
+	if err != nil {
+		t.Fatalf("Lookup() unexpected error: %v", err)
+	}
+	if time.Since(cover.CachedAt) > time.Minute || cover.MaxAge != time.Hour {
+		t.Errorf("Lookup() cached at %v with max age %v, want now and 1h", cover.CachedAt, cover.MaxAge)
+	}
+	if fresh, ok := cover.FreshFor(); !ok || fresh <= 59*time.Minute || fresh > time.Hour {
+		t.Errorf("FreshFor() = %v, %v, want about 1h", fresh, ok)
+	}
+}
+
+func TestLookup_LegacyCacheEntryIsHit(t *testing.T) {
+	mockCache := mocks.NewMockCache()
+	mockCache.Set(&memcache.Item{Key: "9780345376596", Value: []byte("https://example.com/cover.jpg")})
+	svc := NewBookcoverServiceWithConfig(mockCache, Config{MaxAge: time.Hour})
+
+	cover, err := svc.Lookup(context.Background(), Query{ISBN: "9780345376596"})
+	if err != nil {
+		t.Fatalf("Lookup() unexpected error: %v", err)
+	}
+	if cover.CacheStatus != CacheHit || cover.Source != "" {
+		t.Errorf("Lookup() = %+v, want a hit without source", cover)
+	}
+	if _, ok := cover.FreshFor(); ok {
+		t.Errorf("FreshFor() ok for a cover cached without its age")
+	}
+}
+
+func TestLookup_StaleEntryIsRefreshed(t *testing.T) {
+	var calls atomic.Int32
+	ms := &mockScraper{
+		fetchByISBNFunc: func(isbn string) (string, error) {
+			calls.Add(1)
+			return "https://example.com/new.jpg", nil
+		},
+	}
+	mockCache := mocks.NewMockCache()
+	old := fmt.Sprintf(`{"url":"https://example.com/old.jpg","source":"mock","cached_at":%d}`,
+		time.Now().Add(-2*time.Hour).Unix())
+	mockCache.Set(&memcache.Item{Key: "9780345376596", Value: []byte(old)})
+	svc := NewBookcoverServiceWithConfig(mockCache, Config{
+		Providers: []scraper.Scraper{ms},
+		MaxAge:    time.Hour,
+	})
+
+	cover, err := svc.Lookup(context.Background(), Query{ISBN: "9780345376596"})
+	if err != nil {
+		t.Fatalf("Lookup() unexpected error: %v", err)
+	}
+	if cover.URL != "https://example.com/old.jpg" || cover.CacheStatus != CacheStale {
+		t.Fatalf("Lookup() = %+v, want the old URL served as stale", cover)
+	}
+	if fresh, ok := cover.FreshFor(); !ok || fresh != 0 {
+		t.Errorf("FreshFor() = %v, %v, want 0, true for a stale cover", fresh, ok)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		item, _ := mockCache.Get("9780345376596")
+		if decodeRecord(t, item.Value).URL == "https://example.com/new.jpg" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("stale entry was not refreshed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cover, err = svc.Lookup(context.Background(), Query{ISBN: "9780345376596"})
+	if err != nil {
+		t.Fatalf("Lookup() unexpected error: %v", err)
+	}
+	if cover.URL != "https://example.com/new.jpg" || cover.CacheStatus != CacheHit {
+		t.Errorf("Lookup() = %+v, want the refreshed URL as a hit", cover)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("scraper called %d times, want 1", calls.Load())
+	}
+}
+
+func TestLookup_CanceledContext(t *testing.T) {
+	scraper := &mockScraper{
+		fetchByISBNFunc: func(isbn string) (string, error) {
+			t.Error("Scraper should not be called once the caller gave up")
+			return "", nil
+		},
+	}
+	service := NewBookcoverService(scraper, mocks.NewMockCache())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := service.Lookup(ctx, Query{ISBN: "9780345376596"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Lookup() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -1,6 +1,211 @@
 package service
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bookcover-api/internal/config"
+	"bookcover-api/internal/imageinfo"
+	"bookcover-api/internal/scraper"
+)
+
+const (
+	ImageSizeSmall  = "small"
+	ImageSizeMedium = "medium"
+	ImageSizeLarge  = "large"
+
+	// FitContain scales the cover to fit within width x height.
+	FitContain = "contain"
+	// FitPad scales the cover to fit and pads it to exactly width x height.
+	FitPad = "pad"
+
+	MaxImageDimension = 2000
+)
+
+var (
+	ErrInvalidImageSize       = errors.New(config.InvalidImageSize)
+	ErrInvalidImageDimensions = errors.New(config.InvalidImageDimensions)
+	ErrInvalidFit             = errors.New(config.InvalidFit)
+	ErrConflictingImageParams = errors.New(config.ConflictingImageParams)
+	ErrConflictingParams      = errors.New(config.ConflictingParams)
+	ErrInvalidISBN            = errors.New(config.InvalidISBN)
+	ErrMissingParams          = errors.New(config.MandidatoryParamsMissing)
+)
+
+// ImageOptions describes the cover variant a client asked for.
+type ImageOptions struct {
+	Size   string
+	Width  int
+	Height int
+	Fit    string
+}
+
+func (o ImageOptions) Validate() error {
+	switch o.Size {
+	case "", ImageSizeSmall, ImageSizeMedium, ImageSizeLarge:
+	default:
+		return ErrInvalidImageSize
+	}
+
+	if o.Width < 0 || o.Height < 0 || o.Width > MaxImageDimension || o.Height > MaxImageDimension {
+		return ErrInvalidImageDimensions
+	}
+	if o.Size != "" && (o.Width > 0 || o.Height > 0) {
+		return ErrConflictingImageParams
+	}
+
+	switch o.Fit {
+	case "":
+	case FitContain:
+		if o.Width == 0 && o.Height == 0 {
+			return ErrInvalidFit
+		}
+	case FitPad:
+		if o.Width == 0 || o.Height == 0 {
+			return ErrInvalidFit
+		}
+	default:
+		return ErrInvalidFit
+	}
+	return nil
+}
+
+// Dimensions returns the bounding box asked for through either image_size or
+// width/height. Zero means unconstrained.
+func (o ImageOptions) Dimensions() (width, height int) {
+	switch o.Size {
+	case ImageSizeSmall:
+		return 0, 75
+	case ImageSizeMedium:
+		return 0, 375
+	}
+	return o.Width, o.Height
+}
+
+// Variant identifies the rendition described by the options, e.g. for cache keys.
+func (o ImageOptions) Variant() string {
+	if o.Width > 0 || o.Height > 0 {
+		variant := fmt.Sprintf("%dx%d", o.Width, o.Height)
+		if o.Fit != "" {
+			variant += "-" + o.Fit
+		}
+		return variant
+	}
+	if o.Size == "" {
+		return ImageSizeLarge
+	}
+	return o.Size
+}
+
+// Query describes a cover lookup, either by ISBN or by title and author.
+type Query struct {
+	ISBN       string
+	BookTitle  string
+	AuthorName string
+	Options    ImageOptions
+	// IncludeImageInfo asks for the dimensions, colors and BlurHash of the cover.
+	IncludeImageInfo bool
+}
+
+// ValidateBook checks that the query names a book either by ISBN or by title
+// and author, and strips the dashes from the ISBN.
+func (q *Query) ValidateBook() error {
+	if q.ISBN != "" && (q.BookTitle != "" || q.AuthorName != "") {
+		return ErrConflictingParams
+	}
+	if q.ISBN != "" {
+		q.ISBN = strings.ReplaceAll(q.ISBN, "-", "")
+		if len(q.ISBN) != 13 {
+			return ErrInvalidISBN
+		}
+	} else if q.BookTitle == "" || q.AuthorName == "" {
+		return ErrMissingParams
+	}
+	return nil
+}
+
+// Failure is the kind of a failed lookup. The HTTP and gRPC APIs each map it
+// to their own status codes.
+type Failure int
+
+const (
+	FailureNotFound Failure = iota
+	FailureProviderUnavailable
+	FailureProviderBusy
+	FailureProviderBlocked
+	FailureProviderLayoutChanged
+)
+
+// Classify returns the kind of a lookup error and the message for clients.
+func Classify(err error) (Failure, string) {
+	switch {
+	case errors.Is(err, scraper.ErrCircuitOpen):
+		return FailureProviderUnavailable, config.ProviderUnavailable
+	case errors.Is(err, scraper.ErrBudgetExhausted):
+		return FailureProviderBusy, config.ProviderBusy
+	case errors.Is(err, scraper.ErrBlocked), errors.Is(err, scraper.ErrLoginRequired):
+		return FailureProviderBlocked, config.ProviderBlocked
+	case errors.Is(err, scraper.ErrUnknownLayout):
+		return FailureProviderLayoutChanged, config.ProviderLayoutChanged
+	}
+	return FailureNotFound, err.Error()
+}
+
+// CacheStatus tells how a cover was served from the cache.
+type CacheStatus string
+
+const (
+	CacheHit   CacheStatus = "hit"
+	CacheMiss  CacheStatus = "miss"
+	CacheStale CacheStatus = "stale"
+)
+
+// Cover is the result of a lookup. ImageInfo describes the full-size cover and
+// is only set when it was asked for and could be computed. Source names the
+// provider that found the cover; it is empty for covers cached before it was
+// recorded.
+type Cover struct {
+	URL         string
+	ImageInfo   *imageinfo.Info
+	Source      string
+	CacheStatus CacheStatus
+	// CachedAt is when the cover was looked up from its provider. It is zero
+	// for covers cached before it was recorded.
+	CachedAt time.Time
+	// MaxAge is how long the cover stays fresh after CachedAt. Zero never
+	// expires.
+	MaxAge time.Duration
+}
+
+// FreshFor returns how much longer the cover stays fresh, and false when it
+// never goes stale.
+func (c *Cover) FreshFor() (time.Duration, bool) {
+	if c.MaxAge <= 0 || c.CachedAt.IsZero() {
+		return 0, false
+	}
+	return max(0, c.MaxAge-time.Since(c.CachedAt)), true
+}
+
+// Resized returns the cover URL with opts applied, as a lookup with those
+// options would have returned it.
+func (c *Cover) Resized(opts ImageOptions) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+	return applyImageOptions(c.URL, opts), nil
+}
+
+// ImageAnalyzer downloads a cover and describes it.
+type ImageAnalyzer interface {
+	Analyze(ctx context.Context, url string) (*imageinfo.Info, error)
+}
+
 type BookcoverService interface {
-	GetByTitleAuthor(bookTitle, authorName, imageSize string) (string, error)
-	GetByISBN(isbn, imageSize string) (string, error)
+	GetByTitleAuthor(ctx context.Context, bookTitle, authorName string, opts ImageOptions) (string, error)
+	GetByISBN(ctx context.Context, isbn string, opts ImageOptions) (string, error)
+	// Lookup finds the cover of a book. Scrapes stop when ctx is done.
+	Lookup(ctx context.Context, q Query) (*Cover, error)
 }
@@ -2,14 +2,17 @@ package mocks
 
 import (
 	"fmt"
+	"sync"
 
 	"bookcover-api/internal/cache"
 
 	"github.com/bradfitz/gomemcache/memcache"
 )
 
-// MockMemcacheClient implements CacheClient interface for testing
+// MockMemcacheClient implements CacheClient interface for testing. Like the
+// real client, it is safe for concurrent use.
 type MockMemcacheClient struct {
+	mu    sync.Mutex
 	items map[string]*memcache.Item
 }
 
@@ -21,6 +24,9 @@ func NewMockCache() cache.CacheClient {
 }
 
 func (m *MockMemcacheClient) Get(key string) (*memcache.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if item, exists := m.items[key]; exists {
 		return item, nil
 	}
@@ -28,11 +34,17 @@ func (m *MockMemcacheClient) Get(key string) (*memcache.Item, error) {
 }
 
 func (m *MockMemcacheClient) Set(item *memcache.Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.items[item.Key] = item
 	return nil
 }
 
 func (m *MockMemcacheClient) Add(item *memcache.Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if _, exists := m.items[item.Key]; exists {
 		return memcache.ErrNotStored
 	}
@@ -41,6 +53,9 @@ func (m *MockMemcacheClient) Add(item *memcache.Item) error {
 }
 
 func (m *MockMemcacheClient) Increment(key string, delta uint64) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	item, exists := m.items[key]
 	if !exists {
 		return 0, memcache.ErrCacheMiss
@@ -56,5 +71,8 @@ func (m *MockMemcacheClient) Increment(key string, delta uint64) (uint64, error)
 
 // Reset clears all items from the mock cache
 func (m *MockMemcacheClient) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.items = make(map[string]*memcache.Item)
 }
@@ -0,0 +1,972 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        v6.32.1
+// source: bookcover/v1/bookcover.proto
+
+package bookcoverpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ImageSize int32
+
+const (
+	ImageSize_IMAGE_SIZE_UNSPECIFIED ImageSize = 0
+	ImageSize_IMAGE_SIZE_SMALL       ImageSize = 1
+	ImageSize_IMAGE_SIZE_MEDIUM      ImageSize = 2
+	ImageSize_IMAGE_SIZE_LARGE       ImageSize = 3
+)
+
+// Enum value maps for ImageSize.
+var (
+	ImageSize_name = map[int32]string{
+		0: "IMAGE_SIZE_UNSPECIFIED",
+		1: "IMAGE_SIZE_SMALL",
+		2: "IMAGE_SIZE_MEDIUM",
+		3: "IMAGE_SIZE_LARGE",
+	}
+	ImageSize_value = map[string]int32{
+		"IMAGE_SIZE_UNSPECIFIED": 0,
+		"IMAGE_SIZE_SMALL":       1,
+		"IMAGE_SIZE_MEDIUM":      2,
+		"IMAGE_SIZE_LARGE":       3,
+	}
+)
+
+func (x ImageSize) Enum() *ImageSize {
+	p := new(ImageSize)
+	*p = x
+	return p
+}
+
+func (x ImageSize) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ImageSize) Descriptor() protoreflect.EnumDescriptor {
+	return file_bookcover_v1_bookcover_proto_enumTypes[0].Descriptor()
+}
+
+func (ImageSize) Type() protoreflect.EnumType {
+	return &file_bookcover_v1_bookcover_proto_enumTypes[0]
+}
+
+func (x ImageSize) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ImageSize.Descriptor instead.
+func (ImageSize) EnumDescriptor() ([]byte, []int) {
+	return file_bookcover_v1_bookcover_proto_rawDescGZIP(), []int{0}
+}
+
+type Fit int32
+
+const (
+	Fit_FIT_UNSPECIFIED Fit = 0
+	// Scale the cover to fit within width x height.
+	Fit_FIT_CONTAIN Fit = 1
+	// Scale the cover to fit and pad it to exactly width x height.
+	Fit_FIT_PAD Fit = 2
+)
+
+// Enum value maps for Fit.
+var (
+	Fit_name = map[int32]string{
+		0: "FIT_UNSPECIFIED",
+		1: "FIT_CONTAIN",
+		2: "FIT_PAD",
+	}
+	Fit_value = map[string]int32{
+		"FIT_UNSPECIFIED": 0,
+		"FIT_CONTAIN":     1,
+		"FIT_PAD":         2,
+	}
+)
+
+func (x Fit) Enum() *Fit {
+	p := new(Fit)
+	*p = x
+	return p
+}
+
+func (x Fit) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Fit) Descriptor() protoreflect.EnumDescriptor {
+	return file_bookcover_v1_bookcover_proto_enumTypes[1].Descriptor()
+}
+
+func (Fit) Type() protoreflect.EnumType {
+	return &file_bookcover_v1_bookcover_proto_enumTypes[1]
+}
+
+func (x Fit) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Fit.Descriptor instead.
+func (Fit) EnumDescriptor() ([]byte, []int) {
+	return file_bookcover_v1_bookcover_proto_rawDescGZIP(), []int{1}
+}
+
+type CacheStatus int32
+
+const (
+	CacheStatus_CACHE_STATUS_UNSPECIFIED CacheStatus = 0
+	CacheStatus_CACHE_STATUS_HIT         CacheStatus = 1
+	CacheStatus_CACHE_STATUS_MISS        CacheStatus = 2
+	// Served from the cache while it is refreshed in the background.
+	CacheStatus_CACHE_STATUS_STALE CacheStatus = 3
+)
+
+// Enum value maps for CacheStatus.
+var (
+	CacheStatus_name = map[int32]string{
+		0: "CACHE_STATUS_UNSPECIFIED",
+		1: "CACHE_STATUS_HIT",
+		2: "CACHE_STATUS_MISS",
+		3: "CACHE_STATUS_STALE",
+	}
+	CacheStatus_value = map[string]int32{
+		"CACHE_STATUS_UNSPECIFIED": 0,
+		"CACHE_STATUS_HIT":         1,
+		"CACHE_STATUS_MISS":        2,
+		"CACHE_STATUS_STALE":       3,
+	}
+)
+
+func (x CacheStatus) Enum() *CacheStatus {
+	p := new(CacheStatus)
+	*p = x
+	return p
+}
+
+func (x CacheStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CacheStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_bookcover_v1_bookcover_proto_enumTypes[2].Descriptor()
+}
+
+func (CacheStatus) Type() protoreflect.EnumType {
+	return &file_bookcover_v1_bookcover_proto_enumTypes[2]
+}
+
+func (x CacheStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CacheStatus.Descriptor instead.
+func (CacheStatus) EnumDescriptor() ([]byte, []int) {
+	return file_bookcover_v1_bookcover_proto_rawDescGZIP(), []int{2}
+}
+
+// ImageOptions asks for a cover variant, either a named size or a bounding
+// box. Covers on CDNs that cannot resize are returned as-is.
+type ImageOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Size          ImageSize              `protobuf:"varint,1,opt,name=size,proto3,enum=bookcover.v1.ImageSize" json:"size,omitempty"`
+	Width         int32                  `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`
+	Height        int32                  `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	Fit           Fit                    `protobuf:"varint,4,opt,name=fit,proto3,enum=bookcover.v1.Fit" json:"fit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImageOptions) Reset() {
+	*x = ImageOptions{}
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImageOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImageOptions) ProtoMessage() {}
+
+func (x *ImageOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImageOptions.ProtoReflect.Descriptor instead.
+func (*ImageOptions) Descriptor() ([]byte, []int) {
+	return file_bookcover_v1_bookcover_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ImageOptions) GetSize() ImageSize {
+	if x != nil {
+		return x.Size
+	}
+	return ImageSize_IMAGE_SIZE_UNSPECIFIED
+}
+
+func (x *ImageOptions) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *ImageOptions) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *ImageOptions) GetFit() Fit {
+	if x != nil {
+		return x.Fit
+	}
+	return Fit_FIT_UNSPECIFIED
+}
+
+type GetCoverByISBNRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Isbn  string                 `protobuf:"bytes,1,opt,name=isbn,proto3" json:"isbn,omitempty"`
+	Image *ImageOptions          `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"`
+	// Compute the dimensions, colors and BlurHash of the full-size cover.
+	IncludeImageInfo bool `protobuf:"varint,3,opt,name=include_image_info,json=includeImageInfo,proto3" json:"include_image_info,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *GetCoverByISBNRequest) Reset() {
+	*x = GetCoverByISBNRequest{}
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCoverByISBNRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCoverByISBNRequest) ProtoMessage() {}
+
+func (x *GetCoverByISBNRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCoverByISBNRequest.ProtoReflect.Descriptor instead.
+func (*GetCoverByISBNRequest) Descriptor() ([]byte, []int) {
+	return file_bookcover_v1_bookcover_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetCoverByISBNRequest) GetIsbn() string {
+	if x != nil {
+		return x.Isbn
+	}
+	return ""
+}
+
+func (x *GetCoverByISBNRequest) GetImage() *ImageOptions {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+func (x *GetCoverByISBNRequest) GetIncludeImageInfo() bool {
+	if x != nil {
+		return x.IncludeImageInfo
+	}
+	return false
+}
+
+type GetCoverByTitleAuthorRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	BookTitle  string                 `protobuf:"bytes,1,opt,name=book_title,json=bookTitle,proto3" json:"book_title,omitempty"`
+	AuthorName string                 `protobuf:"bytes,2,opt,name=author_name,json=authorName,proto3" json:"author_name,omitempty"`
+	Image      *ImageOptions          `protobuf:"bytes,3,opt,name=image,proto3" json:"image,omitempty"`
+	// Compute the dimensions, colors and BlurHash of the full-size cover.
+	IncludeImageInfo bool `protobuf:"varint,4,opt,name=include_image_info,json=includeImageInfo,proto3" json:"include_image_info,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *GetCoverByTitleAuthorRequest) Reset() {
+	*x = GetCoverByTitleAuthorRequest{}
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCoverByTitleAuthorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCoverByTitleAuthorRequest) ProtoMessage() {}
+
+func (x *GetCoverByTitleAuthorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCoverByTitleAuthorRequest.ProtoReflect.Descriptor instead.
+func (*GetCoverByTitleAuthorRequest) Descriptor() ([]byte, []int) {
+	return file_bookcover_v1_bookcover_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetCoverByTitleAuthorRequest) GetBookTitle() string {
+	if x != nil {
+		return x.BookTitle
+	}
+	return ""
+}
+
+func (x *GetCoverByTitleAuthorRequest) GetAuthorName() string {
+	if x != nil {
+		return x.AuthorName
+	}
+	return ""
+}
+
+func (x *GetCoverByTitleAuthorRequest) GetImage() *ImageOptions {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+func (x *GetCoverByTitleAuthorRequest) GetIncludeImageInfo() bool {
+	if x != nil {
+		return x.IncludeImageInfo
+	}
+	return false
+}
+
+// BatchItem is one book of a batch, either an ISBN or a title and author.
+type BatchItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Isbn          string                 `protobuf:"bytes,1,opt,name=isbn,proto3" json:"isbn,omitempty"`
+	BookTitle     string                 `protobuf:"bytes,2,opt,name=book_title,json=bookTitle,proto3" json:"book_title,omitempty"`
+	AuthorName    string                 `protobuf:"bytes,3,opt,name=author_name,json=authorName,proto3" json:"author_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchItem) Reset() {
+	*x = BatchItem{}
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchItem) ProtoMessage() {}
+
+func (x *BatchItem) ProtoReflect() protoreflect.Message {
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchItem.ProtoReflect.Descriptor instead.
+func (*BatchItem) Descriptor() ([]byte, []int) {
+	return file_bookcover_v1_bookcover_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BatchItem) GetIsbn() string {
+	if x != nil {
+		return x.Isbn
+	}
+	return ""
+}
+
+func (x *BatchItem) GetBookTitle() string {
+	if x != nil {
+		return x.BookTitle
+	}
+	return ""
+}
+
+func (x *BatchItem) GetAuthorName() string {
+	if x != nil {
+		return x.AuthorName
+	}
+	return ""
+}
+
+type BatchGetCoversRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Items []*BatchItem           `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	// Applied to every item.
+	Image            *ImageOptions `protobuf:"bytes,2,opt,name=image,proto3" json:"image,omitempty"`
+	IncludeImageInfo bool          `protobuf:"varint,3,opt,name=include_image_info,json=includeImageInfo,proto3" json:"include_image_info,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *BatchGetCoversRequest) Reset() {
+	*x = BatchGetCoversRequest{}
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetCoversRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetCoversRequest) ProtoMessage() {}
+
+func (x *BatchGetCoversRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetCoversRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetCoversRequest) Descriptor() ([]byte, []int) {
+	return file_bookcover_v1_bookcover_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchGetCoversRequest) GetItems() []*BatchItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *BatchGetCoversRequest) GetImage() *ImageOptions {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+func (x *BatchGetCoversRequest) GetIncludeImageInfo() bool {
+	if x != nil {
+		return x.IncludeImageInfo
+	}
+	return false
+}
+
+type BatchGetCoversResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The position of the item in the request.
+	Index int32      `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Item  *BatchItem `protobuf:"bytes,2,opt,name=item,proto3" json:"item,omitempty"`
+	// Types that are valid to be assigned to Result:
+	//
+	//	*BatchGetCoversResponse_Cover
+	//	*BatchGetCoversResponse_Error
+	Result        isBatchGetCoversResponse_Result `protobuf_oneof:"result"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetCoversResponse) Reset() {
+	*x = BatchGetCoversResponse{}
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetCoversResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetCoversResponse) ProtoMessage() {}
+
+func (x *BatchGetCoversResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetCoversResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetCoversResponse) Descriptor() ([]byte, []int) {
+	return file_bookcover_v1_bookcover_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchGetCoversResponse) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *BatchGetCoversResponse) GetItem() *BatchItem {
+	if x != nil {
+		return x.Item
+	}
+	return nil
+}
+
+func (x *BatchGetCoversResponse) GetResult() isBatchGetCoversResponse_Result {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *BatchGetCoversResponse) GetCover() *Cover {
+	if x != nil {
+		if x, ok := x.Result.(*BatchGetCoversResponse_Cover); ok {
+			return x.Cover
+		}
+	}
+	return nil
+}
+
+func (x *BatchGetCoversResponse) GetError() *Error {
+	if x != nil {
+		if x, ok := x.Result.(*BatchGetCoversResponse_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isBatchGetCoversResponse_Result interface {
+	isBatchGetCoversResponse_Result()
+}
+
+type BatchGetCoversResponse_Cover struct {
+	Cover *Cover `protobuf:"bytes,3,opt,name=cover,proto3,oneof"`
+}
+
+type BatchGetCoversResponse_Error struct {
+	Error *Error `protobuf:"bytes,4,opt,name=error,proto3,oneof"`
+}
+
+func (*BatchGetCoversResponse_Cover) isBatchGetCoversResponse_Result() {}
+
+func (*BatchGetCoversResponse_Error) isBatchGetCoversResponse_Result() {}
+
+// Error is why the lookup of a batch item failed.
+type Error struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The gRPC status code the lookup would have failed with on its own.
+	Code          int32  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_bookcover_v1_bookcover_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Error) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Error) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type Cover struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Url   string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// The provider that found the cover. Empty for covers cached before it was
+	// recorded.
+	Source string      `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Cache  CacheStatus `protobuf:"varint,3,opt,name=cache,proto3,enum=bookcover.v1.CacheStatus" json:"cache,omitempty"`
+	// Set when asked for and it could be computed.
+	ImageInfo     *ImageInfo `protobuf:"bytes,4,opt,name=image_info,json=imageInfo,proto3" json:"image_info,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Cover) Reset() {
+	*x = Cover{}
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Cover) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Cover) ProtoMessage() {}
+
+func (x *Cover) ProtoReflect() protoreflect.Message {
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Cover.ProtoReflect.Descriptor instead.
+func (*Cover) Descriptor() ([]byte, []int) {
+	return file_bookcover_v1_bookcover_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Cover) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Cover) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Cover) GetCache() CacheStatus {
+	if x != nil {
+		return x.Cache
+	}
+	return CacheStatus_CACHE_STATUS_UNSPECIFIED
+}
+
+func (x *Cover) GetImageInfo() *ImageInfo {
+	if x != nil {
+		return x.ImageInfo
+	}
+	return nil
+}
+
+// ImageInfo describes the full-size cover.
+type ImageInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Width         int32                  `protobuf:"varint,1,opt,name=width,proto3" json:"width,omitempty"`
+	Height        int32                  `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	Bytes         int64                  `protobuf:"varint,3,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	MimeType      string                 `protobuf:"bytes,4,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	DominantColor string                 `protobuf:"bytes,5,opt,name=dominant_color,json=dominantColor,proto3" json:"dominant_color,omitempty"`
+	AverageColor  string                 `protobuf:"bytes,6,opt,name=average_color,json=averageColor,proto3" json:"average_color,omitempty"`
+	BlurHash      string                 `protobuf:"bytes,7,opt,name=blur_hash,json=blurHash,proto3" json:"blur_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImageInfo) Reset() {
+	*x = ImageInfo{}
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImageInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImageInfo) ProtoMessage() {}
+
+func (x *ImageInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_bookcover_v1_bookcover_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImageInfo.ProtoReflect.Descriptor instead.
+func (*ImageInfo) Descriptor() ([]byte, []int) {
+	return file_bookcover_v1_bookcover_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ImageInfo) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *ImageInfo) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *ImageInfo) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *ImageInfo) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *ImageInfo) GetDominantColor() string {
+	if x != nil {
+		return x.DominantColor
+	}
+	return ""
+}
+
+func (x *ImageInfo) GetAverageColor() string {
+	if x != nil {
+		return x.AverageColor
+	}
+	return ""
+}
+
+func (x *ImageInfo) GetBlurHash() string {
+	if x != nil {
+		return x.BlurHash
+	}
+	return ""
+}
+
+var File_bookcover_v1_bookcover_proto protoreflect.FileDescriptor
+
+const file_bookcover_v1_bookcover_proto_rawDesc = "" +
+	"\n" +
+	"\x1cbookcover/v1/bookcover.proto\x12\fbookcover.v1\"\x8e\x01\n" +
+	"\fImageOptions\x12+\n" +
+	"\x04size\x18\x01 \x01(\x0e2\x17.bookcover.v1.ImageSizeR\x04size\x12\x14\n" +
+	"\x05width\x18\x02 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x03 \x01(\x05R\x06height\x12#\n" +
+	"\x03fit\x18\x04 \x01(\x0e2\x11.bookcover.v1.FitR\x03fit\"\x8b\x01\n" +
+	"\x15GetCoverByISBNRequest\x12\x12\n" +
+	"\x04isbn\x18\x01 \x01(\tR\x04isbn\x120\n" +
+	"\x05image\x18\x02 \x01(\v2\x1a.bookcover.v1.ImageOptionsR\x05image\x12,\n" +
+	"\x12include_image_info\x18\x03 \x01(\bR\x10includeImageInfo\"\xbe\x01\n" +
+	"\x1cGetCoverByTitleAuthorRequest\x12\x1d\n" +
+	"\n" +
+	"book_title\x18\x01 \x01(\tR\tbookTitle\x12\x1f\n" +
+	"\vauthor_name\x18\x02 \x01(\tR\n" +
+	"authorName\x120\n" +
+	"\x05image\x18\x03 \x01(\v2\x1a.bookcover.v1.ImageOptionsR\x05image\x12,\n" +
+	"\x12include_image_info\x18\x04 \x01(\bR\x10includeImageInfo\"_\n" +
+	"\tBatchItem\x12\x12\n" +
+	"\x04isbn\x18\x01 \x01(\tR\x04isbn\x12\x1d\n" +
+	"\n" +
+	"book_title\x18\x02 \x01(\tR\tbookTitle\x12\x1f\n" +
+	"\vauthor_name\x18\x03 \x01(\tR\n" +
+	"authorName\"\xa6\x01\n" +
+	"\x15BatchGetCoversRequest\x12-\n" +
+	"\x05items\x18\x01 \x03(\v2\x17.bookcover.v1.BatchItemR\x05items\x120\n" +
+	"\x05image\x18\x02 \x01(\v2\x1a.bookcover.v1.ImageOptionsR\x05image\x12,\n" +
+	"\x12include_image_info\x18\x03 \x01(\bR\x10includeImageInfo\"\xbf\x01\n" +
+	"\x16BatchGetCoversResponse\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12+\n" +
+	"\x04item\x18\x02 \x01(\v2\x17.bookcover.v1.BatchItemR\x04item\x12+\n" +
+	"\x05cover\x18\x03 \x01(\v2\x13.bookcover.v1.CoverH\x00R\x05cover\x12+\n" +
+	"\x05error\x18\x04 \x01(\v2\x13.bookcover.v1.ErrorH\x00R\x05errorB\b\n" +
+	"\x06result\"5\n" +
+	"\x05Error\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\x9a\x01\n" +
+	"\x05Cover\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\tR\x06source\x12/\n" +
+	"\x05cache\x18\x03 \x01(\x0e2\x19.bookcover.v1.CacheStatusR\x05cache\x126\n" +
+	"\n" +
+	"image_info\x18\x04 \x01(\v2\x17.bookcover.v1.ImageInfoR\timageInfo\"\xd5\x01\n" +
+	"\tImageInfo\x12\x14\n" +
+	"\x05width\x18\x01 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x05R\x06height\x12\x14\n" +
+	"\x05bytes\x18\x03 \x01(\x03R\x05bytes\x12\x1b\n" +
+	"\tmime_type\x18\x04 \x01(\tR\bmimeType\x12%\n" +
+	"\x0edominant_color\x18\x05 \x01(\tR\rdominantColor\x12#\n" +
+	"\raverage_color\x18\x06 \x01(\tR\faverageColor\x12\x1b\n" +
+	"\tblur_hash\x18\a \x01(\tR\bblurHash*j\n" +
+	"\tImageSize\x12\x1a\n" +
+	"\x16IMAGE_SIZE_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10IMAGE_SIZE_SMALL\x10\x01\x12\x15\n" +
+	"\x11IMAGE_SIZE_MEDIUM\x10\x02\x12\x14\n" +
+	"\x10IMAGE_SIZE_LARGE\x10\x03*8\n" +
+	"\x03Fit\x12\x13\n" +
+	"\x0fFIT_UNSPECIFIED\x10\x00\x12\x0f\n" +
+	"\vFIT_CONTAIN\x10\x01\x12\v\n" +
+	"\aFIT_PAD\x10\x02*p\n" +
+	"\vCacheStatus\x12\x1c\n" +
+	"\x18CACHE_STATUS_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10CACHE_STATUS_HIT\x10\x01\x12\x15\n" +
+	"\x11CACHE_STATUS_MISS\x10\x02\x12\x16\n" +
+	"\x12CACHE_STATUS_STALE\x10\x032\x93\x02\n" +
+	"\fCoverService\x12J\n" +
+	"\x0eGetCoverByISBN\x12#.bookcover.v1.GetCoverByISBNRequest\x1a\x13.bookcover.v1.Cover\x12X\n" +
+	"\x15GetCoverByTitleAuthor\x12*.bookcover.v1.GetCoverByTitleAuthorRequest\x1a\x13.bookcover.v1.Cover\x12]\n" +
+	"\x0eBatchGetCovers\x12#.bookcover.v1.BatchGetCoversRequest\x1a$.bookcover.v1.BatchGetCoversResponse0\x01B+Z)bookcover-api/pkg/bookcoverpb;bookcoverpbb\x06proto3"
+
+var (
+	file_bookcover_v1_bookcover_proto_rawDescOnce sync.Once
+	file_bookcover_v1_bookcover_proto_rawDescData []byte
+)
+
+func file_bookcover_v1_bookcover_proto_rawDescGZIP() []byte {
+	file_bookcover_v1_bookcover_proto_rawDescOnce.Do(func() {
+		file_bookcover_v1_bookcover_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_bookcover_v1_bookcover_proto_rawDesc), len(file_bookcover_v1_bookcover_proto_rawDesc)))
+	})
+	return file_bookcover_v1_bookcover_proto_rawDescData
+}
+
+var file_bookcover_v1_bookcover_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_bookcover_v1_bookcover_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_bookcover_v1_bookcover_proto_goTypes = []any{
+	(ImageSize)(0),                       // 0: bookcover.v1.ImageSize
+	(Fit)(0),                             // 1: bookcover.v1.Fit
+	(CacheStatus)(0),                     // 2: bookcover.v1.CacheStatus
+	(*ImageOptions)(nil),                 // 3: bookcover.v1.ImageOptions
+	(*GetCoverByISBNRequest)(nil),        // 4: bookcover.v1.GetCoverByISBNRequest
+	(*GetCoverByTitleAuthorRequest)(nil), // 5: bookcover.v1.GetCoverByTitleAuthorRequest
+	(*BatchItem)(nil),                    // 6: bookcover.v1.BatchItem
+	(*BatchGetCoversRequest)(nil),        // 7: bookcover.v1.BatchGetCoversRequest
+	(*BatchGetCoversResponse)(nil),       // 8: bookcover.v1.BatchGetCoversResponse
+	(*Error)(nil),                        // 9: bookcover.v1.Error
+	(*Cover)(nil),                        // 10: bookcover.v1.Cover
+	(*ImageInfo)(nil),                    // 11: bookcover.v1.ImageInfo
+}
+var file_bookcover_v1_bookcover_proto_depIdxs = []int32{
+	0,  // 0: bookcover.v1.ImageOptions.size:type_name -> bookcover.v1.ImageSize
+	1,  // 1: bookcover.v1.ImageOptions.fit:type_name -> bookcover.v1.Fit
+	3,  // 2: bookcover.v1.GetCoverByISBNRequest.image:type_name -> bookcover.v1.ImageOptions
+	3,  // 3: bookcover.v1.GetCoverByTitleAuthorRequest.image:type_name -> bookcover.v1.ImageOptions
+	6,  // 4: bookcover.v1.BatchGetCoversRequest.items:type_name -> bookcover.v1.BatchItem
+	3,  // 5: bookcover.v1.BatchGetCoversRequest.image:type_name -> bookcover.v1.ImageOptions
+	6,  // 6: bookcover.v1.BatchGetCoversResponse.item:type_name -> bookcover.v1.BatchItem
+	10, // 7: bookcover.v1.BatchGetCoversResponse.cover:type_name -> bookcover.v1.Cover
+	9,  // 8: bookcover.v1.BatchGetCoversResponse.error:type_name -> bookcover.v1.Error
+	2,  // 9: bookcover.v1.Cover.cache:type_name -> bookcover.v1.CacheStatus
+	11, // 10: bookcover.v1.Cover.image_info:type_name -> bookcover.v1.ImageInfo
+	4,  // 11: bookcover.v1.CoverService.GetCoverByISBN:input_type -> bookcover.v1.GetCoverByISBNRequest
+	5,  // 12: bookcover.v1.CoverService.GetCoverByTitleAuthor:input_type -> bookcover.v1.GetCoverByTitleAuthorRequest
+	7,  // 13: bookcover.v1.CoverService.BatchGetCovers:input_type -> bookcover.v1.BatchGetCoversRequest
+	10, // 14: bookcover.v1.CoverService.GetCoverByISBN:output_type -> bookcover.v1.Cover
+	10, // 15: bookcover.v1.CoverService.GetCoverByTitleAuthor:output_type -> bookcover.v1.Cover
+	8,  // 16: bookcover.v1.CoverService.BatchGetCovers:output_type -> bookcover.v1.BatchGetCoversResponse
+	14, // [14:17] is the sub-list for method output_type
+	11, // [11:14] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_bookcover_v1_bookcover_proto_init() }
+func file_bookcover_v1_bookcover_proto_init() {
+	if File_bookcover_v1_bookcover_proto != nil {
+		return
+	}
+	file_bookcover_v1_bookcover_proto_msgTypes[5].OneofWrappers = []any{
+		(*BatchGetCoversResponse_Cover)(nil),
+		(*BatchGetCoversResponse_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bookcover_v1_bookcover_proto_rawDesc), len(file_bookcover_v1_bookcover_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_bookcover_v1_bookcover_proto_goTypes,
+		DependencyIndexes: file_bookcover_v1_bookcover_proto_depIdxs,
+		EnumInfos:         file_bookcover_v1_bookcover_proto_enumTypes,
+		MessageInfos:      file_bookcover_v1_bookcover_proto_msgTypes,
+	}.Build()
+	File_bookcover_v1_bookcover_proto = out.File
+	file_bookcover_v1_bookcover_proto_goTypes = nil
+	file_bookcover_v1_bookcover_proto_depIdxs = nil
+}
@@ -0,0 +1,215 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.32.1
+// source: bookcover/v1/bookcover.proto
+
+package bookcoverpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CoverService_GetCoverByISBN_FullMethodName        = "/bookcover.v1.CoverService/GetCoverByISBN"
+	CoverService_GetCoverByTitleAuthor_FullMethodName = "/bookcover.v1.CoverService/GetCoverByTitleAuthor"
+	CoverService_BatchGetCovers_FullMethodName        = "/bookcover.v1.CoverService/BatchGetCovers"
+)
+
+// CoverServiceClient is the client API for CoverService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CoverService looks up book covers. It serves the same lookups as the
+// /v1/covers HTTP API.
+type CoverServiceClient interface {
+	// GetCoverByISBN looks a cover up by ISBN-13. Dashes are ignored.
+	GetCoverByISBN(ctx context.Context, in *GetCoverByISBNRequest, opts ...grpc.CallOption) (*Cover, error)
+	// GetCoverByTitleAuthor looks a cover up by book title and author name.
+	GetCoverByTitleAuthor(ctx context.Context, in *GetCoverByTitleAuthorRequest, opts ...grpc.CallOption) (*Cover, error)
+	// BatchGetCovers looks many covers up at once. One response is streamed per
+	// item as soon as its lookup finishes, so responses may arrive out of order.
+	BatchGetCovers(ctx context.Context, in *BatchGetCoversRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BatchGetCoversResponse], error)
+}
+
+type coverServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCoverServiceClient(cc grpc.ClientConnInterface) CoverServiceClient {
+	return &coverServiceClient{cc}
+}
+
+func (c *coverServiceClient) GetCoverByISBN(ctx context.Context, in *GetCoverByISBNRequest, opts ...grpc.CallOption) (*Cover, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Cover)
+	err := c.cc.Invoke(ctx, CoverService_GetCoverByISBN_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coverServiceClient) GetCoverByTitleAuthor(ctx context.Context, in *GetCoverByTitleAuthorRequest, opts ...grpc.CallOption) (*Cover, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Cover)
+	err := c.cc.Invoke(ctx, CoverService_GetCoverByTitleAuthor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coverServiceClient) BatchGetCovers(ctx context.Context, in *BatchGetCoversRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BatchGetCoversResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CoverService_ServiceDesc.Streams[0], CoverService_BatchGetCovers_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BatchGetCoversRequest, BatchGetCoversResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CoverService_BatchGetCoversClient = grpc.ServerStreamingClient[BatchGetCoversResponse]
+
+// CoverServiceServer is the server API for CoverService service.
+// All implementations must embed UnimplementedCoverServiceServer
+// for forward compatibility.
+//
+// CoverService looks up book covers. It serves the same lookups as the
+// /v1/covers HTTP API.
+type CoverServiceServer interface {
+	// GetCoverByISBN looks a cover up by ISBN-13. Dashes are ignored.
+	GetCoverByISBN(context.Context, *GetCoverByISBNRequest) (*Cover, error)
+	// GetCoverByTitleAuthor looks a cover up by book title and author name.
+	GetCoverByTitleAuthor(context.Context, *GetCoverByTitleAuthorRequest) (*Cover, error)
+	// BatchGetCovers looks many covers up at once. One response is streamed per
+	// item as soon as its lookup finishes, so responses may arrive out of order.
+	BatchGetCovers(*BatchGetCoversRequest, grpc.ServerStreamingServer[BatchGetCoversResponse]) error
+	mustEmbedUnimplementedCoverServiceServer()
+}
+
+// UnimplementedCoverServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCoverServiceServer struct{}
+
+func (UnimplementedCoverServiceServer) GetCoverByISBN(context.Context, *GetCoverByISBNRequest) (*Cover, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCoverByISBN not implemented")
+}
+func (UnimplementedCoverServiceServer) GetCoverByTitleAuthor(context.Context, *GetCoverByTitleAuthorRequest) (*Cover, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCoverByTitleAuthor not implemented")
+}
+func (UnimplementedCoverServiceServer) BatchGetCovers(*BatchGetCoversRequest, grpc.ServerStreamingServer[BatchGetCoversResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method BatchGetCovers not implemented")
+}
+func (UnimplementedCoverServiceServer) mustEmbedUnimplementedCoverServiceServer() {}
+func (UnimplementedCoverServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeCoverServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CoverServiceServer will
+// result in compilation errors.
+type UnsafeCoverServiceServer interface {
+	mustEmbedUnimplementedCoverServiceServer()
+}
+
+func RegisterCoverServiceServer(s grpc.ServiceRegistrar, srv CoverServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCoverServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CoverService_ServiceDesc, srv)
+}
+
+func _CoverService_GetCoverByISBN_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCoverByISBNRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoverServiceServer).GetCoverByISBN(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CoverService_GetCoverByISBN_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoverServiceServer).GetCoverByISBN(ctx, req.(*GetCoverByISBNRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoverService_GetCoverByTitleAuthor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCoverByTitleAuthorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoverServiceServer).GetCoverByTitleAuthor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CoverService_GetCoverByTitleAuthor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoverServiceServer).GetCoverByTitleAuthor(ctx, req.(*GetCoverByTitleAuthorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CoverService_BatchGetCovers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BatchGetCoversRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CoverServiceServer).BatchGetCovers(m, &grpc.GenericServerStream[BatchGetCoversRequest, BatchGetCoversResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CoverService_BatchGetCoversServer = grpc.ServerStreamingServer[BatchGetCoversResponse]
+
+// CoverService_ServiceDesc is the grpc.ServiceDesc for CoverService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CoverService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bookcover.v1.CoverService",
+	HandlerType: (*CoverServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCoverByISBN",
+			Handler:    _CoverService_GetCoverByISBN_Handler,
+		},
+		{
+			MethodName: "GetCoverByTitleAuthor",
+			Handler:    _CoverService_GetCoverByTitleAuthor_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "BatchGetCovers",
+			Handler:       _CoverService_BatchGetCovers_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "bookcover/v1/bookcover.proto",
+}
@@ -16,6 +16,22 @@ func Success(w http.ResponseWriter, url string) []byte {
 	return buffer.Bytes()
 }
 
+// SuccessWith writes a successful JSON response with the given URL and
+// additional fields
+func SuccessWith(w http.ResponseWriter, url string, fields map[string]any) []byte {
+	body := map[string]any{"url": url}
+	for key, value := range fields {
+		body[key] = value
+	}
+
+	var buffer bytes.Buffer
+	enc := json.NewEncoder(&buffer)
+	enc.SetEscapeHTML(false)
+	enc.Encode(body)
+	w.WriteHeader(http.StatusOK)
+	return buffer.Bytes()
+}
+
 // Error writes an error JSON response with the given status code and message
 func Error(w http.ResponseWriter, statusCode int, message string) []byte {
 	data, err := json.Marshal(map[string]string{"error": message})
@@ -26,3 +42,50 @@ func Error(w http.ResponseWriter, statusCode int, message string) []byte {
 	w.WriteHeader(statusCode)
 	return data
 }
+
+// Meta describes how a versioned API response was produced.
+type Meta struct {
+	RequestID string `json:"request_id,omitempty"`
+	// Source is the provider that found the cover.
+	Source string `json:"source,omitempty"`
+	// Cache is hit, miss or stale.
+	Cache string `json:"cache,omitempty"`
+}
+
+// Problem is the error of a versioned API response. Code is stable and meant
+// for programs; Message is meant for people.
+type Problem struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// envelope is the body of every versioned API response. Data is null when
+// Error is set.
+type envelope struct {
+	Data  any      `json:"data"`
+	Error *Problem `json:"error,omitempty"`
+	Meta  Meta     `json:"meta"`
+}
+
+// Envelope writes a successful versioned API response
+func Envelope(w http.ResponseWriter, data any, meta Meta) []byte {
+	return writeEnvelope(w, http.StatusOK, envelope{Data: data, Meta: meta})
+}
+
+// EnvelopeError writes a failed versioned API response with the given status
+// code, error code and message
+func EnvelopeError(w http.ResponseWriter, statusCode int, code, message string, meta Meta) []byte {
+	return writeEnvelope(w, statusCode, envelope{Error: &Problem{Code: code, Message: message}, Meta: meta})
+}
+
+func writeEnvelope(w http.ResponseWriter, statusCode int, body envelope) []byte {
+	var buffer bytes.Buffer
+	enc := json.NewEncoder(&buffer)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(body); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil
+	}
+	w.WriteHeader(statusCode)
+	return buffer.Bytes()
+}
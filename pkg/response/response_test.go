@@ -106,3 +106,29 @@ func TestError_EmptyMessage(t *testing.T) {
 		t.Errorf("expected empty error field, got %q", result["error"])
 	}
 }
+
+func TestEnvelope_Body(t *testing.T) {
+	rr := httptest.NewRecorder()
+	body := Envelope(rr, map[string]string{"url": "https://example.com/a.jpg?x=1&y=2"}, Meta{RequestID: "abc", Source: "goodreads", Cache: "hit"})
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", rr.Code)
+	}
+	want := `{"data":{"url":"https://example.com/a.jpg?x=1&y=2"},"meta":{"request_id":"abc","source":"goodreads","cache":"hit"}}` + "\n"
+	if string(body) != want {
+		t.Errorf("expected body %s, got %s", want, body)
+	}
+}
+
+func TestEnvelopeError_Body(t *testing.T) {
+	rr := httptest.NewRecorder()
+	body := EnvelopeError(rr, http.StatusNotFound, "not_found", "Book not found", Meta{RequestID: "abc"})
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", rr.Code)
+	}
+	want := `{"data":null,"error":{"code":"not_found","message":"Book not found"},"meta":{"request_id":"abc"}}` + "\n"
+	if string(body) != want {
+		t.Errorf("expected body %s, got %s", want, body)
+	}
+}
@@ -2,5 +2,5 @@ package static
 
 import "embed"
 
-//go:embed index.html
+//go:embed index.html openapi.json
 var Files embed.FS